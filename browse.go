@@ -0,0 +1,411 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/tf2d2/terrastruct-icons/icons"
+)
+
+// Panes of the browser, from left to right
+const (
+	paneProviders = iota
+	paneCategories
+	paneIcons
+	paneCount
+)
+
+const browseHelp = "↑/↓ move  ←/→ tab pane  / search  esc clear  c copy slug  i copy iconify id  q quit"
+
+var (
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240")).Padding(0, 1)
+	focusedStyle  = paneStyle.BorderForeground(lipgloss.Color("63"))
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	dimStyle      = lipgloss.NewStyle().Faint(true)
+)
+
+// browse opens a full-screen browser for a dataset directory
+func browse(args []string) int {
+	fs := flag.NewFlagSet("browse", flag.ExitOnError)
+	fs.Parse(args)
+	dir := "output"
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	d, err := icons.LoadDataset(dir)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+
+	if _, err := tea.NewProgram(newBrowser(d, dir), tea.WithAltScreen()).Run(); err != nil {
+		log.Printf("❌ %v", err)
+		return 1
+	}
+	return 0
+}
+
+// browser walks a dataset in three panes, providers, their categories and
+// the icons of the selected category, with a preview of the selected icon.
+// A search replaces the icons pane with the matches in the selected provider
+type browser struct {
+	d   *icons.Dataset
+	dir string
+
+	providers  []string
+	categories []string
+	icons      []*icons.IconPayload
+
+	pane      int
+	cursor    [paneCount]int
+	query     string
+	searching bool
+	status    string
+
+	width, height int
+}
+
+func newBrowser(d *icons.Dataset, dir string) *browser {
+	b := &browser{d: d, dir: dir}
+	seen := make(map[string]bool)
+	for _, icon := range d.Icons {
+		if key := providerKey(icon); !seen[key] {
+			seen[key] = true
+			b.providers = append(b.providers, key)
+		}
+	}
+	sort.Strings(b.providers)
+	b.refresh(paneCategories)
+	return b
+}
+
+func (b *browser) Init() tea.Cmd {
+	return nil
+}
+
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		if b.searching {
+			return b, b.editQuery(msg)
+		}
+		return b, b.key(msg)
+	}
+	return b, nil
+}
+
+// key handles a key outside the search field
+func (b *browser) key(msg tea.KeyMsg) tea.Cmd {
+	b.status = ""
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		b.move(-1)
+	case "down", "j":
+		b.move(1)
+	case "pgup":
+		b.move(-b.listHeight())
+	case "pgdown":
+		b.move(b.listHeight())
+	case "home", "g":
+		b.move(-len(b.list(b.pane)))
+	case "end", "G":
+		b.move(len(b.list(b.pane)))
+	case "left", "h", "shift+tab":
+		b.pane = (b.pane + paneCount - 1) % paneCount
+	case "right", "l", "tab", "enter":
+		b.pane = (b.pane + 1) % paneCount
+	case "/":
+		b.searching = true
+	case "esc":
+		if b.query != "" {
+			b.query = ""
+			b.refresh(paneIcons)
+		}
+	case "c":
+		if icon := b.selected(); icon != nil {
+			return b.copy(icon.Slug)
+		}
+	case "i":
+		if icon := b.selected(); icon != nil && icon.IconifyID != "" {
+			return b.copy(icon.IconifyID)
+		}
+	}
+	return nil
+}
+
+// editQuery handles a key in the search field, searching as the query changes
+func (b *browser) editQuery(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return tea.Quit
+	case tea.KeyEnter:
+		b.searching = false
+		b.pane = paneIcons
+		return nil
+	case tea.KeyEsc:
+		b.searching = false
+		b.query = ""
+	case tea.KeyBackspace:
+		if r := []rune(b.query); len(r) > 0 {
+			b.query = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		b.query += string(msg.Runes)
+	default:
+		return nil
+	}
+	b.refresh(paneIcons)
+	return nil
+}
+
+// move moves the cursor of the focused pane by delta, refreshing the panes
+// to its right
+func (b *browser) move(delta int) {
+	n := len(b.list(b.pane))
+	if n == 0 {
+		return
+	}
+	c := max(0, min(n-1, b.cursor[b.pane]+delta))
+	if c == b.cursor[b.pane] {
+		return
+	}
+	b.cursor[b.pane] = c
+	if b.pane < paneIcons {
+		b.refresh(b.pane + 1)
+	}
+}
+
+// refresh recomputes the pane and every pane to its right
+func (b *browser) refresh(pane int) {
+	provider := pick(b.providers, b.cursor[paneProviders])
+	if pane <= paneCategories {
+		seen := make(map[string]bool)
+		b.categories = b.categories[:0]
+		for _, icon := range b.d.Icons {
+			if c := categoryOf(icon); providerKey(icon) == provider && !seen[c] {
+				seen[c] = true
+				b.categories = append(b.categories, c)
+			}
+		}
+		sort.Strings(b.categories)
+		b.cursor[paneCategories] = 0
+	}
+
+	b.icons = b.icons[:0]
+	if b.query != "" {
+		b.icons = b.search(provider)
+	} else {
+		category := pick(b.categories, b.cursor[paneCategories])
+		for _, icon := range b.d.Icons {
+			if providerKey(icon) == provider && categoryOf(icon) == category {
+				b.icons = append(b.icons, icon)
+			}
+		}
+		sort.Slice(b.icons, func(i, j int) bool { return b.icons[i].Slug < b.icons[j].Slug })
+	}
+	b.cursor[paneIcons] = 0
+}
+
+// search ranks the icons of provider with Dataset.Search, falling back to
+// matching the letters of the query in order within the slugs
+func (b *browser) search(provider string) []*icons.IconPayload {
+	var found []*icons.IconPayload
+	for _, r := range b.d.Search(b.query, icons.SearchOptions{}) {
+		if providerKey(r.Icon) == provider {
+			found = append(found, r.Icon)
+		}
+	}
+	if len(found) > 0 {
+		return found
+	}
+	letters := strings.ToLower(strings.ReplaceAll(b.query, " ", ""))
+	for _, icon := range b.d.Icons {
+		if providerKey(icon) == provider && subsequence(strings.ToLower(icon.Slug), letters) {
+			found = append(found, icon)
+		}
+	}
+	return found
+}
+
+// list returns the lines of pane
+func (b *browser) list(pane int) []string {
+	switch pane {
+	case paneProviders:
+		return b.providers
+	case paneCategories:
+		return b.categories
+	}
+	lines := make([]string, len(b.icons))
+	for i, icon := range b.icons {
+		lines[i] = icon.Slug
+	}
+	return lines
+}
+
+// selected returns the icon under the cursor of the icons pane
+func (b *browser) selected() *icons.IconPayload {
+	if c := b.cursor[paneIcons]; c < len(b.icons) {
+		return b.icons[c]
+	}
+	return nil
+}
+
+// copy copies value to the clipboard of the terminal
+func (b *browser) copy(value string) tea.Cmd {
+	b.status = "copied " + value
+	return func() tea.Msg {
+		copyToClipboard(os.Stdout, value)
+		return nil
+	}
+}
+
+// listHeight is the number of lines a pane shows, below its border and title
+func (b *browser) listHeight() int {
+	return max(1, b.height-6)
+}
+
+func (b *browser) View() string {
+	if b.width == 0 {
+		return ""
+	}
+	// three list panes of a fixed share of the width, the preview takes the
+	// rest; widths include the padding but not the border of the panes
+	listWidth := max(12, b.width/6)
+	iconsWidth := max(16, b.width/4)
+	previewWidth := max(10, b.width-2*listWidth-iconsWidth-paneCount*2-2)
+	height := b.listHeight()
+
+	titles := [paneCount]string{"Providers", "Categories", "Icons"}
+	if b.query != "" {
+		titles[paneIcons] = fmt.Sprintf("Icons matching %q", b.query)
+	}
+	widths := [paneCount]int{listWidth, listWidth, iconsWidth}
+	var panes []string
+	for pane := 0; pane < paneCount; pane++ {
+		panes = append(panes, b.renderList(pane, titles[pane], widths[pane], height))
+	}
+	panes = append(panes, paneStyle.Width(previewWidth).Height(height+1).Render(b.preview(previewWidth, height)))
+
+	footer := dimStyle.Render(browseHelp)
+	switch {
+	case b.searching:
+		footer = "/" + b.query + "█"
+	case b.status != "":
+		footer = b.status
+	}
+	header := titleStyle.Render(fmt.Sprintf("%d icons in %s", len(b.d.Icons), b.dir))
+	return lipgloss.JoinVertical(lipgloss.Left, header, lipgloss.JoinHorizontal(lipgloss.Top, panes...), footer)
+}
+
+// renderList renders pane with its cursor scrolled into view
+func (b *browser) renderList(pane int, title string, width, height int) string {
+	lines := b.list(pane)
+	cursor := b.cursor[pane]
+	offset := max(0, cursor-height+1)
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(truncate(title, width-2)))
+	for i := offset; i < len(lines) && i < offset+height; i++ {
+		line := truncate(lines[i], width-2)
+		if i == cursor {
+			line = selectedStyle.Render(line)
+		}
+		sb.WriteString("\n" + line)
+	}
+	style := paneStyle
+	if pane == b.pane {
+		style = focusedStyle
+	}
+	return style.Width(width).Height(height + 1).Render(sb.String())
+}
+
+// preview returns the metadata of the selected icon as indented JSON,
+// leaving out the document and provenance, which repeat it
+func (b *browser) preview(width, height int) string {
+	icon := b.selected()
+	if icon == nil {
+		return dimStyle.Render("no icon selected")
+	}
+	preview := *icon
+	preview.Document = ""
+	preview.Provenance = nil
+	data, err := json.MarshalIndent(&preview, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	lines := strings.Split(string(data), "\n")
+	if len(lines) > height+1 {
+		lines = lines[:height+1]
+	}
+	for i, line := range lines {
+		lines[i] = truncate(line, width-2)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pick returns the element at i of values, empty when out of range
+func pick(values []string, i int) string {
+	if i < len(values) {
+		return values[i]
+	}
+	return ""
+}
+
+// truncate cuts s to width cells, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && lipgloss.Width(string(r))+1 > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+// providerKey returns the provider directory of icon, e.g. aws
+func providerKey(icon *icons.IconPayload) string {
+	if p, ok := icons.Providers.ByDisplayName(icon.Provider); ok {
+		return p.Dir
+	}
+	return strings.ToLower(icon.Provider)
+}
+
+func categoryOf(icon *icons.IconPayload) string {
+	if icon.Category == "" {
+		return "uncategorized"
+	}
+	return icon.Category
+}
+
+// subsequence reports whether the letters of sub appear in s in order
+func subsequence(s, sub string) bool {
+	for _, r := range sub {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
+
+// copyToClipboard sets the clipboard of the terminal with an OSC 52 escape
+// sequence, which works over SSH but not in every terminal
+func copyToClipboard(w io.Writer, value string) {
+	fmt.Fprintf(w, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(value)))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tf2d2/terrastruct-icons/icons"
+)
+
+// eval scores search against an evaluation set, exiting with 1 when a
+// metric is below its minimum and 2 when it could not run
+func eval(args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	setPath := fs.String("set", "", "YAML evaluation set, the built-in set when empty")
+	dir := fs.String("dir", "output", "dataset directory to search")
+	endpoint := fs.String("endpoint", "", "search endpoint to query instead of -dir, e.g. http://localhost:8080/search")
+	apiKey := fs.String("api-key", os.Getenv("ICONS_API_KEY"), "API key sent to -endpoint, defaults to $ICONS_API_KEY")
+	k := fs.Int("k", 0, "cutoff of hit@k and MRR, the set's when 0")
+	minHit := fs.Float64("min-hit", 0, "fail when hit@k is below this share")
+	minMRR := fs.Float64("min-mrr", 0, "fail when MRR is below this value")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	var (
+		set *icons.EvalSet
+		err error
+	)
+	if *setPath != "" {
+		set, err = icons.LoadEvalSet(*setPath)
+	} else {
+		set, err = icons.DefaultEvalSet()
+	}
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	if *k > 0 {
+		set.K = *k
+	}
+
+	var search icons.SearchFunc
+	if *endpoint != "" {
+		search = func(query string, opts icons.SearchOptions) ([]icons.SearchResult, error) {
+			return icons.RemoteSearch(context.Background(), *endpoint, *apiKey, query, opts)
+		}
+	} else {
+		d, err := icons.LoadDataset(*dir)
+		if err != nil {
+			log.Printf("❌ %v", err)
+			return 2
+		}
+		search = func(query string, opts icons.SearchOptions) ([]icons.SearchResult, error) {
+			return d.Search(query, opts), nil
+		}
+	}
+	report, err := icons.Evaluate(set, search)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+
+	if *asJSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		e.Encode(report)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RANK\tQUERY\tTOP")
+		for _, r := range report.Results {
+			rank := "-"
+			if r.Rank > 0 {
+				rank = fmt.Sprint(r.Rank)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", rank, r.Query, strings.Join(r.Top, ", "))
+		}
+		w.Flush()
+		fmt.Printf("%d queries, hit@%d %.2f, MRR %.3f\n", report.Queries, report.K, report.HitAtK, report.MRR)
+	}
+	if report.HitAtK < *minHit || report.MRR < *minMRR {
+		return 1
+	}
+	return 0
+}
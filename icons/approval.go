@@ -0,0 +1,41 @@
+package icons
+
+import "fmt"
+
+// Approval statuses recorded per icon. Curators set approved and rejected in
+// the overrides file, every run derives auto and pending_review from the
+// confidence of the enrichment fields
+const (
+	ApprovalAuto     = "auto"
+	ApprovalPending  = "pending_review"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// approvalStatuses are the valid approval statuses
+var approvalStatuses = map[string]bool{ApprovalAuto: true, ApprovalPending: true, ApprovalApproved: true, ApprovalRejected: true}
+
+// validApproval rejects unknown approval statuses
+func validApproval(status string) error {
+	if !approvalStatuses[status] {
+		return fmt.Errorf("unknown approval status %q", status)
+	}
+	return nil
+}
+
+// assignApprovals sets the approval status of the icons without a curator
+// decision in this run's overrides: pending_review when a field is below
+// threshold, auto otherwise. Decisions removed from the overrides file since
+// the previous run are dropped rather than carried over by their provenance
+func assignApprovals(icons []*IconPayload, overrides map[string]Override, threshold float32, timestamp string) {
+	for _, icon := range icons {
+		if o, ok := overrides[icon.Slug]; ok && o.ApprovalStatus != nil {
+			continue
+		}
+		icon.ApprovalStatus = ApprovalAuto
+		if len(reviewFields(icon, threshold)) > 0 {
+			icon.ApprovalStatus = ApprovalPending
+		}
+		icon.setProvenance(SourceRules, timestamp, "approval_status")
+	}
+}
@@ -0,0 +1,126 @@
+package icons
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const assertionsFile = "assertions_report.json"
+
+var assertionRgx = regexp.MustCompile(`^\s*([a-z0-9_-]+)\s*(>=|<=|==|>|<)\s*([0-9.]+)\s*(%?)\s*$`)
+
+// AssertionResult is the outcome of a single coverage assertion
+type AssertionResult struct {
+	Assertion string  `json:"assertion"`
+	Actual    float64 `json:"actual"`
+	Passed    bool    `json:"passed"`
+}
+
+// coverageFields reports whether a field is populated for percent assertions
+var coverageFields = map[string]func(*IconPayload) bool{
+	"iconify_id": func(i *IconPayload) bool {
+		// a derived ID is a guess and an icon without provenance unverified
+		prov, ok := i.Provenance["iconify_id"]
+		return ok && i.IconifyID != "" && prov.Source != SourceRules
+	},
+	"semantic_profile": func(i *IconPayload) bool { return i.SemanticProfile != "" },
+	"technical_intent": func(i *IconPayload) bool { return i.TechnicalIntent != "" },
+	"description":      func(i *IconPayload) bool { return i.Description != "" },
+	"aliases":          func(i *IconPayload) bool { return len(jsonToArray(i.Aliases)) > 0 },
+	"tags":             func(i *IconPayload) bool { return len(jsonToArray(i.Tags)) > 0 },
+	"color_theme":      func(i *IconPayload) bool { return i.ColorTheme != "" },
+	"shape_type":       func(i *IconPayload) bool { return i.ShapeType != "" },
+	"content_sha256":   func(i *IconPayload) bool { return i.ContentSHA256 != "" },
+	"document":         func(i *IconPayload) bool { return i.Document != "" },
+}
+
+// evaluateAssertions checks coverage assertions such as "aws >= 300",
+// "total >= 2000" or "iconify_id >= 95%" against icons
+func evaluateAssertions(assertions []string, icons []*IconPayload) ([]AssertionResult, error) {
+	perProvider := make(map[string]int)
+	for _, icon := range icons {
+		perProvider[providerDir(icon.Provider)]++
+	}
+
+	results := make([]AssertionResult, 0, len(assertions))
+	var failed []string
+	for _, a := range assertions {
+		m := assertionRgx.FindStringSubmatch(strings.ToLower(a))
+		if m == nil {
+			return nil, fmt.Errorf("invalid assertion %q", a)
+		}
+		subject, op, percent := m[1], m[2], m[4] == "%"
+		want, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %w", a, err)
+		}
+
+		var actual float64
+		switch field, isField := coverageFields[subject]; {
+		case subject == "total":
+			actual = float64(len(icons))
+			if percent {
+				actual = 100
+			}
+		case isField:
+			n := 0
+			for _, icon := range icons {
+				if field(icon) {
+					n++
+				}
+			}
+			actual = float64(n)
+			if percent {
+				actual = ratio(n, len(icons))
+			}
+		default:
+			actual = float64(perProvider[subject])
+			if percent {
+				actual = ratio(perProvider[subject], len(icons))
+			}
+		}
+
+		passed := compare(actual, op, want)
+		results = append(results, AssertionResult{Assertion: a, Actual: actual, Passed: passed})
+		if passed {
+			log.Printf("✅ Assertion %q (actual %.1f)", a, actual)
+		} else {
+			log.Printf("❌ Assertion %q failed (actual %.1f)", a, actual)
+			failed = append(failed, fmt.Sprintf("%s (actual %.1f)", a, actual))
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, fmt.Errorf("%w: %s", ErrCoverageRegressed, strings.Join(failed, "; "))
+	}
+	return results, nil
+}
+
+// ErrCoverageRegressed is returned when a coverage assertion fails
+var ErrCoverageRegressed = errors.New("coverage assertions failed")
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+func compare(actual float64, op string, want float64) bool {
+	switch op {
+	case ">=":
+		return actual >= want
+	case ">":
+		return actual > want
+	case "<=":
+		return actual <= want
+	case "<":
+		return actual < want
+	default:
+		return actual == want
+	}
+}
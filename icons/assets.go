@@ -0,0 +1,156 @@
+package icons
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	assetsDir       = "assets"
+	maxAssetSize    = 5 << 20
+	assetReqTimeout = 30 * time.Second
+)
+
+// assetClient returns the client used to download icon artwork, sharing the
+// scraper cache and fixtures
+func (c *Config) assetClient() *http.Client {
+	transport := c.baseTransport()
+	if c.CacheDir != "" {
+		transport = newCacheTransport(c.CacheDir, c.Offline, transport)
+	}
+	return &http.Client{Timeout: assetReqTimeout, Transport: c.transport(transport)}
+}
+
+// downloadAssets fetches the SVG of every icon into the provider assets
+// directory and records its SHA-256
+func downloadAssets(ctx context.Context, cfg *Config, icons []*IconPayload, timestamp string) (int, error) {
+	client := cfg.assetClient()
+	workers := cfg.Parallelism
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+		jobs   = make(chan *IconPayload)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for icon := range jobs {
+				if err := downloadAsset(ctx, client, cfg.OutputDir, icon, timestamp); err != nil {
+					log.Printf("⚠️  Asset %s: %v", icon.Slug, err)
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+				downloadVariants(ctx, client, cfg.OutputDir, icon)
+				if cfg.RequestDelay > 0 {
+					time.Sleep(cfg.RequestDelay)
+				}
+			}
+		}()
+	}
+
+	for _, icon := range icons {
+		select {
+		case jobs <- icon:
+		case <-ctx.Done():
+			close(jobs)
+			wg.Wait()
+			return failed, ctx.Err()
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return failed, nil
+}
+
+func downloadAsset(ctx context.Context, client *http.Client, outDir string, icon *IconPayload, timestamp string) error {
+	data, err := fetchAsset(ctx, client, icon.URL)
+	if err != nil {
+		return err
+	}
+
+	rel := filepath.Join(providerDir(icon.Provider), assetsDir, icon.Slug+".svg")
+	path := filepath.Join(outDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	icon.LocalPath = filepath.ToSlash(rel)
+	icon.ContentSHA256 = contentHash(data)
+	icon.setProvenance(SourceScraper, timestamp, "local_path", "content_sha256")
+	return nil
+}
+
+// contentHash returns the hex SHA-256 of data
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fetchAsset reads the artwork at rawURL, which may be a file:// URL from a
+// local source
+func fetchAsset(ctx context.Context, client *http.Client, rawURL string) ([]byte, error) {
+	if u, err := url.Parse(rawURL); err == nil && u.Scheme == "file" {
+		f, err := os.Open(filepath.FromSlash(u.Path))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readLimited(f, maxAssetSize)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return readLimited(resp.Body, maxAssetSize)
+}
+
+// readLimited reads r to the end, failing rather than truncating when it
+// holds more than limit bytes
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+// readAsset returns the SVG of icon downloaded under outDir
+func readAsset(outDir string, icon *IconPayload) ([]byte, error) {
+	if icon.LocalPath == "" {
+		return nil, fmt.Errorf("no asset downloaded for %s", icon.Slug)
+	}
+	return os.ReadFile(filepath.Join(outDir, filepath.FromSlash(icon.LocalPath)))
+}
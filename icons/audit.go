@@ -0,0 +1,257 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Audit issues
+const (
+	IssueMissingAsset     = "missing_asset"
+	IssueChecksumMismatch = "checksum_mismatch"
+	IssueOrphanAsset      = "orphan_asset"
+	IssueURLUnreachable   = "url_unreachable"
+	IssueURLMoved         = "url_moved"
+	IssueRemoteChanged    = "remote_changed"
+)
+
+// Repair actions
+const (
+	RepairDownload   = "download"
+	RepairDelete     = "delete"
+	RepairUpdateURL  = "update_url"
+	RepairRescrape   = "rescrape"
+	RepairRegenerate = "regenerate"
+)
+
+// AuditOptions configures Audit
+type AuditOptions struct {
+	// Remote also fetches the URL of every icon to detect drift
+	Remote bool
+	// Workers is the number of concurrent remote checks, 4 by default
+	Workers int
+	// Client defaults to the default client of the generator
+	Client *http.Client
+}
+
+// RepairStep is one entry of the repair plan of an audit
+type RepairStep struct {
+	Slug   string `json:"slug,omitempty"`
+	Issue  string `json:"issue"`
+	Action string `json:"action"`
+	// Path is the asset file, relative to the audited directory
+	Path string `json:"path,omitempty"`
+	URL  string `json:"url,omitempty"`
+	// NewURL is the URL a moved icon redirects to
+	NewURL string `json:"new_url,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// AuditReport is the machine-readable result of Audit
+type AuditReport struct {
+	Dir     string         `json:"dir"`
+	Icons   int            `json:"icons"`
+	Issues  map[string]int `json:"issues"`
+	Repairs []RepairStep   `json:"repairs"`
+}
+
+// Audit cross-checks the icons of the output in dir against their downloaded
+// assets, and with opts.Remote against their remote URLs, returning a repair
+// plan. It never modifies dir and runs independently of generation
+func Audit(ctx context.Context, dir string, opts AuditOptions) (*AuditReport, error) {
+	d, err := LoadDataset(dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(filepath.Join(dir, latestLink)); err == nil {
+		dir = filepath.Join(dir, latestLink)
+	}
+
+	report := &AuditReport{Dir: dir, Icons: len(d.Icons), Issues: map[string]int{}, Repairs: []RepairStep{}}
+	add := func(step RepairStep) {
+		report.Issues[step.Issue]++
+		report.Repairs = append(report.Repairs, step)
+	}
+
+	referenced := make(map[string]bool)
+	for _, icon := range d.Icons {
+		for _, rel := range []string{icon.LocalPath, icon.DarkLocalPath, icon.MonoLocalPath} {
+			if rel != "" {
+				referenced[filepath.FromSlash(rel)] = true
+			}
+		}
+		for _, step := range auditAsset(dir, icon) {
+			add(step)
+		}
+	}
+
+	orphans, err := orphanAssets(dir, referenced)
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range orphans {
+		add(RepairStep{Issue: IssueOrphanAsset, Action: RepairDelete, Path: filepath.ToSlash(rel)})
+	}
+
+	if opts.Remote {
+		steps, err := auditRemote(ctx, d.Icons, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, step := range steps {
+			add(step)
+		}
+	}
+	return report, nil
+}
+
+// auditAsset checks that the assets of icon exist and match its checksum
+func auditAsset(dir string, icon *IconPayload) []RepairStep {
+	var steps []RepairStep
+	if icon.LocalPath != "" {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(icon.LocalPath)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			steps = append(steps, RepairStep{Slug: icon.Slug, Issue: IssueMissingAsset, Action: RepairDownload, Path: icon.LocalPath, URL: icon.URL})
+		case err != nil:
+			steps = append(steps, RepairStep{Slug: icon.Slug, Issue: IssueMissingAsset, Action: RepairDownload, Path: icon.LocalPath, URL: icon.URL, Detail: err.Error()})
+		case icon.ContentSHA256 != "" && contentHash(data) != icon.ContentSHA256:
+			steps = append(steps, RepairStep{Slug: icon.Slug, Issue: IssueChecksumMismatch, Action: RepairDownload, Path: icon.LocalPath, URL: icon.URL,
+				Detail: fmt.Sprintf("expected sha256 %s, file has %s", icon.ContentSHA256, contentHash(data))})
+		}
+	}
+	// variants are derived from the asset, regenerating them restores them
+	for _, rel := range []string{icon.DarkLocalPath, icon.MonoLocalPath} {
+		if rel == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel))); err != nil {
+			steps = append(steps, RepairStep{Slug: icon.Slug, Issue: IssueMissingAsset, Action: RepairRegenerate, Path: rel})
+		}
+	}
+	return steps
+}
+
+// orphanAssets returns the files of the assets directories of dir no icon
+// refers to, relative to dir
+func orphanAssets(dir string, referenced map[string]bool) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*", assetsDir, "*"))
+	if err != nil {
+		return nil, err
+	}
+	var orphans []string
+	for _, path := range matches {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		if !referenced[rel] {
+			orphans = append(orphans, rel)
+		}
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// auditRemote fetches the URL of every icon on opts.Workers workers,
+// reporting unreachable and redirected URLs and artwork that changed since
+// it was downloaded
+func auditRemote(ctx context.Context, icons []*IconPayload, opts AuditOptions) ([]RepairStep, error) {
+	client := opts.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = defaultIconifyWorkers
+	}
+
+	results := make([][]RepairStep, len(icons))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = checkRemote(ctx, client, icons[i])
+			}
+		}()
+	}
+feed:
+	for i := range icons {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var steps []RepairStep
+	for _, r := range results {
+		steps = append(steps, r...)
+	}
+	return steps, nil
+}
+
+// checkRemote fetches the URL of icon and compares it with the icon
+func checkRemote(ctx context.Context, client *http.Client, icon *IconPayload) []RepairStep {
+	var (
+		data   []byte
+		newURL string
+		err    error
+	)
+	if u, perr := url.Parse(icon.URL); perr == nil && u.Scheme == "file" {
+		data, err = fetchAsset(ctx, client, icon.URL)
+	} else {
+		data, newURL, err = fetchFollowing(ctx, client, icon.URL)
+	}
+	if err != nil {
+		return []RepairStep{{Slug: icon.Slug, Issue: IssueURLUnreachable, Action: RepairRescrape, URL: icon.URL, Detail: err.Error()}}
+	}
+
+	var steps []RepairStep
+	if newURL != "" && newURL != icon.URL {
+		steps = append(steps, RepairStep{Slug: icon.Slug, Issue: IssueURLMoved, Action: RepairUpdateURL, URL: icon.URL, NewURL: newURL})
+	}
+	if icon.ContentSHA256 != "" && contentHash(data) != icon.ContentSHA256 {
+		steps = append(steps, RepairStep{Slug: icon.Slug, Issue: IssueRemoteChanged, Action: RepairDownload, Path: icon.LocalPath, URL: icon.URL,
+			Detail: "remote artwork differs from the downloaded asset"})
+	}
+	return steps
+}
+
+// fetchFollowing reads rawURL following redirects, returning the body and
+// the URL it was served from
+func fetchFollowing(ctx context.Context, client *http.Client, rawURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := readLimited(resp.Body, maxAssetSize)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Request.URL.String(), nil
+}
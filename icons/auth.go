@@ -0,0 +1,152 @@
+package icons
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// API key roles, a curator can do everything a reader can
+const (
+	RoleReader  = "reader"
+	RoleCurator = "curator"
+)
+
+// APIKey grants a client access to the HTTP handlers
+type APIKey struct {
+	Name string `yaml:"name"`
+	// Key is sent as the X-API-Key header, a bearer token or the password of
+	// basic auth, which browsers prompt for
+	Key string `yaml:"key"`
+	// Role is reader, for search and lookups, or curator, which can also
+	// write overrides
+	Role string `yaml:"role"`
+	// RatePerMinute caps the requests of the key per minute, 0 means no cap
+	RatePerMinute int `yaml:"rate_per_minute,omitempty"`
+}
+
+// LoadAPIKeys reads an API keys file, a missing file yields no keys
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading API keys %s: %w", path, err)
+	}
+	var keys []APIKey
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("error parsing API keys %s: %w", path, err)
+	}
+	seen := make(map[string]bool, len(keys))
+	for i, k := range keys {
+		switch {
+		case k.Key == "":
+			return nil, fmt.Errorf("API keys %s: key %d (%s) is empty", path, i+1, k.Name)
+		case seen[k.Key]:
+			return nil, fmt.Errorf("API keys %s: key %d (%s) is a duplicate", path, i+1, k.Name)
+		case k.Role != RoleReader && k.Role != RoleCurator:
+			return nil, fmt.Errorf("API keys %s: key %d (%s) has unknown role %q", path, i+1, k.Name, k.Role)
+		case k.RatePerMinute < 0:
+			return nil, fmt.Errorf("API keys %s: key %d (%s) has a negative rate", path, i+1, k.Name)
+		}
+		seen[k.Key] = true
+	}
+	return keys, nil
+}
+
+// Authenticator checks the API key of requests against a role and the rate
+// limit of the key
+type Authenticator struct {
+	keys []APIKey
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+// rateWindow counts the requests of a key in the current minute
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewAuthenticator returns an Authenticator accepting keys
+func NewAuthenticator(keys []APIKey) *Authenticator {
+	return &Authenticator{keys: keys, windows: make(map[string]*rateWindow)}
+}
+
+// Require wraps next so only requests with a key of role, or a curator key,
+// reach it. It answers 401 without a valid key, 403 when the role of the key
+// is not enough and 429 when the key is over its rate
+func (a *Authenticator) Require(role string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := a.authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="icons"`)
+			http.Error(w, "missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+		if role == RoleCurator && key.Role != RoleCurator {
+			http.Error(w, fmt.Sprintf("API key %s is not a curator key", key.Name), http.StatusForbidden)
+			return
+		}
+		if wait := a.limit(key, time.Now()); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds()+1)))
+			http.Error(w, fmt.Sprintf("API key %s is over its rate of %d requests per minute", key.Name, key.RatePerMinute), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate returns the key sent with r, comparing it with every key in
+// constant time
+func (a *Authenticator) authenticate(r *http.Request) (APIKey, bool) {
+	sent := r.Header.Get("X-API-Key")
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		sent = token
+	} else if _, password, ok := r.BasicAuth(); ok {
+		sent = password
+	}
+	if sent == "" {
+		return APIKey{}, false
+	}
+	var match APIKey
+	found := false
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(sent), []byte(k.Key)) == 1 {
+			match, found = k, true
+		}
+	}
+	return match, found
+}
+
+// limit counts a request of key at now, returning how long the key has to
+// wait when it is over its rate
+func (a *Authenticator) limit(key APIKey, now time.Time) time.Duration {
+	if key.RatePerMinute == 0 {
+		return 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	win := a.windows[key.Key]
+	if win == nil || now.Sub(win.start) >= time.Minute {
+		win = &rateWindow{start: now}
+		a.windows[key.Key] = win
+	}
+	if win.count >= key.RatePerMinute {
+		return win.start.Add(time.Minute).Sub(now)
+	}
+	win.count++
+	return 0
+}
@@ -0,0 +1,46 @@
+package icons
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Fetch backends selectable in SourceConfig
+const (
+	BackendHTTP    = "http"
+	BackendBrowser = "browser"
+)
+
+// FetchBackend returns the transport catalog pages are fetched through,
+// wrapping next, and a function releasing its resources
+type FetchBackend func(ctx context.Context, cfg *Config, sc SourceConfig, next http.RoundTripper) (http.RoundTripper, func(), error)
+
+var fetchBackends = map[string]FetchBackend{
+	BackendHTTP: func(_ context.Context, _ *Config, _ SourceConfig, next http.RoundTripper) (http.RoundTripper, func(), error) {
+		return next, func() {}, nil
+	},
+}
+
+// RegisterFetchBackend makes a fetch backend selectable by name
+func RegisterFetchBackend(name string, backend FetchBackend) {
+	fetchBackends[name] = backend
+}
+
+// fetchTransport returns the transport of the backend selected by sc, offline
+// and replay runs always use plain HTTP so pages come from the cache or
+// fixtures
+func fetchTransport(ctx context.Context, cfg *Config, sc SourceConfig) (http.RoundTripper, func(), error) {
+	name := sc.Backend
+	if name == "" || cfg.Offline || cfg.FixtureMode == FixtureReplay {
+		name = BackendHTTP
+	}
+	backend, ok := fetchBackends[name]
+	if !ok {
+		if name == BackendBrowser {
+			return nil, nil, fmt.Errorf("fetch backend %q requires building with -tags chromedp", name)
+		}
+		return nil, nil, fmt.Errorf("unknown fetch backend %q", name)
+	}
+	return backend(ctx, cfg, sc, cfg.baseTransport())
+}
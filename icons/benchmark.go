@@ -0,0 +1,232 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Pipeline stages timed by Benchmark
+const (
+	stageFetch        = "fetch"
+	stageEnrich       = "enrich"
+	stageIconify      = "iconify"
+	stageAnnotate     = "annotate"
+	stageClassify     = "classify"
+	stageAssets       = "assets"
+	stageShapes       = "shapes"
+	stageOverrides    = "overrides"
+	stageFamilies     = "families"
+	stageDisambiguate = "disambiguate"
+	stageDocuments    = "documents"
+	stageQuality      = "quality"
+	stageWrite        = "write"
+)
+
+// StageResult is the throughput of one pipeline stage
+type StageResult struct {
+	Name        string  `json:"name"`
+	Icons       int     `json:"icons"`
+	Duration    string  `json:"duration"`
+	IconsPerSec float64 `json:"icons_per_sec"`
+	// AllocBytes and Allocs are allocated by the stage, zero for stages
+	// nested in another one
+	AllocBytes uint64 `json:"alloc_bytes"`
+	Allocs     uint64 `json:"allocs"`
+}
+
+// BenchmarkReport is the result of a Benchmark run
+type BenchmarkReport struct {
+	Icons       int           `json:"icons"`
+	Duration    string        `json:"duration"`
+	IconsPerSec float64       `json:"icons_per_sec"`
+	Stages      []StageResult `json:"stages"`
+}
+
+// stageClock records the stages of the run being benchmarked, nil otherwise
+var stageClock *stageTimings
+
+// stageTimings accumulates the time, icons and allocations of each stage in
+// the order stages first complete
+type stageTimings struct {
+	mu     sync.Mutex
+	order  []string
+	stages map[string]*stageTotals
+}
+
+type stageTotals struct {
+	icons   int
+	elapsed time.Duration
+	bytes   uint64
+	allocs  uint64
+}
+
+// stageMark is the state of the process when a stage started
+type stageMark struct {
+	at     time.Time
+	bytes  uint64
+	allocs uint64
+}
+
+func newStageTimings() *stageTimings {
+	return &stageTimings{stages: make(map[string]*stageTotals)}
+}
+
+// start marks the beginning of a stage, it is free when not benchmarking
+func (t *stageTimings) start() stageMark {
+	if t == nil {
+		return stageMark{}
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return stageMark{at: time.Now(), bytes: m.TotalAlloc, allocs: m.Mallocs}
+}
+
+// done records that stage processed icons since mark
+func (t *stageTimings) done(stage string, mark stageMark, icons int) {
+	if t == nil {
+		return
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	s := t.totals(stage)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s.icons += icons
+	s.elapsed += time.Since(mark.at)
+	s.bytes += m.TotalAlloc - mark.bytes
+	s.allocs += m.Mallocs - mark.allocs
+}
+
+// add records a nested stage, e.g. the Iconify lookups of enrichment,
+// without allocation counts
+func (t *stageTimings) add(stage string, icons int, elapsed time.Duration) {
+	if t == nil {
+		return
+	}
+	s := t.totals(stage)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s.icons += icons
+	s.elapsed += elapsed
+}
+
+func (t *stageTimings) totals(stage string) *stageTotals {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.stages[stage]
+	if !ok {
+		s = &stageTotals{}
+		t.stages[stage] = s
+		t.order = append(t.order, stage)
+	}
+	return s
+}
+
+func (t *stageTimings) results() []StageResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	results := make([]StageResult, 0, len(t.order))
+	for _, name := range t.order {
+		s := t.stages[name]
+		results = append(results, StageResult{
+			Name:        name,
+			Icons:       s.icons,
+			Duration:    s.elapsed.Round(time.Microsecond).String(),
+			IconsPerSec: perSecond(s.icons, s.elapsed),
+			AllocBytes:  s.bytes,
+			Allocs:      s.allocs,
+		})
+	}
+	return results
+}
+
+func perSecond(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+// Benchmark runs the pipeline against the fixtures recorded in
+// cfg.FixtureDir, writing into a temporary directory, and reports the
+// throughput of every stage so regressions are measurable
+func Benchmark(ctx context.Context, cfg *Config) (*BenchmarkReport, error) {
+	if cfg.FixtureDir == "" {
+		return nil, errors.New("benchmark needs recorded fixtures, set WithFixtures")
+	}
+	dir, err := os.MkdirTemp("", "icons-benchmark-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	run := *cfg
+	run.OutputDir = dir
+	run.FixtureMode = FixtureReplay
+	run.RequestDelay, run.RandomDelay = 0, 0
+	run.KeepSnapshots = 0
+
+	stageClock = newStageTimings()
+	defer func() { stageClock = nil }()
+
+	start := time.Now()
+	report, err := GenerateAll(ctx, &run)
+	if err != nil {
+		return nil, fmt.Errorf("benchmark run failed: %w", err)
+	}
+	elapsed := time.Since(start)
+
+	result := &BenchmarkReport{
+		Icons:       report.Total,
+		Duration:    elapsed.Round(time.Millisecond).String(),
+		IconsPerSec: perSecond(report.Total, elapsed),
+		Stages:      stageClock.results(),
+	}
+	for _, s := range result.Stages {
+		log.Printf("⏱️  %-10s %6d icons %12s %10.1f icons/s %10d KiB", s.Name, s.Icons, s.Duration, s.IconsPerSec, s.AllocBytes/1024)
+	}
+	log.Printf("⏱️  %d icons in %s, %.1f icons/s", result.Icons, result.Duration, result.IconsPerSec)
+	return result, nil
+}
+
+// PprofHandler serves the net/http/pprof profiles under /debug/pprof/, to be
+// mounted next to LookupIconHandler by long-running servers
+func PprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// servePprof serves PprofHandler on addr for the duration of a run, the
+// returned function stops it
+func servePprof(addr string) func() {
+	if addr == "" {
+		return func() {}
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Printf("⚠️  pprof disabled: %v", err)
+		return func() {}
+	}
+	srv := &http.Server{Handler: PprofHandler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("⚠️  pprof: %v", err)
+		}
+	}()
+	log.Printf("🩺 pprof on http://%s/debug/pprof/", ln.Addr())
+	return func() { srv.Close() }
+}
@@ -0,0 +1,71 @@
+package icons
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// brandCasing holds the official capitalization of words title casing gets
+// wrong, keyed by their lower case form
+var brandCasing = map[string]string{
+	// vendors and products
+	"aws": "AWS", "gcp": "GCP", "ibm": "IBM", "sap": "SAP", "vmware": "VMware",
+	"github": "GitHub", "gitlab": "GitLab", "bitbucket": "Bitbucket", "linkedin": "LinkedIn",
+	"youtube": "YouTube", "wordpress": "WordPress", "paypal": "PayPal", "whatsapp": "WhatsApp",
+	"ios": "iOS", "ipad": "iPad", "iphone": "iPhone", "macos": "macOS", "tvos": "tvOS", "watchos": "watchOS",
+	"postgresql": "PostgreSQL", "mysql": "MySQL", "mariadb": "MariaDB", "mongodb": "MongoDB",
+	"couchdb": "CouchDB", "dynamodb": "DynamoDB", "documentdb": "DocumentDB", "cosmosdb": "CosmosDB",
+	"influxdb": "InfluxDB", "cockroachdb": "CockroachDB", "sqlite": "SQLite", "nosql": "NoSQL",
+	"graphql": "GraphQL", "grpc": "gRPC", "javascript": "JavaScript", "typescript": "TypeScript",
+	"nodejs": "Node.js", "npm": "npm", "jquery": "jQuery", "php": "PHP", "devops": "DevOps",
+	"rabbitmq": "RabbitMQ", "activemq": "ActiveMQ", "zeromq": "ZeroMQ", "openshift": "OpenShift",
+	"openstack": "OpenStack", "openai": "OpenAI", "bigquery": "BigQuery", "bigtable": "Bigtable",
+	"cloudwatch": "CloudWatch", "cloudformation": "CloudFormation", "cloudfront": "CloudFront",
+	"cloudtrail": "CloudTrail", "cloudsearch": "CloudSearch", "cloudhsm": "CloudHSM",
+	"codebuild": "CodeBuild", "codecommit": "CodeCommit", "codedeploy": "CodeDeploy",
+	"codepipeline": "CodePipeline", "codestar": "CodeStar", "elasticache": "ElastiCache",
+	"opsworks": "OpsWorks", "sagemaker": "SageMaker", "robomaker": "RoboMaker", "quicksight": "QuickSight",
+	"appsync": "AppSync", "eventbridge": "EventBridge", "iot": "IoT", "fsx": "FSx", "automl": "AutoML",
+	"devtest": "DevTest", "hdinsight": "HDInsight", "powershell": "PowerShell", "onedrive": "OneDrive",
+	"sharepoint": "SharePoint", "datadog": "Datadog", "newrelic": "New Relic", "pagerduty": "PagerDuty",
+	"hashicorp": "HashiCorp", "fastapi": "FastAPI", "tensorflow": "TensorFlow", "pytorch": "PyTorch",
+	// acronyms
+	"ai": "AI", "ml": "ML", "api": "API", "apis": "APIs", "sdk": "SDK", "cli": "CLI", "ui": "UI", "ux": "UX",
+	"vm": "VM", "vms": "VMs", "vpc": "VPC", "vpn": "VPN", "dns": "DNS", "cdn": "CDN", "ec2": "EC2", "s3": "S3",
+	"iam": "IAM", "rds": "RDS", "sqs": "SQS", "sns": "SNS", "ses": "SES", "ecs": "ECS", "eks": "EKS",
+	"ecr": "ECR", "efs": "EFS", "ebs": "EBS", "elb": "ELB", "alb": "ALB", "nlb": "NLB", "emr": "EMR",
+	"kms": "KMS", "waf": "WAF", "sso": "SSO", "ad": "AD", "sql": "SQL", "db": "DB", "k8s": "K8s",
+	"html": "HTML", "css": "CSS", "json": "JSON", "xml": "XML", "yaml": "YAML", "http": "HTTP",
+	"https": "HTTPS", "ssl": "SSL", "tls": "TLS", "ssh": "SSH", "ftp": "FTP", "sftp": "SFTP",
+	"tcp": "TCP", "udp": "UDP", "ip": "IP", "nat": "NAT", "url": "URL", "id": "ID", "gpu": "GPU",
+	"cpu": "CPU", "hpc": "HPC", "saas": "SaaS", "paas": "PaaS", "iaas": "IaaS", "ci": "CI", "cd": "CD",
+}
+
+// smallWords stay lower case unless they start the name
+var smallWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "by": true, "for": true,
+	"in": true, "of": true, "on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// caseWord capitalizes word following brandCasing, keeping surrounding
+// punctuation such as "(classic)"; first reports whether it starts the name
+func caseWord(word string, first bool) string {
+	start := strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+	if start < 0 {
+		return word
+	}
+	end := strings.LastIndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+	_, size := utf8.DecodeRuneInString(word[end:])
+	prefix, core, suffix := word[:start], word[start:end+size], word[end+size:]
+
+	lower := strings.ToLower(core)
+	if brand, ok := brandCasing[lower]; ok {
+		return prefix + brand + suffix
+	}
+	if !first && smallWords[lower] {
+		return prefix + lower + suffix
+	}
+	r, n := utf8.DecodeRuneInString(lower)
+	return prefix + string(unicode.ToTitle(r)) + lower[n:] + suffix
+}
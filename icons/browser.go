@@ -0,0 +1,160 @@
+//go:build chromedp
+
+package icons
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/chromedp"
+)
+
+const browserTimeout = 60 * time.Second
+
+func init() {
+	RegisterFetchBackend(BackendBrowser, newBrowserTransport)
+}
+
+// browserTransport renders catalog pages in headless Chrome and returns the
+// resulting DOM, so client-side galleries reach the colly callbacks
+type browserTransport struct {
+	browser      context.Context
+	next         http.RoundTripper
+	waitSelector string
+	// proxyUser answers the authentication challenges of the proxy, Chrome
+	// ignores credentials in --proxy-server
+	proxyUser *url.Userinfo
+}
+
+// newBrowserTransport starts a headless browser shared by the requests of a
+// source
+func newBrowserTransport(ctx context.Context, cfg *Config, sc SourceConfig, next http.RoundTripper) (http.RoundTripper, func(), error) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.UserAgent(cfg.UserAgent))
+	var proxyUser *url.Userinfo
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		opts = append(opts, chromedp.ProxyServer(u.Scheme+"://"+u.Host))
+		proxyUser = u.User
+	}
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, opts...)
+	browser, cancelBrowser := chromedp.NewContext(allocCtx)
+	done := func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+	if err := chromedp.Run(browser); err != nil {
+		done()
+		return nil, nil, fmt.Errorf("error starting browser: %w", err)
+	}
+
+	wait := sc.WaitSelector
+	if wait == "" {
+		wait = "body"
+	}
+	return &browserTransport{browser: browser, next: next, waitSelector: wait, proxyUser: proxyUser}, done, nil
+}
+
+func (t *browserTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !renderedPage(req.URL) {
+		return t.next.RoundTrip(req)
+	}
+
+	// tabs derive from the browser context, so the request context is
+	// watched separately to stop rendering when the run is cancelled
+	tab, cancel := chromedp.NewContext(t.browser)
+	defer cancel()
+	stop := context.AfterFunc(req.Context(), cancel)
+	defer stop()
+	tab, cancelTimeout := context.WithTimeout(tab, browserTimeout)
+	defer cancelTimeout()
+
+	actions := []chromedp.Action{chromedp.Navigate(req.URL.String())}
+	if t.proxyUser != nil {
+		t.authenticateProxy(tab)
+		actions = append([]chromedp.Action{fetch.Enable().WithHandleAuthRequests(true)}, actions...)
+	}
+	resp, err := chromedp.RunResponse(tab, actions...)
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error rendering %s: %w", req.URL, err)
+	}
+	var html string
+	if err := chromedp.Run(tab,
+		chromedp.WaitReady(t.waitSelector, chromedp.ByQuery),
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+	); err != nil {
+		if cerr := req.Context().Err(); cerr != nil {
+			return nil, cerr
+		}
+		return nil, fmt.Errorf("error rendering %s: %w", req.URL, err)
+	}
+
+	status := http.StatusOK
+	if resp != nil && resp.Status != 0 {
+		status = int(resp.Status)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(strings.NewReader(html)),
+		ContentLength: int64(len(html)),
+		Request:       req,
+	}, nil
+}
+
+// authenticateProxy answers the proxy authentication challenges of tab with
+// the proxy credentials, leaving those of servers to the browser. Requests
+// paused by the fetch domain are resumed unchanged
+func (t *browserTransport) authenticateProxy(tab context.Context) {
+	password, _ := t.proxyUser.Password()
+	chromedp.ListenTarget(tab, func(ev any) {
+		var action chromedp.Action
+		switch ev := ev.(type) {
+		case *fetch.EventRequestPaused:
+			action = fetch.ContinueRequest(ev.RequestID)
+		case *fetch.EventAuthRequired:
+			answer := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+			if ev.AuthChallenge != nil && ev.AuthChallenge.Source == fetch.AuthChallengeSourceProxy {
+				answer = &fetch.AuthChallengeResponse{
+					Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+					Username: t.proxyUser.Username(),
+					Password: password,
+				}
+			}
+			action = fetch.ContinueWithAuth(ev.RequestID, answer)
+		default:
+			return
+		}
+		// listeners must not block, actions are run on their own goroutine
+		go func() {
+			_ = action.Do(cdp.WithExecutor(tab, chromedp.FromContext(tab).Target))
+		}()
+	})
+}
+
+// renderedPage reports whether u is a page to render rather than a robots.txt,
+// sitemap or asset fetched directly
+func renderedPage(u *url.URL) bool {
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".xml", ".txt", ".json", ".svg":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package icons
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// volatileFields change on every run without the icon changing, ContentHash
+// leaves them out along with the timestamps of the provenance
+var volatileFields = []string{"last_seen"}
+
+// marshalCanonical encodes v as canonical JSON: object keys sorted at every
+// level, numbers in plain decimal notation without exponents, two space
+// indentation, LF line endings and a final newline
+func marshalCanonical(v any) ([]byte, error) {
+	data, err := marshalRaw(v)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decodeNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writeCanonical(&buf, value, "")
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// decodeNumbers decodes data keeping numbers as written
+func decodeNumbers(data []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var value any
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// writeCanonical writes value indented by indent, nested values one level
+// deeper
+func writeCanonical(buf *bytes.Buffer, value any, indent string) {
+	inner := indent + "  "
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteString("{\n")
+		for i, k := range keys {
+			key, _ := marshalRaw(k)
+			buf.WriteString(inner)
+			buf.Write(key)
+			buf.WriteString(": ")
+			writeCanonical(buf, v[k], inner)
+			if i < len(keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case []any:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteString("[\n")
+		for i, item := range v {
+			buf.WriteString(inner)
+			writeCanonical(buf, item, inner)
+			if i < len(v)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	case json.Number:
+		buf.WriteString(canonicalNumber(v))
+	default:
+		data, _ := marshalRaw(v)
+		buf.Write(data)
+	}
+}
+
+// canonicalNumber keeps integers as written and formats other numbers in
+// the shortest plain decimal notation, e.g. 1e-07 as 0.0000001
+func canonicalNumber(n json.Number) string {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		return s
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return s
+	}
+	if f == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// ContentHash returns the SHA-256 of the canonical JSON of icons without
+// their volatile fields, so reruns that changed nothing hash the same even
+// though they scraped at another time
+func ContentHash(icons []*IconPayload) (string, error) {
+	data, err := marshalRaw(icons)
+	if err != nil {
+		return "", err
+	}
+	value, err := decodeNumbers(data)
+	if err != nil {
+		return "", err
+	}
+	items, _ := value.([]any)
+	for _, item := range items {
+		if icon, ok := item.(map[string]any); ok {
+			stripVolatile(icon)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeCanonical(&buf, value, "")
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// stripVolatile removes the volatile fields, the extra fields and the
+// provenance timestamps of a decoded icon
+func stripVolatile(icon map[string]any, extra ...string) {
+	for _, field := range volatileFields {
+		delete(icon, field)
+	}
+	for _, field := range extra {
+		delete(icon, field)
+	}
+	provenance, _ := icon["provenance"].(map[string]any)
+	for _, p := range provenance {
+		if p, ok := p.(map[string]any); ok {
+			delete(p, "at")
+		}
+	}
+}
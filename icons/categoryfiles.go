@@ -0,0 +1,63 @@
+package icons
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	categoryIndexFile = "index.json"
+	uncategorized     = "uncategorized"
+)
+
+// CategoryFile is an entry of a provider category index
+type CategoryFile struct {
+	Category string `json:"category"`
+	File     string `json:"file"`
+	Icons    int    `json:"icons"`
+}
+
+// writeCategoryFiles writes one JSON file per category of a provider, e.g.
+// aws/compute.json, and the provider index.json listing them
+func writeCategoryFiles(cfg *Config, dir, providerKey string, icons []*IconPayload) ([]CategoryFile, error) {
+	groups := make(map[string][]*IconPayload)
+	names := make(map[string]string)
+	for _, icon := range icons {
+		name := icon.Category
+		if name == "" {
+			name = uncategorized
+		}
+		key := slugify(name)
+		if key == "" {
+			key = uncategorized
+		}
+		// keep clear of the provider file and the index
+		if key == providerKey || key+".json" == categoryIndexFile {
+			key += "-category"
+		}
+		groups[key] = append(groups[key], icon)
+		if _, ok := names[key]; !ok {
+			names[key] = name
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	index := make([]CategoryFile, 0, len(keys))
+	for _, key := range keys {
+		file := key + ".json"
+		if err := cfg.writeJSON(filepath.Join(dir, file), groups[key]); err != nil {
+			return nil, fmt.Errorf("failed to write category %s: %w", names[key], err)
+		}
+		index = append(index, CategoryFile{Category: names[key], File: file, Icons: len(groups[key])})
+	}
+	if err := cfg.writeJSON(filepath.Join(dir, categoryIndexFile), index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
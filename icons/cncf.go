@@ -0,0 +1,138 @@
+package icons
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// cncfLandscapeURL is the landscape data read by cncf sources without URL
+	cncfLandscapeURL = "https://raw.githubusercontent.com/cncf/landscape/master/landscape.yml"
+	cncfProvider     = "cncf"
+	// cncfMembers lists member companies rather than projects
+	cncfMembers = "CNCF Members"
+	// maxLandscapeSize bounds the landscape data read
+	maxLandscapeSize = 64 << 20
+)
+
+// cncfLandscape is the landscape.yml of the CNCF landscape
+type cncfLandscape struct {
+	Landscape []struct {
+		Name          string `yaml:"name"`
+		Subcategories []struct {
+			Name  string     `yaml:"name"`
+			Items []cncfItem `yaml:"items"`
+		} `yaml:"subcategories"`
+	} `yaml:"landscape"`
+}
+
+// cncfItem is a project or product of the landscape, Project is its CNCF
+// maturity, e.g. graduated, and empty for projects outside the CNCF
+type cncfItem struct {
+	Name        string `yaml:"name"`
+	Logo        string `yaml:"logo"`
+	Project     string `yaml:"project"`
+	HomepageURL string `yaml:"homepage_url"`
+	RepoURL     string `yaml:"repo_url"`
+}
+
+// cncfSource ingests the projects of the CNCF landscape with their logos,
+// landscape category and subcategory, and maturity
+type cncfSource struct {
+	cfg SourceConfig
+}
+
+func (s *cncfSource) Name() string { return sourceName(s.cfg) }
+
+func (s *cncfSource) Fetch(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	dataURL := s.cfg.URL
+	if dataURL == "" {
+		dataURL = cncfLandscapeURL
+	}
+	landscape, err := fetchLandscape(ctx, cfg.assetClient(), dataURL)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(dataURL)
+	if err != nil {
+		return nil, err
+	}
+	logos := base.ResolveReference(&url.URL{Path: "hosted_logos/"})
+
+	pending := make([]PendingIcon, 0)
+	seen := make(map[string]bool)
+	for _, category := range landscape.Landscape {
+		if category.Name == cncfMembers {
+			continue
+		}
+		for _, sub := range category.Subcategories {
+			for _, item := range sub.Items {
+				if item.Logo == "" || seen[item.Name] {
+					continue
+				}
+				seen[item.Name] = true
+
+				logo, err := logos.Parse(item.Logo)
+				if err != nil {
+					continue
+				}
+				file := strings.TrimSuffix(path.Base(logo.Path), path.Ext(logo.Path))
+				pending = append(pending, PendingIcon{
+					Category:    strings.ToUpper(cncfProvider),
+					Title:       item.Name,
+					Link:        strings.Join([]string{cncfProvider, url.PathEscape(category.Name), url.PathEscape(sub.Name), url.PathEscape(file) + ".svg"}, "%2F"),
+					URL:         logo.String(),
+					DisplayName: item.Name,
+					Extensions:  item.extensions(),
+				})
+			}
+		}
+	}
+	return pending, nil
+}
+
+// extensions returns the landscape metadata of item stored on its icon
+func (item cncfItem) extensions() map[string]any {
+	ext := make(map[string]any)
+	if item.Project != "" {
+		ext["maturity"] = item.Project
+	}
+	if item.HomepageURL != "" {
+		ext["homepage_url"] = item.HomepageURL
+	}
+	if item.RepoURL != "" {
+		ext["repo_url"] = item.RepoURL
+	}
+	return ext
+}
+
+func fetchLandscape(ctx context.Context, client *http.Client, dataURL string) (*cncfLandscape, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cncf landscape: unexpected status %s", resp.Status)
+	}
+	data, err := readLimited(resp.Body, maxLandscapeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var landscape cncfLandscape
+	if err := yaml.Unmarshal(data, &landscape); err != nil {
+		return nil, fmt.Errorf("cncf landscape: %w", err)
+	}
+	return &landscape, nil
+}
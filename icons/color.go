@@ -0,0 +1,129 @@
+package icons
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// svgColorRgx matches paint values in attributes and inline styles
+	svgColorRgx = regexp.MustCompile(`(?i)((?:fill|stroke|stop-color|color)\s*(?:=\s*["']|:\s*))\s*(#[0-9a-f]{6}\b|#[0-9a-f]{3}\b|rgb\(\s*\d+\s*,\s*\d+\s*,\s*\d+\s*\)|[a-z]+)`)
+	rgbFuncRgx  = regexp.MustCompile(`^rgb\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)\s*\)$`)
+
+	namedColors = map[string]rgb{
+		"black": {0, 0, 0}, "white": {255, 255, 255}, "red": {255, 0, 0},
+		"green": {0, 128, 0}, "blue": {0, 0, 255}, "gray": {128, 128, 128},
+		"grey": {128, 128, 128}, "orange": {255, 165, 0}, "yellow": {255, 255, 0},
+		"purple": {128, 0, 128}, "currentcolor": {0, 0, 0},
+	}
+)
+
+// rgb is an sRGB color
+type rgb struct {
+	R, G, B uint8
+}
+
+// parseColor parses a hex, rgb() or basic named color
+func parseColor(s string) (rgb, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+	if m := rgbFuncRgx.FindStringSubmatch(s); m != nil {
+		var c [3]uint8
+		for i := range c {
+			n, _ := strconv.Atoi(m[i+1])
+			if n > 255 {
+				n = 255
+			}
+			c[i] = uint8(n)
+		}
+		return rgb{c[0], c[1], c[2]}, true
+	}
+	if !hexColorRgx.MatchString(s) {
+		return rgb{}, false
+	}
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{uint8(n >> 16), uint8(n >> 8), uint8(n)}, true
+}
+
+// Hex formats c as #rrggbb
+func (c rgb) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// luminance returns the WCAG relative luminance of c
+func (c rgb) luminance() float64 {
+	channel := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+// contrastRatio returns the WCAG contrast ratio of a and b, from 1 to 21
+func contrastRatio(a, b rgb) float64 {
+	la, lb := a.luminance(), b.luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// svgPalette returns the distinct colors painted by an SVG, black when no
+// paint is specified
+func svgPalette(data []byte) []rgb {
+	seen := make(map[rgb]bool)
+	var palette []rgb
+	for _, m := range svgColorRgx.FindAllStringSubmatch(string(data), -1) {
+		c, ok := parseColor(m[2])
+		if !ok || seen[c] {
+			continue
+		}
+		seen[c] = true
+		palette = append(palette, c)
+	}
+	if len(palette) == 0 {
+		palette = append(palette, namedColors["black"])
+	}
+	return palette
+}
+
+// recolorSVG rewrites every paint of an SVG with fn. SVGs without explicit
+// paint get a root fill so the default black is recolored too
+func recolorSVG(data []byte, fn func(rgb) rgb) []byte {
+	painted := false
+	out := svgColorRgx.ReplaceAllStringFunc(string(data), func(match string) string {
+		m := svgColorRgx.FindStringSubmatch(match)
+		c, ok := parseColor(m[2])
+		if !ok {
+			return match
+		}
+		painted = true
+		return m[1] + fn(c).Hex()
+	})
+	if !painted {
+		fill := ` fill="` + fn(namedColors["black"]).Hex() + `"`
+		if loc := svgOpenRgx.FindStringIndex(out); loc != nil && !strings.Contains(out[loc[0]:loc[1]], "fill=") {
+			end := loc[1] - 1
+			if strings.HasSuffix(out[:loc[1]], "/>") {
+				end--
+			}
+			out = out[:end] + fill + out[end:]
+		}
+	}
+	return []byte(out)
+}
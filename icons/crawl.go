@@ -0,0 +1,139 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gocolly/colly"
+)
+
+// loadMoreAttrs hold the URL of the next chunk on "load more" elements
+var loadMoreAttrs = []string{"href", "data-url", "data-href", "data-next", "data-src"}
+
+// Pagination configures how a source crawls a catalog spread over several
+// pages, the zero value visits the start page only
+type Pagination struct {
+	// NextSelector matches the link to the next page, e.g. `a[rel="next"]`
+	NextSelector string `yaml:"next_selector,omitempty"`
+	// LoadMoreSelector matches "load more" elements pointing at the next
+	// chunk in their href or data-url attribute
+	LoadMoreSelector string `yaml:"load_more_selector,omitempty"`
+	// Sitemaps are sitemap or sitemap index URLs whose pages are all visited
+	Sitemaps []string `yaml:"sitemaps,omitempty"`
+	// MaxPages caps the visited pages, zero means no limit
+	MaxPages int `yaml:"max_pages,omitempty"`
+}
+
+// crawler follows the pagination of a catalog with a colly collector, so
+// sources only register callbacks for the icons of a page
+type crawler struct {
+	ctx   context.Context
+	c     *colly.Collector
+	p     Pagination
+	mu    sync.Mutex
+	pages int
+	err   error
+}
+
+// newCrawler registers the pagination callbacks of p on c
+func newCrawler(ctx context.Context, c *colly.Collector, p Pagination) *crawler {
+	cr := &crawler{ctx: ctx, c: c, p: p}
+
+	if p.NextSelector != "" {
+		c.OnHTML(p.NextSelector, func(e *colly.HTMLElement) {
+			cr.follow(e.Request.AbsoluteURL(e.Attr("href")))
+		})
+	}
+	if p.LoadMoreSelector != "" {
+		c.OnHTML(p.LoadMoreSelector, func(e *colly.HTMLElement) {
+			for _, attr := range loadMoreAttrs {
+				if v := strings.TrimSpace(e.Attr(attr)); v != "" && !strings.HasPrefix(v, "#") {
+					cr.follow(e.Request.AbsoluteURL(v))
+					return
+				}
+			}
+		})
+	}
+	if len(p.Sitemaps) > 0 {
+		c.OnXML("//urlset/url/loc", func(e *colly.XMLElement) {
+			cr.follow(strings.TrimSpace(e.Text))
+		})
+		c.OnXML("//sitemapindex/sitemap/loc", func(e *colly.XMLElement) {
+			cr.visit(strings.TrimSpace(e.Text), false)
+		})
+	}
+	return cr
+}
+
+// Crawl visits start and the sitemaps, following pagination until it runs
+// out, MaxPages is reached or the context is done
+func (cr *crawler) Crawl(start string) error {
+	if err := cr.visit(start, true); err != nil {
+		return err
+	}
+	for _, sitemap := range cr.p.Sitemaps {
+		if err := cr.visit(sitemap, false); err != nil {
+			return err
+		}
+	}
+	cr.c.Wait()
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return cr.err
+}
+
+// Pages returns the number of catalog pages visited
+func (cr *crawler) Pages() int {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return cr.pages
+}
+
+// follow visits a further catalog page, recording the first failure
+func (cr *crawler) follow(u string) {
+	if u == "" {
+		return
+	}
+	if err := cr.visit(u, true); err != nil {
+		cr.mu.Lock()
+		if cr.err == nil {
+			cr.err = err
+		}
+		cr.mu.Unlock()
+	}
+}
+
+// visit requests u unless the context is done, page visits count towards
+// MaxPages while sitemap documents do not
+func (cr *crawler) visit(u string, page bool) error {
+	if err := cr.ctx.Err(); err != nil {
+		return err
+	}
+	if page {
+		cr.mu.Lock()
+		if cr.p.MaxPages > 0 && cr.pages >= cr.p.MaxPages {
+			cr.mu.Unlock()
+			return nil
+		}
+		cr.pages++
+		cr.mu.Unlock()
+	}
+
+	err := cr.c.Visit(u)
+	if errors.Is(err, colly.ErrAlreadyVisited) {
+		if page {
+			cr.mu.Lock()
+			cr.pages--
+			cr.mu.Unlock()
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error visiting %s: %w", u, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package icons
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+//go:embed curate.html
+var curationPage []byte
+
+// CurationIcon is an icon of the curation UI with its current override
+type CurationIcon struct {
+	Icon     *IconPayload `json:"icon"`
+	Override Override     `json:"override"`
+}
+
+// overridesMu serializes the read-modify-write of overrides files by
+// curation handlers, which outlive the handlers a LiveDataset replaces
+var overridesMu sync.Mutex
+
+// curator reads and writes the overrides file behind CurationHandler
+type curator struct {
+	path string
+}
+
+// CurationHandler serves a web UI to search the icons of d, edit their
+// fields and approve or reject them. Saving writes the override of the icon
+// to the overrides file at path, applied by the next run. Mount it under a
+// prefix with http.StripPrefix, its page uses relative URLs:
+//
+//	GET /                the curation UI
+//	GET /search          SearchHandler
+//	GET /icons/{slug}    the icon and its override
+//	PUT /icons/{slug}    replaces the override of the icon, an empty one removes it
+func CurationHandler(d *Dataset, path string) http.Handler {
+	c := &curator{path: path}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(curationPage)
+	})
+	mux.Handle("/search", SearchHandler(d))
+	mux.HandleFunc("/icons/", func(w http.ResponseWriter, r *http.Request) {
+		icon, ok := d.Get(strings.TrimPrefix(r.URL.Path, "/icons/"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			c.get(w, icon)
+		case http.MethodPut:
+			c.put(w, r, icon)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	return mux
+}
+
+func (c *curator) get(w http.ResponseWriter, icon *IconPayload) {
+	overridesMu.Lock()
+	overrides, err := LoadOverrides(c.path)
+	overridesMu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(CurationIcon{Icon: icon, Override: overrides[icon.Slug]})
+}
+
+func (c *curator) put(w http.ResponseWriter, r *http.Request, icon *IconPayload) {
+	var o Override
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&o); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if o.ApprovalStatus != nil {
+		if err := validApproval(*o.ApprovalStatus); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	overrides, err := LoadOverrides(c.path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if overrides == nil {
+		overrides = make(map[string]Override)
+	}
+	if reflect.ValueOf(o).IsZero() {
+		delete(overrides, icon.Slug)
+	} else {
+		overrides[icon.Slug] = o
+	}
+	if err := writeOverrides(c.path, overrides); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(CurationIcon{Icon: icon, Override: o})
+}
@@ -0,0 +1,221 @@
+package icons
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// externalServiceTag marks icons drawn as external services in D2
+const externalServiceTag = "external-service"
+
+//go:embed packs
+var packsFS embed.FS
+
+// curatedPack is a hand-picked set of icons of an Iconify collection, kept
+// in packs/<name>.yaml, for domains the catalogs cover poorly
+type curatedPack struct {
+	Provider   string            `yaml:"provider"`
+	Collection string            `yaml:"collection"`
+	Categories []curatedCategory `yaml:"categories"`
+}
+
+// curatedCategory groups icons sharing a technical intent, its fields are
+// forced onto the icons like an override
+type curatedCategory struct {
+	Name            string  `yaml:"name"`
+	TechnicalIntent string  `yaml:"technical_intent"`
+	Summary         string  `yaml:"summary"`
+	ShapeType       *string `yaml:"shape_type"`
+	IsContainer     *bool   `yaml:"is_container"`
+	// Tags are added to the tags of the icons, e.g. externalServiceTag
+	Tags  []string      `yaml:"tags"`
+	Icons []curatedIcon `yaml:"icons"`
+}
+
+// curatedIcon is an icon of the collection, Icon is its name there. Color
+// sets the color theme and fills monochrome artwork
+type curatedIcon struct {
+	Icon    string   `yaml:"icon"`
+	Title   string   `yaml:"title"`
+	Aliases []string `yaml:"aliases"`
+	Color   string   `yaml:"color"`
+}
+
+var (
+	packsOnce sync.Once
+	packs     map[string]*curatedPack
+	packsErr  error
+)
+
+// loadPacks parses the embedded curated packs by name
+func loadPacks() (map[string]*curatedPack, error) {
+	packsOnce.Do(func() {
+		entries, err := packsFS.ReadDir("packs")
+		if err != nil {
+			packsErr = err
+			return
+		}
+		packs = make(map[string]*curatedPack, len(entries))
+		for _, e := range entries {
+			data, err := packsFS.ReadFile("packs/" + e.Name())
+			if err != nil {
+				packsErr = err
+				return
+			}
+			var p curatedPack
+			if err := yaml.Unmarshal(data, &p); err != nil {
+				packsErr = fmt.Errorf("error parsing pack %s: %w", e.Name(), err)
+				return
+			}
+			packs[strings.TrimSuffix(e.Name(), ".yaml")] = &p
+		}
+	})
+	return packs, packsErr
+}
+
+// curatedSource lists the icons of a curated pack that exist in its Iconify
+// collection
+type curatedSource struct {
+	cfg  SourceConfig
+	pack string
+}
+
+func (s *curatedSource) Name() string { return sourceName(s.cfg) }
+
+func (s *curatedSource) Fetch(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	all, err := loadPacks()
+	if err != nil {
+		return nil, err
+	}
+	pack, ok := all[s.pack]
+	if !ok {
+		return nil, fmt.Errorf("unknown curated pack %q", s.pack)
+	}
+	collection, err := fetchIconifyCollection(ctx, cfg.assetClient(), pack.Collection)
+	if err != nil {
+		return nil, err
+	}
+	available := make(map[string]bool)
+	for _, name := range collection.Uncategorized {
+		available[name] = true
+	}
+	for _, names := range collection.Categories {
+		for _, name := range names {
+			available[name] = true
+		}
+	}
+
+	pending := make([]PendingIcon, 0)
+	var missing []string
+	for _, c := range pack.Categories {
+		for _, icon := range c.Icons {
+			if !available[icon.Icon] {
+				missing = append(missing, icon.Icon)
+				continue
+			}
+			u := fmt.Sprintf("%s/%s/%s.svg", iconifyAPIURL, pack.Collection, icon.Icon)
+			if icon.Color != "" {
+				u += "?" + url.Values{"color": {icon.Color}}.Encode()
+			}
+			pending = append(pending, PendingIcon{
+				Category:    strings.ToUpper(pack.Provider),
+				Title:       icon.Icon,
+				Link:        pack.Provider + "%2F" + url.PathEscape(c.Name) + "%2F" + url.PathEscape(icon.Icon) + ".svg",
+				URL:         u,
+				DisplayName: icon.Title,
+			})
+		}
+	}
+	if len(missing) > 0 {
+		log.Printf("⚠️  Skipped %d icons missing from the %s collection: %s", len(missing), pack.Collection, strings.Join(missing, ", "))
+	}
+	return pending, nil
+}
+
+// applyPacks forces the technical intent, shape, tags and exact Iconify ID
+// of their category onto the icons of curated packs, which the enrichment
+// cannot tell apart from other products. Overrides applied later still win
+func applyPacks(icons []*IconPayload, timestamp string) int {
+	all, err := loadPacks()
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+		return 0
+	}
+	categories := make(map[string]map[string]*curatedCategory)
+	collections := make(map[string]string)
+	curated := make(map[string]*curatedIcon)
+	for _, pack := range all {
+		p, ok := Providers.Lookup(pack.Provider)
+		if !ok {
+			continue
+		}
+		categories[p.DisplayName] = make(map[string]*curatedCategory)
+		collections[p.DisplayName] = pack.Collection
+		for i, c := range pack.Categories {
+			categories[p.DisplayName][c.Name] = &pack.Categories[i]
+			for j, icon := range c.Icons {
+				curated[pack.Collection+":"+icon.Icon] = &pack.Categories[i].Icons[j]
+			}
+		}
+	}
+
+	applied := 0
+	for _, icon := range icons {
+		c, ok := categories[icon.Provider][icon.Category]
+		if !ok {
+			continue
+		}
+		o := Override{
+			ShapeType:   c.ShapeType,
+			IsContainer: c.IsContainer,
+		}
+		if c.TechnicalIntent != "" {
+			o.TechnicalIntent = &c.TechnicalIntent
+		}
+		if c.Summary != "" {
+			description := fmt.Sprintf("%s from %s. %s", icon.DisplayName, icon.Provider, c.Summary)
+			o.Description = &description
+		}
+		if len(c.Tags) > 0 {
+			o.Tags = mergeTerms(jsonToArray(icon.Tags), c.Tags)
+		}
+		if u, err := url.Parse(icon.URL); err == nil && strings.HasSuffix(u.Path, ".svg") {
+			id := collections[icon.Provider] + ":" + strings.TrimSuffix(path.Base(u.Path), ".svg")
+			o.IconifyID = &id
+			if ci := curated[id]; ci != nil {
+				if len(ci.Aliases) > 0 {
+					o.Aliases = mergeTerms(jsonToArray(icon.Aliases), ci.Aliases)
+				}
+				if ci.Color != "" {
+					o.ColorTheme = &ci.Color
+				}
+			}
+		}
+		o.apply(icon, timestamp)
+		applied++
+	}
+	return applied
+}
+
+// mergeTerms appends the terms of extra missing from terms, ignoring case
+func mergeTerms(terms, extra []string) []string {
+	seen := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		seen[strings.ToLower(t)] = true
+	}
+	for _, t := range extra {
+		if !seen[strings.ToLower(t)] {
+			seen[strings.ToLower(t)] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,74 @@
+package icons
+
+import (
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// darkBackground is the canvas color of dark diagram themes
+	darkBackground = "#1e1e1e"
+	// minDarkContrast is the WCAG contrast required for graphical objects
+	minDarkContrast = 3.0
+)
+
+// darkModeLegible reports whether any color of palette stands out against
+// the dark background
+func darkModeLegible(palette []rgb) bool {
+	bg, _ := parseColor(darkBackground)
+	for _, c := range palette {
+		if contrastRatio(c, bg) >= minDarkContrast {
+			return true
+		}
+	}
+	return false
+}
+
+// invertColor returns the RGB inverse of c
+func invertColor(c rgb) rgb {
+	return rgb{255 - c.R, 255 - c.G, 255 - c.B}
+}
+
+// generateDarkVariants checks every downloaded icon against the dark
+// background and writes an inverted variant of flat monochrome icons that are
+// not legible, returning the number of variants written
+func generateDarkVariants(cfg *Config, icons []*IconPayload, timestamp string) int {
+	generated := 0
+	for _, icon := range icons {
+		data, err := readAsset(cfg.OutputDir, icon)
+		if err != nil {
+			continue
+		}
+
+		palette := svgPalette(data)
+		legible := darkModeLegible(palette)
+		icon.DarkModeLegible = &legible
+		icon.setProvenance(SourceRules, timestamp, "dark_mode_legible")
+		if legible || len(palette) != 1 {
+			continue
+		}
+
+		rel := strings.TrimSuffix(icon.LocalPath, ".svg") + ".dark.svg"
+		if err := os.WriteFile(filepath.Join(cfg.OutputDir, filepath.FromSlash(rel)), recolorSVG(data, invertColor), 0600); err != nil {
+			log.Printf("⚠️  Dark variant %s: %v", icon.Slug, err)
+			continue
+		}
+		icon.DarkLocalPath = rel
+		icon.DarkURL = assetURL(cfg, rel)
+		icon.setProvenance(SourceRules, timestamp, "dark_local_path", "dark_url")
+		generated++
+	}
+	return generated
+}
+
+// assetURL returns the published URL of the output file rel, empty when no
+// AssetBaseURL is configured
+func assetURL(cfg *Config, rel string) string {
+	if cfg.AssetBaseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(cfg.AssetBaseURL, "/") + "/" + path.Clean(rel)
+}
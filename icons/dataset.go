@@ -0,0 +1,314 @@
+package icons
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Dataset is an in-memory, queryable icon corpus
+type Dataset struct {
+	Icons       []*IconPayload
+	bySlug      map[string]*IconPayload
+	byTerraform map[string]*IconPayload
+	suggest     *suggestTrie
+
+	// mu guards usage and popularity updates against concurrent searches
+	mu    sync.RWMutex
+	usage *UsageStats
+}
+
+// SearchOptions narrows a search
+type SearchOptions struct {
+	// Provider matches a provider key or display name
+	Provider string
+	// Limit caps the number of results, 0 means no limit
+	Limit int
+}
+
+// SearchResult is a ranked search match
+type SearchResult struct {
+	Icon  *IconPayload `json:"icon"`
+	Score float64      `json:"score"`
+}
+
+// NewDataset indexes icons for lookup
+func NewDataset(icons []*IconPayload) *Dataset {
+	d := &Dataset{
+		Icons:       icons,
+		bySlug:      make(map[string]*IconPayload, len(icons)),
+		byTerraform: make(map[string]*IconPayload),
+	}
+	for _, icon := range icons {
+		d.bySlug[icon.Slug] = icon
+		if icon.Identifiers == nil {
+			continue
+		}
+		for _, typ := range icon.Identifiers.TerraformResources {
+			if _, ok := d.byTerraform[typ]; !ok {
+				d.byTerraform[typ] = icon
+			}
+		}
+	}
+	d.suggest = newSuggestTrie(icons)
+	return d
+}
+
+// LoadDataset reads the output of an earlier run from dir, following the
+// latest link of snapshot directories. It reads icons_rag.json, or the
+// provider files when the corpus file is missing, after checking the schema
+// version of run_report.json. Output written before schema versions counts
+// as version 1
+func LoadDataset(dir string) (*Dataset, error) {
+	dir, icons, err := readCorpus(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(icons))
+	for i, icon := range icons {
+		if icon == nil || icon.Slug == "" {
+			return nil, fmt.Errorf("icon %d of %s has no slug", i+1, dir)
+		}
+		if seen[icon.Slug] {
+			return nil, fmt.Errorf("duplicate slug %s in %s", icon.Slug, dir)
+		}
+		seen[icon.Slug] = true
+	}
+	return NewDataset(icons), nil
+}
+
+// readCorpus reads the icons of the output in dir without checking them,
+// returning the directory it read after following the latest link
+func readCorpus(dir string) (string, []*IconPayload, error) {
+	dir = resolveLatest(dir)
+	report, err := loadRunReport(dir)
+	if err != nil {
+		return dir, nil, err
+	}
+	if report != nil {
+		if report.SchemaVersion > SchemaVersion {
+			return dir, nil, fmt.Errorf("%s has schema version %d, this version reads up to %d", dir, report.SchemaVersion, SchemaVersion)
+		}
+		if report.Partial {
+			log.Printf("⚠️  %s holds the partial output of an interrupted run", dir)
+		}
+	}
+
+	icons, err := loadIcons(filepath.Join(dir, jsonFile))
+	if err != nil {
+		return dir, nil, fmt.Errorf("error reading %s: %w", jsonFile, err)
+	}
+	if icons == nil {
+		if icons, err = loadProviderFiles(dir); err != nil {
+			return dir, nil, err
+		}
+	}
+	return dir, icons, nil
+}
+
+// loadRunReport reads the run report of dir, a missing report yields nil
+func loadRunReport(dir string) (*RunReport, error) {
+	data, err := os.ReadFile(filepath.Join(dir, runReportFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var report RunReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", runReportFile, err)
+	}
+	if report.SchemaVersion == 0 {
+		report.SchemaVersion = 1
+	}
+	return &report, nil
+}
+
+// loadProviderFiles reads the icons of every <provider>/<provider>.json of dir
+func loadProviderFiles(dir string) ([]*IconPayload, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var icons []*IconPayload
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name(), e.Name()+".json")
+		provider, err := loadIcons(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+		icons = append(icons, provider...)
+	}
+	if icons == nil {
+		return nil, fmt.Errorf("no %s or provider files in %s", jsonFile, dir)
+	}
+	return icons, nil
+}
+
+// Get returns the icon with slug
+func (d *Dataset) Get(slug string) (*IconPayload, bool) {
+	icon, ok := d.bySlug[slug]
+	return icon, ok
+}
+
+// Similar returns the other members of the family of the icon with slug
+func (d *Dataset) Similar(slug string) []*IconPayload {
+	icon, ok := d.bySlug[slug]
+	if !ok || icon.FamilyID == "" {
+		return nil
+	}
+	similar := make([]*IconPayload, 0)
+	for _, other := range d.Icons {
+		if other.FamilyID == icon.FamilyID && other.Slug != slug {
+			similar = append(similar, other)
+		}
+	}
+	return similar
+}
+
+// Search ranks icons matching query by name, aliases, tags and intent. The
+// results hold copies of the icons, taken under the popularity lock
+func (d *Dataset) Search(query string, opts SearchOptions) []SearchResult {
+	query = strings.ToLower(strings.TrimSpace(query))
+	tokens := searchTokens(query)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	results := make([]SearchResult, 0)
+	for _, icon := range d.Icons {
+		if opts.Provider != "" && !matchesProvider(icon, opts.Provider) {
+			continue
+		}
+		score := scoreIcon(icon, query, tokens)
+		if score <= 0 {
+			continue
+		}
+		results = append(results, SearchResult{Icon: snapshotIcon(icon), Score: score + float64(icon.Popularity)})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Icon.Slug < results[j].Icon.Slug
+	})
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results
+}
+
+// snapshotIcon copies icon with its provenance, so results can be read after
+// the lock is released while RecomputePopularity updates the dataset
+func snapshotIcon(icon *IconPayload) *IconPayload {
+	c := *icon
+	c.Provenance = maps.Clone(icon.Provenance)
+	return &c
+}
+
+// Lookup returns the best match for name, or nil when nothing matches or
+// name is blank
+func (d *Dataset) Lookup(name, provider string) *SearchResult {
+	if strings.TrimSpace(name) == "" {
+		return nil
+	}
+	results := d.Search(name, SearchOptions{Provider: provider, Limit: 1})
+	if len(results) == 0 {
+		return nil
+	}
+	return &results[0]
+}
+
+// matchesProvider reports whether provider is the key or display name of the
+// icon provider
+func matchesProvider(icon *IconPayload, provider string) bool {
+	return strings.EqualFold(icon.Provider, provider) || strings.EqualFold(providerDir(icon.Provider), provider)
+}
+
+// scoreIcon scores an icon against a lower cased query and its tokens
+func scoreIcon(icon *IconPayload, query string, tokens []string) float64 {
+	if query == "" {
+		return 1
+	}
+
+	name := strings.ToLower(icon.DisplayName)
+	score := 0.0
+	switch {
+	case icon.Slug == query || name == query:
+		score += 100
+	case strings.Contains(name, query):
+		score += 40
+	}
+
+	nameWords := wordSet(name)
+	slugWords := wordSet(icon.Slug)
+	aliases := wordSet(strings.ToLower(strings.Join(jsonToArray(icon.Aliases), " ")))
+	tags := wordSet(strings.ToLower(strings.Join(jsonToArray(icon.Tags), " ")))
+	for _, alias := range jsonToArray(icon.Aliases) {
+		if strings.ToLower(alias) == query {
+			score += 60
+		}
+	}
+	intent := strings.ToLower(icon.TechnicalIntent + " " + icon.Description)
+
+	matched := 0
+	for _, t := range tokens {
+		hit := false
+		if nameWords[t] {
+			score += 10
+			hit = true
+		}
+		if aliases[t] {
+			score += 6
+			hit = true
+		}
+		if tags[t] {
+			score += 4
+			hit = true
+		}
+		if slugWords[t] {
+			score += 3
+			hit = true
+		}
+		if strings.Contains(intent, t) {
+			score++
+			hit = true
+		}
+		if hit {
+			matched++
+		}
+	}
+	if len(tokens) > 0 && matched == 0 && score == 0 {
+		return 0
+	}
+	return score
+}
+
+// searchTokens splits a query into lower cased words
+func searchTokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func wordSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range searchTokens(s) {
+		set[w] = true
+	}
+	return set
+}
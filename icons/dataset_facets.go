@@ -0,0 +1,92 @@
+package icons
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxTagFacets caps the tag counts of Facets, the most frequent first
+const maxTagFacets = 50
+
+// FacetCount is the number of icons with a value of a facet
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets counts the icons matching a query per value of the fields a filter
+// UI offers, the most frequent values first
+type Facets struct {
+	// Total is the number of icons matching the query
+	Total      int          `json:"total"`
+	Providers  []FacetCount `json:"providers"`
+	Categories []FacetCount `json:"categories"`
+	// Tags holds the 50 most frequent tags, lower cased
+	Tags       []FacetCount `json:"tags"`
+	ShapeTypes []FacetCount `json:"shape_types"`
+}
+
+// Facets returns the facet counts of the icons matching q, whose sort, limit
+// and cursor are ignored. Provider and shape type counts leave out their own
+// filter, so a UI can offer the other values of a checked facet, while the
+// other counts apply every filter
+func (d *Dataset) Facets(q IconQuery) Facets {
+	noShape := q
+	noShape.ShapeType = ""
+	providers := make(map[string]int)
+	categories := make(map[string]int)
+	tags := make(map[string]int)
+	shapes := make(map[string]int)
+	total := 0
+	for _, r := range d.Search(q.Query, SearchOptions{}) {
+		icon := r.Icon
+		inProvider := q.Provider == "" || matchesProvider(icon, q.Provider)
+		if inProvider && noShape.matches(icon) && icon.ShapeType != "" {
+			shapes[icon.ShapeType]++
+		}
+		if !q.matches(icon) {
+			continue
+		}
+		providers[icon.Provider]++
+		if !inProvider {
+			continue
+		}
+		total++
+		if icon.Category != "" {
+			categories[icon.Category]++
+		}
+		seen := make(map[string]bool)
+		for _, t := range jsonToArray(icon.Tags) {
+			if t = strings.ToLower(t); !seen[t] {
+				seen[t] = true
+				tags[t]++
+			}
+		}
+	}
+	return Facets{
+		Total:      total,
+		Providers:  facetCounts(providers, 0),
+		Categories: facetCounts(categories, 0),
+		Tags:       facetCounts(tags, maxTagFacets),
+		ShapeTypes: facetCounts(shapes, 0),
+	}
+}
+
+// facetCounts sorts counts by count then value, keeping the first limit
+// unless it is zero
+func facetCounts(counts map[string]int, limit int) []FacetCount {
+	facets := make([]FacetCount, 0, len(counts))
+	for v, n := range counts {
+		facets = append(facets, FacetCount{Value: v, Count: n})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	if limit > 0 && len(facets) > limit {
+		facets = facets[:limit]
+	}
+	return facets
+}
@@ -0,0 +1,265 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchHandler serves Dataset.Search over HTTP with the q, provider and
+// limit query parameters, answering the ranked results as JSON. The top
+// result of a query is credited as a usage hit, like a query without a click
+// in a usage log
+func SearchHandler(d *Dataset) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		opts := SearchOptions{Provider: q.Get("provider")}
+		if limit := q.Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 0 {
+				http.Error(w, fmt.Sprintf("invalid limit %q", limit), http.StatusBadRequest)
+				return
+			}
+			opts.Limit = n
+		}
+		results := d.Search(q.Get("q"), opts)
+		if strings.TrimSpace(q.Get("q")) != "" && len(results) > 0 {
+			d.recordHits(results[0].Icon.Slug, queryHitWeight)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(results)
+	})
+}
+
+// SuggestHandler serves Dataset.Suggest over HTTP with the q and limit query
+// parameters, answering the completions as JSON
+func SuggestHandler(d *Dataset) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := 0
+		if s := r.URL.Query().Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				http.Error(w, fmt.Sprintf("invalid limit %q", s), http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(d.Suggest(r.URL.Query().Get("q"), limit))
+	})
+}
+
+// IconsHandler serves Dataset.Page over HTTP with the q, provider, tag,
+// is_container, shape_type, min_popularity, sort, limit and cursor query
+// parameters, answering the IconPage as JSON. tag may repeat and limit is
+// capped at 200
+func IconsHandler(d *Dataset) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseIconQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		page, err := d.Page(q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(page)
+	})
+}
+
+// FacetsHandler serves Dataset.Facets over HTTP with the query parameters of
+// IconsHandler, answering the counts as JSON
+func FacetsHandler(d *Dataset) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseIconQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(d.Facets(q))
+	})
+}
+
+// ResolveHandler serves Dataset.Resolve over HTTP, accepting a POSTed
+// ResolveRequest of at most 1000 names and answering the resolutions as JSON.
+// Every resolved icon is credited as a usage hit
+func ResolveHandler(d *Dataset) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req ResolveRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(req.Names) > maxResolveNames {
+			http.Error(w, fmt.Sprintf("%d names, at most %d per request", len(req.Names), maxResolveNames), http.StatusRequestEntityTooLarge)
+			return
+		}
+		resolved := d.Resolve(req.Names, req.Provider)
+		for _, res := range resolved {
+			if res.Found {
+				d.RecordHit(res.Slug)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resolved)
+	})
+}
+
+// exportFlushEvery is the number of icons ExportHandler writes between
+// flushes
+const exportFlushEvery = 100
+
+// ExportHandler streams every icon of d as a JSON line, or those of the
+// provider query parameter and, with since, an RFC 3339 time, those modified
+// after it. Icons are encoded and flushed one by one, so large corpora are
+// never buffered in memory
+func ExportHandler(d *Dataset) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider := r.URL.Query().Get("provider")
+		var since time.Time
+		if s := r.URL.Query().Get("since"); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid since %q, want an RFC 3339 time", s), http.StatusBadRequest)
+				return
+			}
+			since = t
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher, _ := w.(http.Flusher)
+		var line bytes.Buffer
+		e := json.NewEncoder(&line)
+		e.SetEscapeHTML(false)
+		d.mu.RLock()
+		icons := d.Icons
+		d.mu.RUnlock()
+		written := 0
+		for _, icon := range icons {
+			if provider != "" && !matchesProvider(icon, provider) {
+				continue
+			}
+			if !since.IsZero() {
+				modified, err := time.Parse(time.RFC3339, icon.LastModified)
+				if err != nil || !modified.After(since) {
+					continue
+				}
+			}
+			line.Reset()
+			d.mu.RLock()
+			err := e.Encode(icon)
+			d.mu.RUnlock()
+			if err != nil {
+				return
+			}
+			if _, err := w.Write(line.Bytes()); err != nil {
+				// the client went away
+				return
+			}
+			if written++; flusher != nil && written%exportFlushEvery == 0 {
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// parseIconQuery reads an IconQuery from query parameters
+func parseIconQuery(v url.Values) (IconQuery, error) {
+	q := IconQuery{
+		Query:     v.Get("q"),
+		Provider:  v.Get("provider"),
+		ShapeType: v.Get("shape_type"),
+		Sort:      v.Get("sort"),
+		Cursor:    v.Get("cursor"),
+	}
+	for _, tags := range v["tag"] {
+		for _, t := range strings.Split(tags, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				q.Tags = append(q.Tags, t)
+			}
+		}
+	}
+	if s := v.Get("is_container"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return q, fmt.Errorf("invalid is_container %q", s)
+		}
+		q.IsContainer = &b
+	}
+	if s := v.Get("min_popularity"); s != "" {
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return q, fmt.Errorf("invalid min_popularity %q", s)
+		}
+		q.MinPopularity = float32(f)
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid limit %q", s)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// remoteSearchTimeout bounds a RemoteSearch request
+const remoteSearchTimeout = 30 * time.Second
+
+var remoteSearchClient = &http.Client{Timeout: remoteSearchTimeout}
+
+// RemoteSearch queries a SearchHandler mounted at endpoint, e.g.
+// http://localhost:8080/search, sending apiKey as X-API-Key when set for
+// servers that require a reader key
+func RemoteSearch(ctx context.Context, endpoint, apiKey, query string, opts SearchOptions) ([]SearchResult, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("q", query)
+	if opts.Provider != "" {
+		q.Set("provider", opts.Provider)
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	resp, err := remoteSearchClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("search returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var results []SearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("error decoding search results: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,191 @@
+package icons
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// Sort orders of an IconQuery
+const (
+	SortRelevance    = "relevance"
+	SortPopularity   = "popularity"
+	SortName         = "name"
+	SortLastModified = "last_modified"
+)
+
+// ErrInvalidCursor is returned by Dataset.Page for a cursor it did not issue
+// for the same sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// IconQuery filters, sorts and pages the icons of a dataset
+type IconQuery struct {
+	// Query is searched like Dataset.Search, empty matches every icon
+	Query    string
+	Provider string
+	// Tags are all required, ignoring case
+	Tags          []string
+	IsContainer   *bool
+	ShapeType     string
+	MinPopularity float32
+	// Sort is relevance, popularity, name or last_modified, defaulting to
+	// relevance with a query and name without
+	Sort string
+	// Limit is the page size, 50 by default and at most 200
+	Limit int
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+}
+
+// IconPage is a page of the icons matching an IconQuery
+type IconPage struct {
+	Icons []SearchResult `json:"icons"`
+	// Total is the number of matching icons over all pages
+	Total int `json:"total"`
+	// NextCursor fetches the next page, empty on the last one
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// pageKey is the position of an icon in a sort order, and as a cursor the
+// position of the last icon of a page. Cursors stay valid when icons move
+// while paging, e.g. as their popularity changes
+type pageKey struct {
+	Sort string  `json:"o"`
+	Num  float64 `json:"n,omitempty"`
+	Text string  `json:"t,omitempty"`
+	Slug string  `json:"s"`
+}
+
+// Page returns the page of q, ordered with ties broken by slug
+func (d *Dataset) Page(q IconQuery) (IconPage, error) {
+	order := q.Sort
+	if order == "" {
+		order = SortName
+		if strings.TrimSpace(q.Query) != "" {
+			order = SortRelevance
+		}
+	}
+	if order != SortRelevance && order != SortPopularity && order != SortName && order != SortLastModified {
+		return IconPage{}, fmt.Errorf("unknown sort %q", q.Sort)
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	limit = min(limit, maxPageSize)
+	var cursor *pageKey
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil || c.Sort != order {
+			return IconPage{}, ErrInvalidCursor
+		}
+		cursor = &c
+	}
+
+	results := d.Search(q.Query, SearchOptions{Provider: q.Provider})
+	matched := results[:0]
+	keys := make(map[string]pageKey, len(results))
+	for _, r := range results {
+		if !q.matches(r.Icon) {
+			continue
+		}
+		matched = append(matched, r)
+		keys[r.Icon.Slug] = newPageKey(order, r)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return keys[matched[i].Icon.Slug].before(keys[matched[j].Icon.Slug])
+	})
+
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(matched), func(i int) bool {
+			return cursor.before(keys[matched[i].Icon.Slug])
+		})
+	}
+	end := min(start+limit, len(matched))
+	page := IconPage{Icons: matched[start:end], Total: len(matched)}
+	if end < len(matched) {
+		page.NextCursor = keys[matched[end-1].Icon.Slug].encode()
+	}
+	return page, nil
+}
+
+// matches reports whether icon passes the filters of q other than the query
+// and provider
+func (q IconQuery) matches(icon *IconPayload) bool {
+	if q.IsContainer != nil && icon.IsContainer != *q.IsContainer {
+		return false
+	}
+	if q.ShapeType != "" && !strings.EqualFold(icon.ShapeType, q.ShapeType) {
+		return false
+	}
+	if icon.Popularity < q.MinPopularity {
+		return false
+	}
+	if len(q.Tags) == 0 {
+		return true
+	}
+	tags := make(map[string]bool)
+	for _, t := range jsonToArray(icon.Tags) {
+		tags[strings.ToLower(t)] = true
+	}
+	for _, t := range q.Tags {
+		if !tags[strings.ToLower(t)] {
+			return false
+		}
+	}
+	return true
+}
+
+// newPageKey returns the position of r in order
+func newPageKey(order string, r SearchResult) pageKey {
+	k := pageKey{Sort: order, Slug: r.Icon.Slug}
+	switch order {
+	case SortRelevance:
+		k.Num = r.Score
+	case SortPopularity:
+		k.Num = float64(r.Icon.Popularity)
+	case SortName:
+		k.Text = strings.ToLower(r.Icon.DisplayName)
+	case SortLastModified:
+		k.Text = r.Icon.LastModified
+	}
+	return k
+}
+
+// before reports whether k comes before other: by descending relevance,
+// popularity or last_modified, or by ascending name
+func (k pageKey) before(other pageKey) bool {
+	switch {
+	case k.Sort == SortName && k.Text != other.Text:
+		return k.Text < other.Text
+	case k.Sort == SortLastModified && k.Text != other.Text:
+		return k.Text > other.Text
+	case k.Num != other.Num:
+		return k.Num > other.Num
+	}
+	return k.Slug < other.Slug
+}
+
+func (k pageKey) encode() string {
+	data, _ := json.Marshal(k)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (pageKey, error) {
+	var k pageKey
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return k, err
+	}
+	err = json.Unmarshal(data, &k)
+	return k, err
+}
@@ -0,0 +1,68 @@
+package icons
+
+import "strings"
+
+const (
+	// maxResolveNames caps the names of a resolve request
+	maxResolveNames = 1000
+	// strongMatchScore is the score from which a match counts as strong on
+	// its own, e.g. the name containing the query
+	strongMatchScore = 40
+)
+
+// ResolveRequest is the body of a resolve request: the node names of a
+// diagram, optionally restricted to a provider
+type ResolveRequest struct {
+	Names    []string `json:"names"`
+	Provider string   `json:"provider,omitempty"`
+}
+
+// Resolution is the best icon for a node name
+type Resolution struct {
+	Name string `json:"name"`
+	LookupIconResult
+	// Confidence is from 0 to 1, see resolveConfidence
+	Confidence float64 `json:"confidence"`
+}
+
+// Resolve returns the best icon for every name, in order, with the
+// confidence of the match. Repeated names are only searched once
+func (d *Dataset) Resolve(names []string, provider string) []Resolution {
+	resolved := make([]Resolution, len(names))
+	seen := make(map[string]Resolution)
+	for i, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if r, ok := seen[key]; ok {
+			r.Name = name
+			resolved[i] = r
+			continue
+		}
+		r := Resolution{Name: name}
+		if key != "" {
+			results := d.Search(name, SearchOptions{Provider: provider})
+			if len(results) > 0 {
+				r.LookupIconResult = newLookupIconResult(&results[0])
+				r.Confidence = resolveConfidence(results)
+			}
+		}
+		seen[key] = r
+		resolved[i] = r
+	}
+	return resolved
+}
+
+// resolveConfidence rates the best of ranked results from 0 to 1: half for
+// how strongly it matches, half for its lead over the next different icon.
+// Variants of the best icon, like its light version, are not competitors
+func resolveConfidence(results []SearchResult) float64 {
+	best := results[0]
+	strength := min(best.Score/strongMatchScore, 1)
+	lead := 1.0
+	for _, r := range results[1:] {
+		if !sameIcon(best.Icon, r.Icon) {
+			lead = (best.Score - r.Score) / best.Score
+			break
+		}
+	}
+	return float64(int((strength*(0.5+0.5*lead))*qualityScale+0.5)) / qualityScale
+}
@@ -0,0 +1,112 @@
+package icons
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const diffFile = "diff_report.json"
+
+// DiffEntry describes a single changed icon
+type DiffEntry struct {
+	Slug   string   `json:"slug"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// DiffReport compares a run with the previous output
+type DiffReport struct {
+	Added          []string    `json:"added"`
+	Removed        []string    `json:"removed"`
+	Changed        []DiffEntry `json:"changed"`
+	ArtworkChanged []string    `json:"artwork_changed"`
+}
+
+// loadIcons reads a previously written icons file, a missing file yields nil
+func loadIcons(path string) ([]*IconPayload, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var icons []*IconPayload
+	if err := json.Unmarshal(data, &icons); err != nil {
+		return nil, err
+	}
+	return icons, nil
+}
+
+// diffIcons compares previous and current icons by slug
+func diffIcons(previous, current []*IconPayload) DiffReport {
+	report := DiffReport{
+		Added:          []string{},
+		Removed:        []string{},
+		Changed:        []DiffEntry{},
+		ArtworkChanged: []string{},
+	}
+
+	prev := make(map[string]*IconPayload, len(previous))
+	for _, icon := range previous {
+		prev[icon.Slug] = icon
+	}
+	seen := make(map[string]bool, len(current))
+
+	for _, icon := range current {
+		seen[icon.Slug] = true
+		old, ok := prev[icon.Slug]
+		if !ok {
+			report.Added = append(report.Added, icon.Slug)
+			continue
+		}
+		if old.ContentSHA256 != "" && icon.ContentSHA256 != "" && old.ContentSHA256 != icon.ContentSHA256 {
+			report.ArtworkChanged = append(report.ArtworkChanged, icon.Slug)
+		}
+		if fields := changedFields(old, icon); len(fields) > 0 {
+			report.Changed = append(report.Changed, DiffEntry{Slug: icon.Slug, Fields: fields})
+		}
+	}
+	for slug := range prev {
+		if !seen[slug] {
+			report.Removed = append(report.Removed, slug)
+		}
+	}
+
+	sort.Strings(report.Added)
+	sort.Strings(report.Removed)
+	sort.Strings(report.ArtworkChanged)
+	sort.Slice(report.Changed, func(i, j int) bool { return report.Changed[i].Slug < report.Changed[j].Slug })
+	return report
+}
+
+// changedFields lists the content fields that differ between a and b,
+// ignoring volatile fields such as id and last_seen
+func changedFields(a, b *IconPayload) []string {
+	var fields []string
+	check := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	check("iconify_id", a.IconifyID != b.IconifyID)
+	check("provider", a.Provider != b.Provider)
+	check("category", a.Category != b.Category || a.Subcategory != b.Subcategory)
+	check("url", a.URL != b.URL)
+	check("semantic_profile", a.SemanticProfile != b.SemanticProfile)
+	check("display_name", a.DisplayName != b.DisplayName)
+	check("aliases", a.Aliases != b.Aliases)
+	check("description", a.Description != b.Description)
+	check("technical_intent", a.TechnicalIntent != b.TechnicalIntent)
+	check("shape_type", a.ShapeType != b.ShapeType)
+	check("default_width", a.DefaultWidth != b.DefaultWidth)
+	check("is_container", a.IsContainer != b.IsContainer)
+	check("color_theme", a.ColorTheme != b.ColorTheme)
+	check("popularity", a.Popularity != b.Popularity)
+	check("tags", a.Tags != b.Tags)
+	check("content_sha256", a.ContentSHA256 != b.ContentSHA256)
+	return fields
+}
@@ -0,0 +1,228 @@
+package icons
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	disambiguationFile = "disambiguation.yaml"
+	// confusableSimilarity is the share of name concepts two icons need in
+	// common to count as confusable
+	confusableSimilarity = 0.5
+	// maxConfusables caps the confusable icons recorded per icon
+	maxConfusables = 3
+	// maxConceptIcons skips concepts shared by more icons, they say nothing
+	// about an icon
+	maxConceptIcons = 40
+)
+
+// genericConcepts are name words that do not tell services apart
+var genericConcepts = map[string]bool{"service": true, "cloud": true, "light": true, "dark": true, "icon": true, "managed": true}
+
+// Confusable is an icon easily confused with the one listing it
+type Confusable struct {
+	Slug string `json:"slug"`
+	// Note says how the icons differ
+	Note string `json:"note"`
+}
+
+// ConfusableGroup is a curated group of icons easily confused with each
+// other, with a note on how to choose between them
+type ConfusableGroup struct {
+	Slugs []string `yaml:"slugs"`
+	Note  string   `yaml:"note"`
+}
+
+// LoadConfusables reads a disambiguation file, a missing file yields nil
+func LoadConfusables(path string) ([]ConfusableGroup, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading disambiguation %s: %w", path, err)
+	}
+	var groups []ConfusableGroup
+	if err := yaml.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("error parsing disambiguation %s: %w", path, err)
+	}
+	return groups, nil
+}
+
+// disambiguate records on every icon the icons it is easily confused with:
+// the members of its curated groups, then icons whose names share most of
+// their concepts, e.g. Cloud Functions and Azure Function Apps. Services
+// named differently, like Lambda, need a curated group. It returns the number
+// of icons with confusables
+func disambiguate(icons []*IconPayload, curated []ConfusableGroup, timestamp string) int {
+	bySlug := make(map[string]*IconPayload, len(icons))
+	for _, icon := range icons {
+		bySlug[icon.Slug] = icon
+		icon.Disambiguation = nil
+	}
+
+	for _, g := range curated {
+		for _, slug := range g.Slugs {
+			icon, ok := bySlug[slug]
+			if !ok {
+				continue
+			}
+			for _, other := range g.Slugs {
+				if o, ok := bySlug[other]; ok && other != slug {
+					addConfusable(icon, Confusable{Slug: other, Note: curatedNote(o, g.Note)})
+				}
+			}
+		}
+	}
+
+	concepts := make([]map[string]bool, len(icons))
+	index := make(map[string][]int)
+	for i, icon := range icons {
+		concepts[i] = nameConcepts(icon)
+		for c := range concepts[i] {
+			index[c] = append(index[c], i)
+		}
+	}
+	for i, icon := range icons {
+		type candidate struct {
+			icon     *IconPayload
+			concepts map[string]bool
+			score    float64
+		}
+		shared := make(map[int]int)
+		for c := range concepts[i] {
+			if len(index[c]) > maxConceptIcons {
+				continue
+			}
+			for _, j := range index[c] {
+				if j != i {
+					shared[j]++
+				}
+			}
+		}
+		var candidates []candidate
+		for j, n := range shared {
+			score := float64(n) / float64(len(concepts[i])+len(concepts[j])-n)
+			if score >= confusableSimilarity && !sameIcon(icon, icons[j]) {
+				candidates = append(candidates, candidate{icons[j], concepts[j], score})
+			}
+		}
+		sort.Slice(candidates, func(a, b int) bool {
+			if candidates[a].score != candidates[b].score {
+				return candidates[a].score > candidates[b].score
+			}
+			return candidates[a].icon.Slug < candidates[b].icon.Slug
+		})
+		for _, c := range candidates {
+			if len(icon.Disambiguation) >= maxConfusables {
+				break
+			}
+			addConfusable(icon, Confusable{Slug: c.icon.Slug, Note: similarNote(icon, c.icon, concepts[i], c.concepts)})
+		}
+	}
+
+	n := 0
+	for _, icon := range icons {
+		if len(icon.Disambiguation) > 0 {
+			icon.setProvenance(SourceRules, timestamp, "disambiguation")
+			n++
+		}
+	}
+	return n
+}
+
+// addConfusable adds c to icon unless it lists the icon already
+func addConfusable(icon *IconPayload, c Confusable) {
+	for _, existing := range icon.Disambiguation {
+		if existing.Slug == c.Slug {
+			return
+		}
+	}
+	icon.Disambiguation = append(icon.Disambiguation, c)
+}
+
+// nameConcepts returns the distinctive words of the name of icon, singular
+// and without vendor words. Aliases are left out, LLM aliases are too
+// generic to tell services apart
+func nameConcepts(icon *IconPayload) map[string]bool {
+	concepts := make(map[string]bool)
+	p, _ := Providers.ByDisplayName(icon.Provider)
+	for _, w := range searchTokens(icon.DisplayName) {
+		if len(w) > 3 {
+			w = strings.TrimSuffix(w, "s")
+		}
+		if len(w) < 2 || vendorTokens[w] || genericConcepts[w] || w == p.Key || w == strings.ToLower(icon.Provider) {
+			continue
+		}
+		concepts[w] = true
+	}
+	return concepts
+}
+
+// sameIcon reports whether a and b show the same thing: variants of one
+// icon, such as a light version, or copies from two sources
+func sameIcon(a, b *IconPayload) bool {
+	if a.Provider != b.Provider {
+		return false
+	}
+	if strings.EqualFold(a.DisplayName, b.DisplayName) {
+		return true
+	}
+	base := func(slug string) string { return strings.TrimSuffix(strings.TrimSuffix(slug, "-light"), "-dark") }
+	return base(a.Slug) == base(b.Slug)
+}
+
+// similarNote explains how icon differs from other, a similarly named icon,
+// given the name concepts of both
+func similarNote(icon, other *IconPayload, concepts, otherConcepts map[string]bool) string {
+	if icon.Provider != other.Provider {
+		return fmt.Sprintf("%s is a similarly named %s icon, pick by the provider of the diagram", other.DisplayName, other.Provider)
+	}
+	switch {
+	case len(concepts) == len(otherConcepts):
+		// same concepts, only the intent tells them apart
+	case subset(concepts, otherConcepts):
+		return fmt.Sprintf("%s shows a part or feature of %s, use it only when the diagram shows that part", other.DisplayName, icon.DisplayName)
+	case subset(otherConcepts, concepts):
+		return fmt.Sprintf("%s is the whole service, use it unless the diagram shows this part", other.DisplayName)
+	}
+	if intent := strings.TrimSuffix(other.TechnicalIntent, "."); intent != "" {
+		return fmt.Sprintf("%s is a different %s service: %s", other.DisplayName, other.Provider, lowerFirst(intent))
+	}
+	return fmt.Sprintf("%s is a different %s service", other.DisplayName, other.Provider)
+}
+
+// subset reports whether every concept of a is in b
+func subset(a, b map[string]bool) bool {
+	for c := range a {
+		if !b[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// curatedNote prefixes the note of a curated group with the icon it is
+// about
+func curatedNote(other *IconPayload, note string) string {
+	if note == "" {
+		return fmt.Sprintf("Often confused with %s", other.DisplayName)
+	}
+	return fmt.Sprintf("Often confused with %s: %s", other.DisplayName, strings.TrimSuffix(note, "."))
+}
+
+// lowerFirst lower cases the first letter of s unless it starts an acronym
+func lowerFirst(s string) string {
+	if len(s) < 2 || strings.ToUpper(s[:2]) == s[:2] {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
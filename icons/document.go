@@ -0,0 +1,80 @@
+package icons
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+)
+
+// defaultDocumentTemplate renders the text chunk embedded for retrieval
+const defaultDocumentTemplate = `{{ .DisplayName }} is an icon from {{ .Provider }}
+{{- with .Category }} in the {{ . }}{{ with $.Subcategory }} / {{ . }}{{ end }} category{{ end }}.
+{{- with list_of .Aliases }} Also known as {{ join ", " . }}.{{ end }}
+{{- with .TechnicalIntent }} {{ sentence . }}{{ end }}
+{{- with .SemanticProfile }} {{ sentence . }}{{ end }}
+{{- if .IsContainer }} It is typically drawn as a container grouping other resources.{{ end }}
+{{- with list_of .Tags }} Tags: {{ join ", " . }}.{{ end }}
+{{- range .Disambiguation }} {{ sentence .Note }}{{ end }}`
+
+// newDocumentTemplate parses the configured template source or file,
+// falling back to the default template
+func newDocumentTemplate(cfg *Config) (*template.Template, error) {
+	src := defaultDocumentTemplate
+	switch {
+	case cfg.DocumentTemplate != "":
+		src = cfg.DocumentTemplate
+	case cfg.DocumentTemplateFile != "":
+		data, err := os.ReadFile(filepath.Clean(cfg.DocumentTemplateFile))
+		if err != nil {
+			return nil, fmt.Errorf("error reading document template: %w", err)
+		}
+		src = string(data)
+	}
+
+	funcs := sprig.TxtFuncMap()
+	funcs["list_of"] = jsonToArray
+	funcs["sentence"] = sentence
+	tmpl, err := template.New("document").Funcs(funcs).Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing document template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderDocuments sets the document field of every icon
+func renderDocuments(tmpl *template.Template, icons []*IconPayload, timestamp string) error {
+	var buf bytes.Buffer
+	for _, icon := range icons {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, icon); err != nil {
+			return fmt.Errorf("error rendering document for %s: %w", icon.Slug, err)
+		}
+		icon.Document = strings.TrimSpace(buf.String())
+		icon.setProvenance(SourceRules, timestamp, "document")
+	}
+	return nil
+}
+
+// jsonToArray decodes a JSON encoded string array as produced by arrayToJSON
+func jsonToArray(s string) []string {
+	var arr []string
+	if err := json.Unmarshal([]byte(s), &arr); err != nil {
+		return nil
+	}
+	return arr
+}
+
+// sentence trims s and terminates it with a period
+func sentence(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasSuffix(s, ".") || strings.HasSuffix(s, "!") || strings.HasSuffix(s, "?") {
+		return s
+	}
+	return s + "."
+}
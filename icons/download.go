@@ -0,0 +1,111 @@
+package icons
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// downloads serves the corpus files of an output directory with ETags
+type downloads struct {
+	mu    sync.Mutex
+	etags map[string]fileETag
+}
+
+// fileETag is the ETag of a file as of its modification time and size
+type fileETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// DownloadHandler serves the corpus files of the output directory dir, or of
+// its latest snapshot, for clients polling for new versions:
+//
+//	GET /dataset.json        icons_rag.json
+//	GET /providers/{p}.json  the provider file of provider p
+//
+// Responses carry the SHA-256 of the file as a strong ETag and its
+// modification time as Last-Modified, and conditional requests with
+// If-None-Match or If-Modified-Since get 304 until a new corpus is written
+func DownloadHandler(dir string) http.Handler {
+	dl := &downloads{etags: make(map[string]fileETag)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dataset.json", func(w http.ResponseWriter, r *http.Request) {
+		dl.serve(w, r, filepath.Join(resolveLatest(dir), jsonFile))
+	})
+	mux.HandleFunc("/providers/", func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/providers/"), ".json")
+		if !ok || key == "" || filepath.Base(key) != key || !filepath.IsLocal(key) {
+			http.NotFound(w, r)
+			return
+		}
+		dl.serve(w, r, filepath.Join(resolveLatest(dir), key, key+".json"))
+	})
+	return mux
+}
+
+func (dl *downloads) serve(w http.ResponseWriter, r *http.Request, path string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	f, err := os.Open(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	etag, err := dl.etag(path, f, info)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "application/json")
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), f)
+}
+
+// etag returns the ETag of the open file f at path, hashing it only when it
+// changed since the last request, and rewinds f
+func (dl *downloads) etag(path string, f *os.File, info fs.FileInfo) (string, error) {
+	dl.mu.Lock()
+	cached, ok := dl.etags[path]
+	dl.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.etag, nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+	dl.mu.Lock()
+	dl.etags[path] = fileETag{modTime: info.ModTime(), size: info.Size(), etag: etag}
+	dl.mu.Unlock()
+	return etag, nil
+}
@@ -0,0 +1,196 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Enrichment statuses recorded per icon
+const (
+	EnrichmentLLM      = "llm"
+	EnrichmentRetried  = "retried"
+	EnrichmentFallback = "fallback"
+	EnrichmentDisabled = "disabled"
+)
+
+const (
+	maxEnrichmentRetries = 2
+	retryBackoff         = 2 * time.Second
+)
+
+// retryItem is an icon whose enrichment failed during the main pass
+type retryItem struct {
+	Pending PendingIcon
+	Icon    *IconPayload
+}
+
+// retryEnrichment retries failed icons individually at the end of the run and
+// falls back to heuristics for icons that still fail
+func retryEnrichment(client *http.Client, queue []retryItem, timestamp string) {
+	log.Printf("🔁 Retrying enrichment for %d icons...", len(queue))
+
+	recovered := 0
+	for _, item := range queue {
+		if recoverEnrichment(client, item, timestamp) {
+			recovered++
+		}
+	}
+
+	log.Printf("🔁 Retry pass: %d recovered, %d fell back to heuristics", recovered, len(queue)-recovered)
+}
+
+// recoverEnrichment retries the enrichment of item and falls back to
+// heuristics, reporting whether the LLM eventually succeeded
+func recoverEnrichment(client *http.Client, item retryItem, timestamp string) bool {
+	p := item.Pending
+	enrichment, err := retryLLMEnrichment(client, p)
+	if err == nil {
+		applyEnrichment(item.Icon, p.Category, enrichment, SourceLLM, timestamp)
+		item.Icon.EnrichmentStatus = EnrichmentRetried
+		return true
+	}
+
+	log.Printf("⚠️  Enrichment failed for %s, using heuristics: %v", item.Icon.Slug, err)
+	fallbackEnrichment(item, timestamp)
+	return false
+}
+
+// retryLLMEnrichment asks the LLM service to enrich p up to
+// maxEnrichmentRetries times
+func retryLLMEnrichment(client *http.Client, p PendingIcon) (enrichment LLMEnrichmentResponse, err error) {
+	for attempt := 0; attempt < maxEnrichmentRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(retryBackoff * time.Duration(attempt))
+		}
+		if enrichment, err = getLLMEnrichment(client, p.Category, p.Title, p.DisplayName); err == nil {
+			return enrichment, nil
+		}
+	}
+	return enrichment, err
+}
+
+// fallbackEnrichment enriches item from heuristics without calling the LLM
+func fallbackEnrichment(item retryItem, timestamp string) {
+	applyEnrichment(item.Icon, item.Pending.Category, heuristicEnrichment(item.Pending), SourceRules, timestamp)
+	item.Icon.EnrichmentStatus = EnrichmentFallback
+}
+
+// heuristicEnrichment derives enrichment from the title alone
+func heuristicEnrichment(p PendingIcon) LLMEnrichmentResponse {
+	var tags []string
+	for _, t := range searchTokens(p.Title) {
+		if len(t) > 2 {
+			tags = append(tags, t)
+		}
+	}
+	return LLMEnrichmentResponse{
+		Aliases:     []string{strings.ToLower(p.DisplayName)},
+		Tags:        tags,
+		ShapeType:   determineShapeType(p.Category),
+		IsContainer: containerPatterns.MatchString(p.Title),
+		// rules only see the title
+		Confidence: map[string]float32{"aliases": 0.5, "tags": 0.4, "shape_type": 0.3, "is_container": 0.4},
+	}
+}
+
+// Reenrich runs the enrichment again over the existing output of cfg without
+// scraping, e.g. after the prompt or the model of the LLM service changed.
+// IDs, URLs and scrape metadata are kept; the corpus stages and quality gates
+// run again before the result is published like any other run
+func Reenrich(ctx context.Context, cfg *Config) (*RunReport, error) {
+	if !useLLMEnrichment {
+		return nil, errors.New("re-enrichment needs LLM enrichment")
+	}
+	return updateOutput(ctx, cfg, func(ctx context.Context, report *RunReport, icons []*IconPayload, timestamp string) error {
+		if !llmServiceAvailable {
+			return errors.New("re-enrichment needs the LLM service")
+		}
+		mark := stageClock.start()
+		if err := reenrichIcons(ctx, cfg.client(), icons, timestamp); err != nil {
+			return err
+		}
+		stageClock.done(stageEnrich, mark, len(icons))
+		return nil
+	})
+}
+
+// reenrichIcons replaces the enrichment of icons. Icons still failing after
+// the retry pass keep their previous enrichment
+func reenrichIcons(ctx context.Context, client *http.Client, icons []*IconPayload, timestamp string) error {
+	pending := make([]PendingIcon, len(icons))
+	for i, icon := range icons {
+		pending[i] = pendingFromIcon(icon)
+	}
+	log.Printf("🔄 Re-enriching %d icons...", len(icons))
+
+	var retries []retryItem
+	step := 1
+	if useBatchProcessing {
+		step = batchSize
+	}
+	for i := 0; i < len(icons) && ctx.Err() == nil; i += step {
+		end := i + step
+		if end > len(icons) {
+			end = len(icons)
+		}
+
+		var (
+			enrichments []LLMEnrichmentResponse
+			err         error
+		)
+		if useBatchProcessing {
+			enrichments, err = batchEnrichIcons(client, pending[i:end])
+		} else {
+			var enrichment LLMEnrichmentResponse
+			enrichment, err = getLLMEnrichment(client, pending[i].Category, pending[i].Title, pending[i].DisplayName)
+			enrichments = []LLMEnrichmentResponse{enrichment}
+		}
+
+		for j := i; j < end; j++ {
+			item := retryItem{Pending: pending[j], Icon: icons[j]}
+			if err != nil || j-i >= len(enrichments) {
+				retries = append(retries, item)
+				continue
+			}
+			applyEnrichment(item.Icon, item.Pending.Category, enrichments[j-i], SourceLLM, timestamp)
+			item.Icon.EnrichmentStatus = EnrichmentLLM
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	kept := 0
+	for _, item := range retries {
+		enrichment, err := retryLLMEnrichment(client, item.Pending)
+		if err != nil {
+			log.Printf("⚠️  Re-enrichment failed for %s, keeping its enrichment: %v", item.Icon.Slug, err)
+			kept++
+			continue
+		}
+		applyEnrichment(item.Icon, item.Pending.Category, enrichment, SourceLLM, timestamp)
+		item.Icon.EnrichmentStatus = EnrichmentRetried
+	}
+	log.Printf("✅ Re-enriched %d of %d icons", len(icons)-kept, len(icons))
+	return nil
+}
+
+// pendingFromIcon rebuilds the scraped icon that icon was created from, the
+// display name stands in for the stripped title, which is not kept
+func pendingFromIcon(icon *IconPayload) PendingIcon {
+	category := strings.ToLower(icon.Provider)
+	if p, ok := Providers.ByDisplayName(icon.Provider); ok {
+		category = p.Key
+	}
+	return PendingIcon{
+		Category:    category,
+		Title:       icon.DisplayName,
+		RawTitle:    icon.RawTitle,
+		URL:         icon.URL,
+		DisplayName: icon.DisplayName,
+		Variants:    icon.Variants,
+	}
+}
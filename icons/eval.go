@@ -0,0 +1,129 @@
+package icons
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultEvalK is the cutoff of hit@k and MRR when the set has none
+const defaultEvalK = 5
+
+//go:embed eval_queries.yaml
+var evalQueriesYAML []byte
+
+// EvalCase is a query and the slugs that count as a hit for it
+type EvalCase struct {
+	Query string `yaml:"query" json:"query"`
+	// Provider narrows the search like SearchOptions.Provider
+	Provider string   `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Expected []string `yaml:"expected" json:"expected"`
+}
+
+// EvalSet is a retrieval evaluation set
+type EvalSet struct {
+	// K is the cutoff of the metrics, 5 by default
+	K     int        `yaml:"k,omitempty"`
+	Cases []EvalCase `yaml:"cases"`
+}
+
+// DefaultEvalSet returns the built-in evaluation set
+func DefaultEvalSet() (*EvalSet, error) {
+	return parseEvalSet(evalQueriesYAML, "built-in eval set")
+}
+
+// LoadEvalSet reads an evaluation set from a YAML file
+func LoadEvalSet(path string) (*EvalSet, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading eval set %s: %w", path, err)
+	}
+	return parseEvalSet(data, path)
+}
+
+func parseEvalSet(data []byte, name string) (*EvalSet, error) {
+	var set EvalSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", name, err)
+	}
+	for i, c := range set.Cases {
+		if c.Query == "" || len(c.Expected) == 0 {
+			return nil, fmt.Errorf("case %d of %s needs a query and expected slugs", i+1, name)
+		}
+	}
+	if set.K <= 0 {
+		set.K = defaultEvalK
+	}
+	return &set, nil
+}
+
+// EvalResult is the outcome of one case
+type EvalResult struct {
+	EvalCase
+	// Rank is the 1-based rank of the first expected slug, 0 when none was
+	// in the top K
+	Rank int `json:"rank"`
+	// Top are the slugs of the top K results
+	Top []string `json:"top"`
+}
+
+// EvalReport is the result of Evaluate
+type EvalReport struct {
+	K       int `json:"k"`
+	Queries int `json:"queries"`
+	Hits    int `json:"hits"`
+	// HitAtK is the share of queries with an expected slug in the top K
+	HitAtK float64 `json:"hit_at_k"`
+	// MRR is the mean reciprocal rank of the first expected slug, counting
+	// misses as 0
+	MRR     float64      `json:"mrr"`
+	Results []EvalResult `json:"results"`
+}
+
+// SearchFunc runs a search, e.g. Dataset.Search or RemoteSearch
+type SearchFunc func(query string, opts SearchOptions) ([]SearchResult, error)
+
+// Evaluate runs the queries of set through search and scores the results
+// against their expected slugs
+func Evaluate(set *EvalSet, search SearchFunc) (*EvalReport, error) {
+	k := set.K
+	if k <= 0 {
+		k = defaultEvalK
+	}
+	report := &EvalReport{K: k, Queries: len(set.Cases), Results: make([]EvalResult, 0, len(set.Cases))}
+	var reciprocal float64
+	for _, c := range set.Cases {
+		results, err := search(c.Query, SearchOptions{Provider: c.Provider, Limit: k})
+		if err != nil {
+			return nil, fmt.Errorf("error searching %q: %w", c.Query, err)
+		}
+		expected := make(map[string]bool, len(c.Expected))
+		for _, slug := range c.Expected {
+			expected[slug] = true
+		}
+
+		result := EvalResult{EvalCase: c, Top: make([]string, 0, k)}
+		for i, r := range results {
+			if i == k {
+				break
+			}
+			result.Top = append(result.Top, r.Icon.Slug)
+			if result.Rank == 0 && expected[r.Icon.Slug] {
+				result.Rank = i + 1
+			}
+		}
+		if result.Rank > 0 {
+			report.Hits++
+			reciprocal += 1 / float64(result.Rank)
+		}
+		report.Results = append(report.Results, result)
+	}
+	if report.Queries > 0 {
+		report.HitAtK = float64(report.Hits) / float64(report.Queries)
+		report.MRR = reciprocal / float64(report.Queries)
+	}
+	return report, nil
+}
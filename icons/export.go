@@ -0,0 +1,96 @@
+package icons
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const exportsDir = "exports"
+
+// Exporter writes the corpus in a downstream tool format
+type Exporter interface {
+	// Name identifies the exporter in Config.Exports
+	Name() string
+	// Export writes files for icons into ctx.Dir
+	Export(ctx *ExportContext, icons []*IconPayload) error
+}
+
+// ExportContext locates the export directory and downloaded assets
+type ExportContext struct {
+	// OutputDir is the root of the generated corpus
+	OutputDir string
+	// Dir is the directory the exporter writes into
+	Dir string
+	// Config is the configuration of the run
+	Config *Config
+
+	run context.Context
+}
+
+// Context returns the context of the run, canceled when the run is, so
+// long-running exporters can stop early
+func (ctx *ExportContext) Context() context.Context {
+	if ctx.run == nil {
+		return context.Background()
+	}
+	return ctx.run
+}
+
+// Namespace returns the namespace of the run, empty when there is none
+func (ctx *ExportContext) Namespace() string {
+	if ctx.Config == nil {
+		return ""
+	}
+	return ctx.Config.Namespace
+}
+
+// ReadAsset returns the downloaded SVG of icon
+func (ctx *ExportContext) ReadAsset(icon *IconPayload) ([]byte, error) {
+	return readAsset(ctx.OutputDir, icon)
+}
+
+var exporters = map[string]Exporter{}
+
+// RegisterExporter makes an exporter selectable by name
+func RegisterExporter(e Exporter) {
+	exporters[e.Name()] = e
+}
+
+// runExports runs the configured exporters into the exports directory
+func runExports(ctx context.Context, cfg *Config, icons []*IconPayload) error {
+	for _, name := range cfg.Exports {
+		e, ok := exporters[name]
+		if !ok {
+			return fmt.Errorf("unknown exporter %q", name)
+		}
+		dir := filepath.Join(cfg.OutputDir, exportsDir, name)
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return err
+		}
+		if err := e.Export(&ExportContext{OutputDir: cfg.OutputDir, Dir: dir, Config: cfg, run: ctx}, icons); err != nil {
+			return fmt.Errorf("error exporting %s: %w", name, err)
+		}
+		log.Printf("📦 Exported %s to %s", name, dir)
+	}
+	return nil
+}
+
+// groupByProvider returns icons grouped by provider directory, with the
+// directories sorted
+func groupByProvider(icons []*IconPayload) ([]string, map[string][]*IconPayload) {
+	groups := make(map[string][]*IconPayload)
+	for _, icon := range icons {
+		dir := providerDir(icon.Provider)
+		groups[dir] = append(groups[dir], icon)
+	}
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, groups
+}
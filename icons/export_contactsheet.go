@@ -0,0 +1,121 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	contactSheetColumns = 8
+	// contactSheetSize keeps sheets of large providers within the size a
+	// browser can capture
+	contactSheetSize = 200
+	contactCellWidth = 120
+)
+
+//go:embed contactsheet.tmpl
+var contactSheetTmpl string
+
+// PageRenderer renders HTML documents to PNG images of the whole page
+type PageRenderer func(ctx context.Context, pages []string) ([][]byte, error)
+
+var pageRenderer PageRenderer
+
+// RegisterPageRenderer sets the renderer of the contactsheet export
+func RegisterPageRenderer(r PageRenderer) {
+	pageRenderer = r
+}
+
+func init() {
+	RegisterExporter(contactSheetExporter{})
+}
+
+// contactCell is a thumbnail of a contact sheet
+type contactCell struct {
+	Slug string
+	Src  template.URL
+}
+
+// contactSheetExporter writes a PNG per provider with a grid of thumbnails
+// of the downloaded assets labelled with their slugs, e.g. aws.png, split
+// into aws-1.png, aws-2.png and so on for large providers
+type contactSheetExporter struct{}
+
+func (contactSheetExporter) Name() string { return "contactsheet" }
+
+func (contactSheetExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	if pageRenderer == nil {
+		return fmt.Errorf("the contactsheet export requires building with -tags chromedp")
+	}
+	tmpl, err := template.New("contactsheet").Parse(contactSheetTmpl)
+	if err != nil {
+		return err
+	}
+
+	var (
+		names []string
+		pages []string
+	)
+	keys, groups := groupByProvider(icons)
+	for _, key := range keys {
+		var cells []contactCell
+		for _, icon := range groups[key] {
+			data, err := ctx.ReadAsset(icon)
+			if err != nil {
+				continue
+			}
+			cells = append(cells, contactCell{
+				Slug: icon.Slug,
+				Src:  template.URL("data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(data)),
+			})
+		}
+		if len(cells) == 0 {
+			log.Printf("⚠️  No downloaded assets for a %s contact sheet", groups[key][0].Provider)
+			continue
+		}
+
+		sheets := (len(cells) + contactSheetSize - 1) / contactSheetSize
+		for i := 0; i < sheets; i++ {
+			end := min((i+1)*contactSheetSize, len(cells))
+			name, title := key+".png", groups[key][0].Provider
+			if sheets > 1 {
+				name = fmt.Sprintf("%s-%d.png", key, i+1)
+				title = fmt.Sprintf("%s (%d/%d)", title, i+1, sheets)
+			}
+			var buf bytes.Buffer
+			err := tmpl.Execute(&buf, map[string]any{
+				"Title":   fmt.Sprintf("%s, %d icons", title, end-i*contactSheetSize),
+				"Columns": contactSheetColumns,
+				"Width":   contactSheetColumns * contactCellWidth,
+				"Cells":   cells[i*contactSheetSize : end],
+			})
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+			pages = append(pages, buf.String())
+		}
+	}
+	if len(pages) == 0 {
+		return nil
+	}
+
+	images, err := pageRenderer(ctx.Context(), pages)
+	if err != nil {
+		return fmt.Errorf("error rendering contact sheets: %w", err)
+	}
+	for i, img := range images {
+		path := filepath.Join(ctx.Dir, names[i])
+		if err := os.WriteFile(filepath.Clean(path), img, 0600); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package icons
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+func init() {
+	RegisterExporter(d2Exporter{})
+}
+
+// d2Exporter writes one importable D2 file per provider holding a class per
+// icon and a vars map of icon URLs, e.g. ...@aws-icons.d2
+type d2Exporter struct{}
+
+func (d2Exporter) Name() string { return "d2" }
+
+func (d2Exporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	keys, groups := groupByProvider(icons)
+	for _, key := range keys {
+		path := filepath.Join(ctx.Dir, fmt.Sprintf("%s-icons.d2", key))
+		if err := writeD2Pack(path, groups[key], false); err != nil {
+			return err
+		}
+		if !hasMonoVariants(groups[key]) {
+			continue
+		}
+		path = filepath.Join(ctx.Dir, fmt.Sprintf("%s-icons-sketch.d2", key))
+		if err := writeD2Pack(path, groups[key], true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasMonoVariants reports whether any icon has a published monochrome variant
+func hasMonoVariants(icons []*IconPayload) bool {
+	for _, icon := range icons {
+		if icon.MonoURL != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeD2Pack writes the D2 pack of icons, the sketch pack enables sketch mode
+// and uses the monochrome artwork where available
+func writeD2Pack(path string, icons []*IconPayload, sketch bool) error {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "# %s icons generated by terrastruct-icons\n\n", icons[0].Provider)
+
+	iconURL := func(icon *IconPayload) string {
+		if sketch && icon.MonoURL != "" {
+			return icon.MonoURL
+		}
+		return icon.URL
+	}
+
+	fmt.Fprintln(w, "vars: {")
+	if sketch {
+		fmt.Fprintln(w, "  d2-config: {")
+		fmt.Fprintln(w, "    sketch: true")
+		fmt.Fprintln(w, "  }")
+	}
+	fmt.Fprintln(w, "  icons: {")
+	for _, icon := range icons {
+		fmt.Fprintf(w, "    %s: %s\n", icon.Slug, strconv.Quote(iconURL(icon)))
+	}
+	fmt.Fprintln(w, "  }")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "classes: {")
+	for _, icon := range icons {
+		fmt.Fprintf(w, "  %s: {\n", icon.Slug)
+		fmt.Fprintf(w, "    label: %s\n", strconv.Quote(icon.DisplayName))
+		fmt.Fprintf(w, "    icon: %s\n", strconv.Quote(iconURL(icon)))
+		switch {
+		case icon.IsContainer:
+			fmt.Fprintln(w, "    shape: rectangle")
+		case isExternalService(icon):
+			// outside the system boundary, drawn dashed in the brand color
+			fmt.Fprintln(w, "    shape: rectangle")
+			fmt.Fprintln(w, "    style.stroke-dash: 3")
+			if hexColorRgx.MatchString(icon.ColorTheme) {
+				fmt.Fprintf(w, "    style.stroke: %s\n", strconv.Quote(icon.ColorTheme))
+			}
+		default:
+			fmt.Fprintf(w, "    shape: %s\n", d2Shape(icon.ShapeType))
+			fmt.Fprintf(w, "    width: %d\n", icon.DefaultWidth)
+			fmt.Fprintf(w, "    height: %d\n", icon.DefaultWidth)
+		}
+		fmt.Fprintln(w, "  }")
+	}
+	fmt.Fprintln(w, "}")
+
+	return w.Flush()
+}
+
+// isExternalService reports whether icon has the external service role
+func isExternalService(icon *IconPayload) bool {
+	for _, tag := range jsonToArray(icon.Tags) {
+		if tag == externalServiceTag {
+			return true
+		}
+	}
+	return false
+}
+
+// d2Shape maps a shape type to a D2 shape, defaulting to image
+func d2Shape(shapeType string) string {
+	switch shapeType {
+	case "rectangle", "square", "cylinder", "queue", "cloud", "person",
+		"circle", "oval", "hexagon", "diamond", "package", "page", "document", "stored_data":
+		return shapeType
+	default:
+		return "image"
+	}
+}
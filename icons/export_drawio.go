@@ -0,0 +1,80 @@
+package icons
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	RegisterExporter(drawioExporter{})
+}
+
+// drawioShape is an entry of a draw.io custom library
+type drawioShape struct {
+	Data   string `json:"data,omitempty"`
+	XML    string `json:"xml,omitempty"`
+	W      int    `json:"w"`
+	H      int    `json:"h"`
+	Title  string `json:"title"`
+	Aspect string `json:"aspect"`
+}
+
+// drawioExporter writes one draw.io custom shape library per provider,
+// embedding downloaded artwork and falling back to the icon URL
+type drawioExporter struct{}
+
+func (drawioExporter) Name() string { return "drawio" }
+
+func (drawioExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	keys, groups := groupByProvider(icons)
+	for _, key := range keys {
+		shapes := make([]drawioShape, 0, len(groups[key]))
+		for _, icon := range groups[key] {
+			shapes = append(shapes, newDrawioShape(ctx, icon))
+		}
+		path := filepath.Join(ctx.Dir, fmt.Sprintf("%s.xml", key))
+		if err := writeDrawioLibrary(path, shapes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newDrawioShape(ctx *ExportContext, icon *IconPayload) drawioShape {
+	size := icon.DefaultWidth
+	if size == 0 {
+		size = 64
+	}
+	shape := drawioShape{W: size, H: size, Title: icon.DisplayName, Aspect: "fixed"}
+
+	if data, err := ctx.ReadAsset(icon); err == nil {
+		shape.Data = "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(data)
+		return shape
+	}
+
+	style := "shape=image;verticalLabelPosition=bottom;verticalAlign=top;imageAspect=0;aspect=fixed;image=" + icon.URL + ";"
+	shape.XML = fmt.Sprintf(`<mxGraphModel><root><mxCell id="0"/><mxCell id="1" parent="0"/>`+
+		`<mxCell id="2" value="%s" style="%s" vertex="1" parent="1"><mxGeometry width="%d" height="%d" as="geometry"/></mxCell>`+
+		`</root></mxGraphModel>`, html.EscapeString(icon.DisplayName), html.EscapeString(style), size, size)
+	return shape
+}
+
+func writeDrawioLibrary(path string, shapes []drawioShape) error {
+	data, err := json.Marshal(shapes)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("<mxlibrary>")
+	if err := xml.EscapeText(&buf, data); err != nil {
+		return err
+	}
+	buf.WriteString("</mxlibrary>\n")
+	return os.WriteFile(filepath.Clean(path), buf.Bytes(), 0600)
+}
@@ -0,0 +1,149 @@
+package icons
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"path/filepath"
+	"time"
+)
+
+func init() {
+	RegisterExporter(excalidrawExporter{})
+}
+
+// excalidrawLibrary is the .excalidrawlib v2 document
+type excalidrawLibrary struct {
+	Type         string                    `json:"type"`
+	Version      int                       `json:"version"`
+	Source       string                    `json:"source"`
+	LibraryItems []excalidrawItem          `json:"libraryItems"`
+	Files        map[string]excalidrawFile `json:"files"`
+}
+
+type excalidrawItem struct {
+	ID       string              `json:"id"`
+	Status   string              `json:"status"`
+	Created  int64               `json:"created"`
+	Name     string              `json:"name"`
+	Elements []excalidrawElement `json:"elements"`
+}
+
+type excalidrawElement struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	X               float64   `json:"x"`
+	Y               float64   `json:"y"`
+	Width           float64   `json:"width"`
+	Height          float64   `json:"height"`
+	Angle           float64   `json:"angle"`
+	StrokeColor     string    `json:"strokeColor"`
+	BackgroundColor string    `json:"backgroundColor"`
+	FillStyle       string    `json:"fillStyle"`
+	StrokeWidth     int       `json:"strokeWidth"`
+	StrokeStyle     string    `json:"strokeStyle"`
+	Roughness       int       `json:"roughness"`
+	Opacity         int       `json:"opacity"`
+	GroupIDs        []string  `json:"groupIds"`
+	Seed            uint32    `json:"seed"`
+	Version         int       `json:"version"`
+	VersionNonce    uint32    `json:"versionNonce"`
+	IsDeleted       bool      `json:"isDeleted"`
+	Updated         int64     `json:"updated"`
+	Locked          bool      `json:"locked"`
+	Status          string    `json:"status"`
+	FileID          string    `json:"fileId"`
+	Scale           []float64 `json:"scale"`
+}
+
+type excalidrawFile struct {
+	ID       string `json:"id"`
+	MimeType string `json:"mimeType"`
+	DataURL  string `json:"dataURL"`
+	Created  int64  `json:"created"`
+}
+
+// excalidrawExporter writes an .excalidrawlib per provider and a combined
+// library from the downloaded artwork
+type excalidrawExporter struct{}
+
+func (excalidrawExporter) Name() string { return "excalidraw" }
+
+func (excalidrawExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	all := newExcalidrawLibrary()
+	keys, groups := groupByProvider(icons)
+	for _, key := range keys {
+		lib := newExcalidrawLibrary()
+		for _, icon := range groups[key] {
+			data, err := ctx.ReadAsset(icon)
+			if err != nil {
+				continue
+			}
+			lib.add(icon, data)
+			all.add(icon, data)
+		}
+		if err := ctx.Config.writeJSON(filepath.Join(ctx.Dir, key+".excalidrawlib"), lib); err != nil {
+			return err
+		}
+	}
+	return ctx.Config.writeJSON(filepath.Join(ctx.Dir, "all.excalidrawlib"), all)
+}
+
+func newExcalidrawLibrary() *excalidrawLibrary {
+	return &excalidrawLibrary{
+		Type:         "excalidrawlib",
+		Version:      2,
+		Source:       "https://github.com/tf2d2/terrastruct-icons",
+		LibraryItems: []excalidrawItem{},
+		Files:        map[string]excalidrawFile{},
+	}
+}
+
+// add appends icon as a single image element item, ids are derived from the
+// slug so libraries are stable across runs
+func (l *excalidrawLibrary) add(icon *IconPayload, svg []byte) {
+	size := float64(icon.DefaultWidth)
+	if size == 0 {
+		size = 64
+	}
+	created := int64(0)
+	if t, err := time.Parse(time.RFC3339, icon.LastModified); err == nil {
+		created = t.UnixMilli()
+	}
+
+	fileID := contentHash(svg)[:40]
+	l.Files[fileID] = excalidrawFile{
+		ID:       fileID,
+		MimeType: "image/svg+xml",
+		DataURL:  "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(svg),
+		Created:  created,
+	}
+
+	sum := sha256.Sum256([]byte(icon.Slug))
+	l.LibraryItems = append(l.LibraryItems, excalidrawItem{
+		ID:      "item-" + icon.Slug,
+		Status:  "published",
+		Created: created,
+		Name:    icon.DisplayName,
+		Elements: []excalidrawElement{{
+			ID:              "img-" + icon.Slug,
+			Type:            "image",
+			Width:           size,
+			Height:          size,
+			StrokeColor:     "transparent",
+			BackgroundColor: "transparent",
+			FillStyle:       "solid",
+			StrokeWidth:     1,
+			StrokeStyle:     "solid",
+			Opacity:         100,
+			GroupIDs:        []string{},
+			Seed:            binary.BigEndian.Uint32(sum[:4]),
+			Version:         1,
+			VersionNonce:    binary.BigEndian.Uint32(sum[4:8]),
+			Updated:         created,
+			Status:          "saved",
+			FileID:          fileID,
+			Scale:           []float64{1, 1},
+		}},
+	})
+}
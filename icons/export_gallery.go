@@ -0,0 +1,98 @@
+package icons
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed gallery.tmpl
+var galleryTmpl string
+
+func init() {
+	RegisterExporter(galleryExporter{})
+}
+
+// galleryIcon is an icon of the gallery with its preview
+type galleryIcon struct {
+	Icon *IconPayload
+	// Src is the downloaded asset as a data URI, or the remote URL
+	Src    template.URL
+	Tags   []string
+	Search string
+}
+
+// galleryProvider is the section of a provider in the gallery
+type galleryProvider struct {
+	Key   string
+	Name  string
+	Icons []galleryIcon
+}
+
+// galleryExporter writes a self-contained index.html previewing every icon
+// with its metadata, grouped by provider and searchable, to review a run
+// before it is published
+type galleryExporter struct{}
+
+func (galleryExporter) Name() string { return "gallery" }
+
+func (galleryExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	tmpl, err := template.New("gallery").Funcs(template.FuncMap{"join": strings.Join}).Parse(galleryTmpl)
+	if err != nil {
+		return err
+	}
+
+	title := "Icons"
+	if ns := ctx.Namespace(); ns != "" {
+		title = ns + " icons"
+	}
+	data := struct {
+		Title     string
+		Total     int
+		Providers []galleryProvider
+	}{Title: title, Total: len(icons)}
+
+	keys, groups := groupByProvider(icons)
+	for _, key := range keys {
+		provider := galleryProvider{Key: key, Name: groups[key][0].Provider}
+		for _, icon := range groups[key] {
+			tags := jsonToArray(icon.Tags)
+			search := append([]string{icon.DisplayName, icon.Slug, icon.IconifyID, icon.Category}, tags...)
+			search = append(search, jsonToArray(icon.Aliases)...)
+			provider.Icons = append(provider.Icons, galleryIcon{
+				Icon:   icon,
+				Src:    gallerySrc(ctx, icon),
+				Tags:   tags,
+				Search: strings.ToLower(strings.Join(search, " ")),
+			})
+		}
+		data.Providers = append(data.Providers, provider)
+	}
+
+	path := filepath.Join(ctx.Dir, "index.html")
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", path, err)
+	}
+	if err := tmpl.Execute(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// gallerySrc inlines the downloaded SVG of icon so the gallery works on its
+// own, falling back to the remote URL when no asset was downloaded
+func gallerySrc(ctx *ExportContext, icon *IconPayload) template.URL {
+	if data, err := ctx.ReadAsset(icon); err == nil {
+		return template.URL("data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(data))
+	}
+	if strings.HasPrefix(icon.URL, "http://") || strings.HasPrefix(icon.URL, "https://") {
+		return template.URL(icon.URL)
+	}
+	return ""
+}
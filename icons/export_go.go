@@ -0,0 +1,134 @@
+package icons
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/Masterminds/sprig"
+)
+
+// Icon is the typed accessor emitted by the Go package exporter
+type Icon struct {
+	Slug        string
+	IconifyID   string
+	Provider    string
+	DisplayName string
+	URL         string
+}
+
+//go:embed icons.tmpl
+var goPackageTmpl string
+
+var (
+	goIdentRgx = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+	goInitialisms = map[string]bool{
+		"API": true, "ARN": true, "AWS": true, "CDN": true, "CPU": true, "DB": true, "DNS": true,
+		"EBS": true, "EC2": true, "ECR": true, "ECS": true, "EFS": true, "EKS": true, "ELB": true,
+		"EMR": true, "GCP": true, "GKE": true, "GPU": true, "HTTP": true, "IAM": true, "ID": true,
+		"IOT": true, "IP": true, "KMS": true, "ML": true, "NAT": true, "RDS": true, "S3": true,
+		"SDK": true, "SNS": true, "SQL": true, "SQS": true, "SSL": true, "TLS": true, "UI": true,
+		"URL": true, "VM": true, "VPC": true, "VPN": true, "WAF": true,
+	}
+)
+
+func init() {
+	RegisterExporter(goExporter{})
+}
+
+// goIcon pairs an icon with its Go identifier
+type goIcon struct {
+	Name string
+	Icon *IconPayload
+}
+
+// goExporter writes a Go package per provider with a typed variable per
+// icon, e.g. aws.EC2, using the icons.tmpl template
+type goExporter struct{}
+
+func (goExporter) Name() string { return "go" }
+
+func (goExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	tmpl, err := template.New("icons").Funcs(sprig.TxtFuncMap()).Parse(goPackageTmpl)
+	if err != nil {
+		return err
+	}
+
+	keys, groups := groupByProvider(icons)
+	for _, key := range keys {
+		pkg := goPackageName(key)
+		// Icons is the list declared by icons.tmpl
+		used := map[string]bool{"Icons": true}
+		entries := make([]goIcon, 0, len(groups[key]))
+		for _, icon := range groups[key] {
+			base := goIdentifier(strings.TrimPrefix(icon.Slug, key+"-"))
+			name := base
+			for n := 2; used[name]; n++ {
+				name = fmt.Sprintf("%s%d", base, n)
+			}
+			used[name] = true
+			entries = append(entries, goIcon{Name: name, Icon: icon})
+		}
+
+		var buf bytes.Buffer
+		err := tmpl.Execute(&buf, map[string]any{
+			"Package":  pkg,
+			"Provider": groups[key][0].Provider,
+			"Icons":    entries,
+		})
+		if err != nil {
+			return err
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("error formatting package %s: %w", pkg, err)
+		}
+
+		dir := filepath.Join(ctx.Dir, pkg)
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, pkg+".go"), src, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goIdentifier converts a slug to an exported Go identifier, upper casing
+// known initialisms
+func goIdentifier(slug string) string {
+	var b strings.Builder
+	for _, word := range goIdentRgx.Split(slug, -1) {
+		if word == "" {
+			continue
+		}
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + strings.ToLower(word[1:]))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "Icon" + name
+	}
+	return name
+}
+
+// goPackageName converts a provider directory to a Go package name
+func goPackageName(dir string) string {
+	name := strings.ToLower(goIdentRgx.ReplaceAllString(dir, ""))
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "p" + name
+	}
+	return name
+}
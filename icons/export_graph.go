@@ -0,0 +1,249 @@
+package icons
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	relatedFile = "related.yaml"
+
+	// Edge kinds of the related-icons graph
+	EdgeCurated    = "curated"
+	EdgeTagOverlap = "tag_overlap"
+
+	// minSharedTags and minTagSimilarity gate tag overlap edges, maxTagEdges
+	// caps them per icon so generic tags do not connect everything
+	minSharedTags    = 2
+	minTagSimilarity = 0.5
+	maxTagEdges      = 5
+)
+
+func init() {
+	RegisterExporter(graphExporter{})
+}
+
+// GraphNode is an icon of the related-icons graph
+type GraphNode struct {
+	ID       string `json:"id"`
+	Label    string `json:"label"`
+	Provider string `json:"provider"`
+	FamilyID string `json:"family_id,omitempty"`
+}
+
+// GraphEdge connects icons that commonly appear together
+type GraphEdge struct {
+	Source string  `json:"source"`
+	Target string  `json:"target"`
+	Weight float64 `json:"weight"`
+	Kind   string  `json:"kind"`
+}
+
+// RelatedGraph is the related-icons graph written by the graph exporter
+type RelatedGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// LoadRelated reads a curated adjacency file mapping a slug to the slugs it
+// commonly appears with, a missing file yields no adjacency
+func LoadRelated(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading related icons %s: %w", path, err)
+	}
+
+	related := make(map[string][]string)
+	if err := yaml.Unmarshal(data, &related); err != nil {
+		return nil, fmt.Errorf("error parsing related icons %s: %w", path, err)
+	}
+	return related, nil
+}
+
+// buildRelatedGraph connects icons from the curated adjacency plus icons
+// sharing enough tags
+func buildRelatedGraph(icons []*IconPayload, curated map[string][]string) *RelatedGraph {
+	g := &RelatedGraph{Nodes: make([]GraphNode, 0, len(icons)), Edges: make([]GraphEdge, 0)}
+	known := make(map[string]bool, len(icons))
+	for _, icon := range icons {
+		known[icon.Slug] = true
+		g.Nodes = append(g.Nodes, GraphNode{ID: icon.Slug, Label: icon.DisplayName, Provider: icon.Provider, FamilyID: icon.FamilyID})
+	}
+
+	linked := make(map[[2]string]bool)
+	link := func(a, b string, weight float64, kind string) {
+		if a > b {
+			a, b = b, a
+		}
+		if a == b || linked[[2]string{a, b}] {
+			return
+		}
+		linked[[2]string{a, b}] = true
+		g.Edges = append(g.Edges, GraphEdge{Source: a, Target: b, Weight: weight, Kind: kind})
+	}
+
+	sources := make([]string, 0, len(curated))
+	for slug := range curated {
+		sources = append(sources, slug)
+	}
+	sort.Strings(sources)
+	for _, slug := range sources {
+		for _, other := range curated[slug] {
+			if !known[slug] || !known[other] {
+				log.Printf("⚠️  Related icons %s -> %s: unknown slug", slug, other)
+				continue
+			}
+			link(slug, other, 1, EdgeCurated)
+		}
+	}
+
+	tags := make([]map[string]bool, len(icons))
+	for i, icon := range icons {
+		tags[i] = make(map[string]bool)
+		for _, tag := range familyTags(icon) {
+			tags[i][tag] = true
+		}
+	}
+	for i, icon := range icons {
+		type candidate struct {
+			slug  string
+			score float64
+		}
+		var candidates []candidate
+		for j, other := range icons {
+			if i == j || len(tags[i]) == 0 || len(tags[j]) == 0 {
+				continue
+			}
+			shared := 0
+			for tag := range tags[i] {
+				if tags[j][tag] {
+					shared++
+				}
+			}
+			score := float64(shared) / float64(len(tags[i])+len(tags[j])-shared)
+			if shared >= minSharedTags && score >= minTagSimilarity {
+				candidates = append(candidates, candidate{other.Slug, score})
+			}
+		}
+		sort.Slice(candidates, func(a, b int) bool {
+			if candidates[a].score != candidates[b].score {
+				return candidates[a].score > candidates[b].score
+			}
+			return candidates[a].slug < candidates[b].slug
+		})
+		if len(candidates) > maxTagEdges {
+			candidates = candidates[:maxTagEdges]
+		}
+		for _, c := range candidates {
+			link(icon.Slug, c.slug, c.score, EdgeTagOverlap)
+		}
+	}
+
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Source != g.Edges[j].Source {
+			return g.Edges[i].Source < g.Edges[j].Source
+		}
+		return g.Edges[i].Target < g.Edges[j].Target
+	})
+	return g
+}
+
+// graphExporter writes the related-icons graph as JSON and GraphML
+type graphExporter struct{}
+
+func (graphExporter) Name() string { return "graph" }
+
+func (graphExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	var curated map[string][]string
+	if ctx.Config != nil && ctx.Config.RelatedFile != "" {
+		var err error
+		if curated, err = LoadRelated(ctx.Config.RelatedFile); err != nil {
+			return err
+		}
+	}
+
+	g := buildRelatedGraph(icons, curated)
+	if err := ctx.Config.writeJSON(filepath.Join(ctx.Dir, "related.json"), g); err != nil {
+		return err
+	}
+	return writeGraphML(filepath.Join(ctx.Dir, "related.graphml"), g)
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+func writeGraphML(path string, g *RelatedGraph) error {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "provider", For: "node", Name: "provider", Type: "string"},
+			{ID: "family", For: "node", Name: "family_id", Type: "string"},
+			{ID: "weight", For: "edge", Name: "weight", Type: "double"},
+			{ID: "kind", For: "edge", Name: "kind", Type: "string"},
+		},
+	}
+	doc.Graph.EdgeDefault = "undirected"
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: n.ID, Data: []graphMLData{
+			{Key: "label", Value: n.Label},
+			{Key: "provider", Value: n.Provider},
+			{Key: "family", Value: n.FamilyID},
+		}})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: e.Source, Target: e.Target, Data: []graphMLData{
+			{Key: "weight", Value: strconv.FormatFloat(e.Weight, 'f', 3, 64)},
+			{Key: "kind", Value: e.Kind},
+		}})
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0600)
+}
@@ -0,0 +1,59 @@
+package icons
+
+import (
+	"path/filepath"
+)
+
+func init() {
+	RegisterExporter(iacExporter{})
+}
+
+// IaCIcon is the icon a resource type maps to in the IaC lookup files
+type IaCIcon struct {
+	Slug        string `json:"slug"`
+	Provider    string `json:"provider"`
+	DisplayName string `json:"display_name"`
+	IconifyID   string `json:"iconify_id,omitempty"`
+	URL         string `json:"url"`
+	ServiceCode string `json:"service_code,omitempty"`
+}
+
+// iacExporter writes standalone resource type to icon lookup tables for
+// Terraform, CloudFormation and ARM/Bicep, so IaC visualizers need not load
+// the whole corpus
+type iacExporter struct{}
+
+func (iacExporter) Name() string { return "iac" }
+
+func (iacExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	tables := map[string]func(*MachineIdentifiers) []string{
+		"terraform.json":      func(ids *MachineIdentifiers) []string { return ids.TerraformResources },
+		"cloudformation.json": func(ids *MachineIdentifiers) []string { return ids.CloudFormationTypes },
+		"arm.json":            func(ids *MachineIdentifiers) []string { return ids.ARMTypes },
+	}
+	for file, types := range tables {
+		table := make(map[string]IaCIcon)
+		for _, icon := range icons {
+			if icon.Identifiers == nil {
+				continue
+			}
+			for _, typ := range types(icon.Identifiers) {
+				if _, ok := table[typ]; ok {
+					continue
+				}
+				table[typ] = IaCIcon{
+					Slug:        icon.Slug,
+					Provider:    icon.Provider,
+					DisplayName: icon.DisplayName,
+					IconifyID:   icon.IconifyID,
+					URL:         icon.URL,
+					ServiceCode: icon.Identifiers.ServiceCode,
+				}
+			}
+		}
+		if err := ctx.Config.writeJSON(filepath.Join(ctx.Dir, file), table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package icons
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	RegisterExporter(mermaidExporter{})
+	RegisterExporter(plantUMLExporter{})
+}
+
+// iconifyIcon is a single icon in Iconify JSON format
+type iconifyIcon struct {
+	Body   string  `json:"body"`
+	Left   float64 `json:"left,omitempty"`
+	Top    float64 `json:"top,omitempty"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// iconifyPack is an icon set in Iconify JSON format, as accepted by
+// mermaid.registerIconPacks
+type iconifyPack struct {
+	Prefix string                 `json:"prefix"`
+	Icons  map[string]iconifyIcon `json:"icons"`
+}
+
+// mermaidExporter writes one Iconify JSON icon pack per provider plus a
+// registration script for Mermaid architecture diagrams
+type mermaidExporter struct{}
+
+func (mermaidExporter) Name() string { return "mermaid" }
+
+func (mermaidExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	keys, groups := groupByProvider(icons)
+	for _, key := range keys {
+		pack := iconifyPack{Prefix: key, Icons: make(map[string]iconifyIcon)}
+		for _, icon := range groups[key] {
+			doc, ok := readSVG(ctx, icon)
+			if !ok {
+				continue
+			}
+			pack.Icons[strings.TrimPrefix(icon.Slug, key+"-")] = iconifyIcon{
+				Body:   doc.Body,
+				Left:   doc.MinX,
+				Top:    doc.MinY,
+				Width:  doc.Width,
+				Height: doc.Height,
+			}
+		}
+		if err := ctx.Config.writeJSON(filepath.Join(ctx.Dir, key+".json"), pack); err != nil {
+			return err
+		}
+	}
+
+	path := filepath.Join(ctx.Dir, "register.mjs")
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "// Registers the generated icon packs, icons are used as architecture-beta")
+	fmt.Fprintln(w, "// icons, e.g. service db(aws:amazon-rds)[Database]")
+	fmt.Fprintln(w, "export function registerIcons(mermaid) {")
+	fmt.Fprintln(w, "  mermaid.registerIconPacks([")
+	for _, key := range keys {
+		fmt.Fprintf(w, "    { name: %q, loader: () => import(\"./%s.json\", { with: { type: \"json\" } }).then((m) => m.default) },\n", key, key)
+	}
+	fmt.Fprintln(w, "  ]);")
+	fmt.Fprintln(w, "}")
+	return w.Flush()
+}
+
+// plantUMLExporter writes one include file of SVG sprites per provider
+type plantUMLExporter struct{}
+
+func (plantUMLExporter) Name() string { return "plantuml" }
+
+func (plantUMLExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	keys, groups := groupByProvider(icons)
+	for _, key := range keys {
+		path := filepath.Join(ctx.Dir, key+".puml")
+		f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("error opening file %s: %w", path, err)
+		}
+
+		w := bufio.NewWriter(f)
+		fmt.Fprintf(w, "' %s sprites generated by terrastruct-icons\n", groups[key][0].Provider)
+		fmt.Fprintf(w, "' usage: !include %s.puml then <$%s_name> in labels\n", key, key)
+		for _, icon := range groups[key] {
+			doc, ok := readSVG(ctx, icon)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(w, "sprite $%s %s\n", plantUMLName(icon.Slug), doc.inline())
+		}
+		err = w.Flush()
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSVG loads and parses the downloaded asset of icon
+func readSVG(ctx *ExportContext, icon *IconPayload) (svgDoc, bool) {
+	data, err := ctx.ReadAsset(icon)
+	if err != nil {
+		log.Printf("⚠️  Skipping %s: %v", icon.Slug, err)
+		return svgDoc{}, false
+	}
+	return parseSVG(data)
+}
+
+// plantUMLName converts a slug to a PlantUML sprite name
+func plantUMLName(slug string) string {
+	return strings.ReplaceAll(slug, "-", "_")
+}
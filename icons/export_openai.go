@@ -0,0 +1,133 @@
+package icons
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+)
+
+const lookupIconTool = "lookup_icon"
+
+func init() {
+	RegisterExporter(openAIExporter{})
+}
+
+// LookupIconArgs are the arguments of the lookup_icon tool
+type LookupIconArgs struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// LookupIconResult is returned to the model by the lookup_icon tool
+type LookupIconResult struct {
+	Found       bool    `json:"found"`
+	Slug        string  `json:"slug,omitempty"`
+	IconifyID   string  `json:"iconify_id,omitempty"`
+	DisplayName string  `json:"display_name,omitempty"`
+	Provider    string  `json:"provider,omitempty"`
+	URL         string  `json:"url,omitempty"`
+	ShapeType   string  `json:"shape_type,omitempty"`
+	IsContainer bool    `json:"is_container,omitempty"`
+	Score       float64 `json:"score,omitempty"`
+	// Disambiguation tells the model which icons are easily confused with
+	// the match
+	Disambiguation []Confusable `json:"disambiguation,omitempty"`
+}
+
+// OpenAITools returns the OpenAI tools schema for lookup_icon, restricting
+// provider to the providers present in icons
+func OpenAITools(icons []*IconPayload) []map[string]any {
+	keys, _ := groupByProvider(icons)
+	return []map[string]any{{
+		"type": "function",
+		"function": map[string]any{
+			"name":        lookupIconTool,
+			"description": "Find the diagram icon best matching a cloud service, technology or concept name.",
+			"parameters": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name": map[string]any{
+						"type":        "string",
+						"description": "Service or concept to find an icon for, e.g. \"managed postgres\" or \"EC2\".",
+					},
+					"provider": map[string]any{
+						"type":        "string",
+						"description": "Optional provider key to restrict the search to.",
+						"enum":        keys,
+					},
+				},
+				"required":             []string{"name"},
+				"additionalProperties": false,
+			},
+		},
+	}}
+}
+
+// LookupIcon executes the lookup_icon tool call arguments against d
+func LookupIcon(d *Dataset, arguments string) (LookupIconResult, error) {
+	var args LookupIconArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return LookupIconResult{}, fmt.Errorf("invalid %s arguments: %w", lookupIconTool, err)
+	}
+	return newLookupIconResult(d.Lookup(args.Name, args.Provider)), nil
+}
+
+// newLookupIconResult describes match, not found when it is nil
+func newLookupIconResult(match *SearchResult) LookupIconResult {
+	if match == nil {
+		return LookupIconResult{Found: false}
+	}
+	icon := match.Icon
+	return LookupIconResult{
+		Found:          true,
+		Slug:           icon.Slug,
+		IconifyID:      icon.IconifyID,
+		DisplayName:    icon.DisplayName,
+		Provider:       icon.Provider,
+		URL:            icon.URL,
+		ShapeType:      icon.ShapeType,
+		IsContainer:    icon.IsContainer,
+		Score:          match.Score,
+		Disambiguation: icon.Disambiguation,
+	}
+}
+
+// LookupIconHandler serves lookup_icon over HTTP, accepting the tool call
+// arguments as a JSON body or name/provider query parameters. A found icon
+// is credited as a usage hit
+func LookupIconHandler(d *Dataset) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var args []byte
+		if r.Method == http.MethodPost {
+			var body LookupIconArgs
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			args, _ = json.Marshal(body)
+		} else {
+			args, _ = json.Marshal(LookupIconArgs{Name: r.URL.Query().Get("name"), Provider: r.URL.Query().Get("provider")})
+		}
+
+		result, err := LookupIcon(d, string(args))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if result.Found {
+			d.RecordHit(result.Slug)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	})
+}
+
+// openAIExporter writes the lookup_icon tools schema
+type openAIExporter struct{}
+
+func (openAIExporter) Name() string { return "openai" }
+
+func (openAIExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	return ctx.Config.writeJSON(filepath.Join(ctx.Dir, "tools.json"), OpenAITools(icons))
+}
@@ -0,0 +1,160 @@
+package icons
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	maxChunkChars = 1000
+	chunkOverlap  = 1
+)
+
+func init() {
+	RegisterExporter(langChainExporter{})
+	RegisterExporter(llamaIndexExporter{})
+}
+
+// langChainDocument is a LangChain Document in its JSON form
+type langChainDocument struct {
+	PageContent string         `json:"page_content"`
+	Metadata    map[string]any `json:"metadata"`
+	Type        string         `json:"type"`
+}
+
+// llamaIndexDocument is a LlamaIndex TextNode in its JSON form
+type llamaIndexDocument struct {
+	ID                        string         `json:"id_"`
+	Text                      string         `json:"text"`
+	Metadata                  map[string]any `json:"metadata"`
+	ExcludedEmbedMetadataKeys []string       `json:"excluded_embed_metadata_keys"`
+	ExcludedLLMMetadataKeys   []string       `json:"excluded_llm_metadata_keys"`
+}
+
+// langChainExporter writes documents.jsonl for LangChain JSONL loaders
+type langChainExporter struct{}
+
+func (langChainExporter) Name() string { return "langchain" }
+
+func (langChainExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	return writeJSONL(filepath.Join(ctx.Dir, "documents.jsonl"), icons, func(icon *IconPayload, i, n int, chunk string) any {
+		return langChainDocument{PageContent: chunk, Metadata: chunkMetadata(ctx.Namespace(), icon, i, n), Type: "Document"}
+	})
+}
+
+// llamaIndexExporter writes documents.jsonl of LlamaIndex nodes, keeping
+// URLs and ids out of the embedded text
+type llamaIndexExporter struct{}
+
+func (llamaIndexExporter) Name() string { return "llamaindex" }
+
+func (llamaIndexExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	excluded := []string{"id", "namespace", "url", "iconify_id", "chunk", "chunks", "popularity"}
+	return writeJSONL(filepath.Join(ctx.Dir, "documents.jsonl"), icons, func(icon *IconPayload, i, n int, chunk string) any {
+		return llamaIndexDocument{
+			ID:                        namespaced(ctx.Namespace(), fmt.Sprintf("%s#%d", icon.Slug, i)),
+			Text:                      chunk,
+			Metadata:                  chunkMetadata(ctx.Namespace(), icon, i, n),
+			ExcludedEmbedMetadataKeys: excluded,
+			ExcludedLLMMetadataKeys:   []string{"id", "namespace", "chunk", "chunks"},
+		}
+	})
+}
+
+// writeJSONL writes one line per chunk of every icon document
+func writeJSONL(path string, icons []*IconPayload, doc func(icon *IconPayload, i, n int, chunk string) any) error {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	e := json.NewEncoder(w)
+	e.SetEscapeHTML(false)
+	for _, icon := range icons {
+		text := icon.Document
+		if text == "" {
+			text = icon.Description
+		}
+		chunks := chunkText(text, maxChunkChars)
+		for i, chunk := range chunks {
+			if err := e.Encode(doc(icon, i, len(chunks), chunk)); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// chunkMetadata returns flat scalar metadata accepted by common vector stores,
+// tagged with the namespace so corpora sharing a collection can be filtered
+func chunkMetadata(ns string, icon *IconPayload, chunk, chunks int) map[string]any {
+	metadata := map[string]any{
+		"id":           icon.ID,
+		"slug":         icon.Slug,
+		"iconify_id":   icon.IconifyID,
+		"provider":     icon.Provider,
+		"category":     icon.Category,
+		"subcategory":  icon.Subcategory,
+		"display_name": icon.DisplayName,
+		"url":          icon.URL,
+		"shape_type":   icon.ShapeType,
+		"is_container": icon.IsContainer,
+		"popularity":   icon.Popularity,
+		"tags":         strings.Join(jsonToArray(icon.Tags), ","),
+		"chunk":        chunk,
+		"chunks":       chunks,
+	}
+	if ns != "" {
+		metadata["namespace"] = ns
+	}
+	return metadata
+}
+
+// chunkText splits text on sentence boundaries into chunks of at most max
+// characters, repeating the last chunkOverlap sentences of a chunk at the
+// start of the next one
+func chunkText(text string, max int) []string {
+	text = strings.TrimSpace(text)
+	if len(text) <= max {
+		return []string{text}
+	}
+
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if (text[i] == '.' || text[i] == '!' || text[i] == '?') && (i+1 == len(text) || text[i+1] == ' ') {
+			sentences = append(sentences, strings.TrimSpace(text[start:i+1]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+
+	var chunks []string
+	var current []string
+	size := 0
+	for _, s := range sentences {
+		if size > 0 && size+len(s)+1 > max {
+			chunks = append(chunks, strings.Join(current, " "))
+			keep := chunkOverlap
+			if keep > len(current) {
+				keep = len(current)
+			}
+			current = append([]string{}, current[len(current)-keep:]...)
+			size = len(strings.Join(current, " "))
+		}
+		current = append(current, s)
+		size += len(s) + 1
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, strings.Join(current, " "))
+	}
+	return chunks
+}
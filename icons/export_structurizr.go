@@ -0,0 +1,66 @@
+package icons
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+func init() {
+	RegisterExporter(structurizrExporter{})
+}
+
+// structurizrTheme is a Structurizr theme document
+type structurizrTheme struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Elements    []structurizrElementStyle `json:"elements"`
+}
+
+type structurizrElementStyle struct {
+	Tag    string `json:"tag"`
+	Stroke string `json:"stroke,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Icon   string `json:"icon"`
+}
+
+// structurizrExporter writes a Structurizr theme per provider and a combined
+// theme, tagging each style "<provider> - <display name>"
+type structurizrExporter struct{}
+
+func (structurizrExporter) Name() string { return "structurizr" }
+
+func (structurizrExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	all := structurizrTheme{
+		Name:        "terrastruct-icons",
+		Description: "Icons for every provider generated by terrastruct-icons",
+		Elements:    []structurizrElementStyle{},
+	}
+	keys, groups := groupByProvider(icons)
+	for _, key := range keys {
+		provider := groups[key][0].Provider
+		theme := structurizrTheme{
+			Name:        fmt.Sprintf("terrastruct-icons %s", key),
+			Description: fmt.Sprintf("%s icons generated by terrastruct-icons", provider),
+			Elements:    make([]structurizrElementStyle, 0, len(groups[key])),
+		}
+		for _, icon := range groups[key] {
+			style := structurizrElementStyle{
+				Tag:    StructurizrTag(icon),
+				Stroke: icon.ColorTheme,
+				Color:  icon.ColorTheme,
+				Icon:   icon.URL,
+			}
+			theme.Elements = append(theme.Elements, style)
+			all.Elements = append(all.Elements, style)
+		}
+		if err := ctx.Config.writeJSON(filepath.Join(ctx.Dir, key+"-theme.json"), theme); err != nil {
+			return err
+		}
+	}
+	return ctx.Config.writeJSON(filepath.Join(ctx.Dir, "theme.json"), all)
+}
+
+// StructurizrTag returns the element tag matching icon in generated themes
+func StructurizrTag(icon *IconPayload) string {
+	return fmt.Sprintf("%s - %s", icon.Provider, icon.DisplayName)
+}
@@ -0,0 +1,217 @@
+package icons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const npmPackageName = "@tf2d2/terrastruct-icons-data"
+
+func init() {
+	RegisterExporter(npmExporter{})
+}
+
+// npmExporter writes an npm package holding the JSON corpus, ESM and CommonJS
+// entry points and TypeScript definitions derived from IconPayload
+type npmExporter struct{}
+
+func (npmExporter) Name() string { return "npm" }
+
+func (npmExporter) Export(ctx *ExportContext, icons []*IconPayload) error {
+	if err := ctx.Config.writeJSON(filepath.Join(ctx.Dir, "icons.json"), icons); err != nil {
+		return err
+	}
+	keys, groups := groupByProvider(icons)
+	if err := os.MkdirAll(filepath.Join(ctx.Dir, "providers"), 0750); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := ctx.Config.writeJSON(filepath.Join(ctx.Dir, "providers", key+".json"), groups[key]); err != nil {
+			return err
+		}
+	}
+
+	files := map[string]string{
+		"index.d.ts": typeScriptDefinitions(keys),
+		"index.mjs":  esmModule(keys),
+		"index.cjs":  commonJSModule(keys),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(ctx.Dir, name), []byte(content), 0600); err != nil {
+			return err
+		}
+	}
+
+	// the version follows the content, so reruns over the same corpus produce
+	// the same package
+	hash, err := ContentHash(icons)
+	if err != nil {
+		return err
+	}
+	return ctx.Config.writeJSON(filepath.Join(ctx.Dir, "package.json"), map[string]any{
+		"name":    npmPackage(ctx.Namespace()),
+		"version": "0.0.0-sha-" + hash[:12],
+		"license": "Apache-2.0",
+		"type":    "module",
+		"main":    "./index.cjs",
+		"module":  "./index.mjs",
+		"types":   "./index.d.ts",
+		"exports": map[string]any{
+			".": map[string]string{
+				"types":   "./index.d.ts",
+				"import":  "./index.mjs",
+				"require": "./index.cjs",
+			},
+			"./icons.json":       "./icons.json",
+			"./providers/*.json": "./providers/*.json",
+		},
+		"files": []string{"index.*", "icons.json", "providers/"},
+	})
+}
+
+// npmPackage returns the package name of the corpus of namespace ns
+func npmPackage(ns string) string {
+	if ns == "" {
+		return npmPackageName
+	}
+	return npmPackageName + "-" + ns
+}
+
+// typeScriptDefinitions renders interfaces for IconPayload and its nested
+// structs from their JSON tags
+func typeScriptDefinitions(providers []string) string {
+	var b strings.Builder
+	b.WriteString("// Generated by terrastruct-icons, do not edit.\n\n")
+
+	seen := map[reflect.Type]bool{}
+	queue := []reflect.Type{reflect.TypeOf(IconPayload{})}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		fmt.Fprintf(&b, "export interface %s {\n", t.Name())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, optional, ok := jsonField(f)
+			if !ok {
+				continue
+			}
+			tsType, nested := typeScriptType(f.Type)
+			queue = append(queue, nested...)
+			if optional {
+				name += "?"
+			}
+			fmt.Fprintf(&b, "  %s: %s;\n", name, tsType)
+		}
+		b.WriteString("}\n\n")
+	}
+
+	quoted := make([]string, len(providers))
+	for i, p := range providers {
+		quoted[i] = fmt.Sprintf("%q", p)
+	}
+	union := strings.Join(quoted, " | ")
+	if union == "" {
+		union = "never"
+	}
+	fmt.Fprintf(&b, "export type ProviderKey = %s;\n\n", union)
+	b.WriteString("export declare const icons: IconPayload[];\n")
+	b.WriteString("export declare const providers: Record<ProviderKey, IconPayload[]>;\n")
+	b.WriteString("export declare function bySlug(slug: string): IconPayload | undefined;\n")
+	b.WriteString("export default icons;\n")
+	return b.String()
+}
+
+// jsonField returns the JSON name of f and whether it may be omitted
+func jsonField(f reflect.StructField) (string, bool, bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	optional := false
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			optional = true
+		}
+	}
+	return name, optional, true
+}
+
+// typeScriptType maps a Go type to TypeScript, returning struct types that
+// need their own interface
+func typeScriptType(t reflect.Type) (string, []reflect.Type) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeScriptType(t.Elem())
+	case reflect.String:
+		return "string", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number", nil
+	case reflect.Slice, reflect.Array:
+		elem, nested := typeScriptType(t.Elem())
+		return elem + "[]", nested
+	case reflect.Map:
+		elem, nested := typeScriptType(t.Elem())
+		return "Record<string, " + elem + ">", nested
+	case reflect.Struct:
+		return t.Name(), []reflect.Type{t}
+	default:
+		return "unknown", nil
+	}
+}
+
+func esmModule(providers []string) string {
+	var b strings.Builder
+	b.WriteString("// Generated by terrastruct-icons, do not edit.\n")
+	b.WriteString("import { createRequire } from \"node:module\";\n\n")
+	b.WriteString("const require = createRequire(import.meta.url);\n\n")
+	b.WriteString("export const icons = require(\"./icons.json\");\n")
+	writeProviders(&b, "export ", providers)
+	b.WriteString("const index = new Map(icons.map((icon) => [icon.slug, icon]));\n\n")
+	b.WriteString("export function bySlug(slug) {\n  return index.get(slug);\n}\n\n")
+	b.WriteString("export default icons;\n")
+	return b.String()
+}
+
+func commonJSModule(providers []string) string {
+	var b strings.Builder
+	b.WriteString("// Generated by terrastruct-icons, do not edit.\n")
+	b.WriteString("\"use strict\";\n\n")
+	b.WriteString("const icons = require(\"./icons.json\");\n")
+	writeProviders(&b, "", providers)
+	b.WriteString("const index = new Map(icons.map((icon) => [icon.slug, icon]));\n\n")
+	b.WriteString("function bySlug(slug) {\n  return index.get(slug);\n}\n\n")
+	b.WriteString("module.exports = icons;\n")
+	b.WriteString("module.exports.default = icons;\n")
+	b.WriteString("module.exports.icons = icons;\n")
+	b.WriteString("module.exports.providers = providers;\n")
+	b.WriteString("module.exports.bySlug = bySlug;\n")
+	return b.String()
+}
+
+func writeProviders(b *strings.Builder, prefix string, providers []string) {
+	b.WriteString(prefix + "const providers = {\n")
+	for _, p := range providers {
+		fmt.Fprintf(b, "  %q: require(\"./providers/%s.json\"),\n", p, p)
+	}
+	b.WriteString("};\n\n")
+}
@@ -0,0 +1,163 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// extensionPrefix namespaces extension fields in the JSON output
+const extensionPrefix = "x_"
+
+// Extender attaches custom metadata to icons, e.g. internal cost-center tags,
+// without changing IconPayload
+type Extender interface {
+	// Name identifies the extender in logs and provenance
+	Name() string
+	// Extend sets extensions of icon with SetExtension
+	Extend(icon *IconPayload) error
+}
+
+// ContextExtender is an Extender whose calls are bound by the context of
+// the run, runExtenders prefers ExtendContext when an extender has it
+type ContextExtender interface {
+	Extender
+	ExtendContext(ctx context.Context, icon *IconPayload) error
+}
+
+var extenders []Extender
+
+// RegisterExtender adds e to the extenders run, in order, on every icon
+// after enrichment
+func RegisterExtender(e Extender) {
+	extenders = append(extenders, e)
+}
+
+// SetExtension stores value under key, serialized as x_<key>
+func (p *IconPayload) SetExtension(key string, value any) {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	p.Extensions[strings.TrimPrefix(key, extensionPrefix)] = value
+}
+
+// Extension returns the value stored under key
+func (p *IconPayload) Extension(key string) (any, bool) {
+	v, ok := p.Extensions[strings.TrimPrefix(key, extensionPrefix)]
+	return v, ok
+}
+
+// iconPayloadJSON has the fields of IconPayload without its JSON methods
+type iconPayloadJSON IconPayload
+
+// MarshalJSON appends the extensions as x_ prefixed fields, sorted by key
+func (p *IconPayload) MarshalJSON() ([]byte, error) {
+	data, err := marshalRaw((*iconPayloadJSON)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(p.Extensions))
+	for key := range p.Extensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf := bytes.NewBuffer(bytes.TrimSuffix(data, []byte("}")))
+	for _, key := range keys {
+		raw, err := marshalRaw(p.Extensions[key])
+		if err != nil {
+			return nil, fmt.Errorf("error encoding extension %s of %s: %w", key, p.Slug, err)
+		}
+		name, _ := marshalRaw(extensionPrefix + key)
+		buf.WriteByte(',')
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(raw)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalRaw encodes v like json.Marshal without escaping HTML characters,
+// matching writeJSON
+func marshalRaw(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// UnmarshalJSON collects x_ prefixed fields into the extensions
+func (p *IconPayload) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*iconPayloadJSON)(p)); err != nil {
+		return err
+	}
+	if p.LastSeen == "" && bytes.Contains(data, []byte(`"last_scraped"`)) {
+		// schema 1 output only had last_scraped
+		var legacy struct {
+			LastScraped string `json:"last_scraped"`
+		}
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+		p.FirstSeen, p.LastSeen, p.LastModified = legacy.LastScraped, legacy.LastScraped, legacy.LastScraped
+	}
+	if !strings.Contains(string(data), `"`+extensionPrefix) {
+		return nil
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for key, raw := range fields {
+		if !strings.HasPrefix(key, extensionPrefix) {
+			continue
+		}
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		p.SetExtension(key, value)
+	}
+	return nil
+}
+
+// runExtenders applies the registered extenders, a failing extender is
+// logged and skipped for that icon
+func runExtenders(ctx context.Context, icons []*IconPayload, timestamp string) int {
+	if len(extenders) == 0 {
+		return 0
+	}
+	extended := 0
+	for _, icon := range icons {
+		before := len(icon.Extensions)
+		for _, e := range extenders {
+			if err := extend(ctx, e, icon); err != nil {
+				log.Printf("⚠️  Extender %s on %s: %v", e.Name(), icon.Slug, err)
+				continue
+			}
+			icon.setProvenance(e.Name(), timestamp, "extensions")
+		}
+		if len(icon.Extensions) > before {
+			extended++
+		}
+	}
+	return extended
+}
+
+// extend runs e on icon, with ctx when e takes one
+func extend(ctx context.Context, e Extender, icon *IconPayload) error {
+	if ce, ok := e.(ContextExtender); ok {
+		return ce.ExtendContext(ctx, icon)
+	}
+	return e.Extend(icon)
+}
@@ -0,0 +1,124 @@
+package icons
+
+import (
+	"log"
+	"sort"
+	"strings"
+)
+
+const (
+	familiesFile = "families.json"
+	// minFamilySize drops groups too small to offer similar icons
+	minFamilySize = 2
+)
+
+// Family basis values, how a family was formed
+const (
+	FamilyByCategory = "category"
+	FamilyByTag      = "tag"
+)
+
+// Family is a group of related icons of one provider
+type Family struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Provider string   `json:"provider"`
+	Basis    string   `json:"basis"`
+	Members  []string `json:"members"`
+}
+
+// clusterFamilies groups icons into families and records family_id and
+// family_name on every member. Icons are grouped by their catalog category
+// first; the rest join the most shared tag of their provider
+func clusterFamilies(icons []*IconPayload, timestamp string) []Family {
+	groups := make(map[string]*Family)
+	assign := func(icon *IconPayload, basis, label string) {
+		id := providerDir(icon.Provider) + "-" + slugify(label)
+		f, ok := groups[id]
+		if !ok {
+			f = &Family{ID: id, Name: icon.Provider + " " + label, Provider: icon.Provider, Basis: basis}
+			groups[id] = f
+		}
+		f.Members = append(f.Members, icon.Slug)
+	}
+
+	byCategory := make(map[string][]*IconPayload)
+	for _, icon := range icons {
+		if icon.Category != "" {
+			key := icon.Provider + "/" + icon.Category
+			byCategory[key] = append(byCategory[key], icon)
+		}
+	}
+
+	var rest []*IconPayload
+	for _, icon := range icons {
+		if icon.Category != "" && len(byCategory[icon.Provider+"/"+icon.Category]) >= minFamilySize {
+			assign(icon, FamilyByCategory, icon.Category)
+			continue
+		}
+		rest = append(rest, icon)
+	}
+
+	tagCount := make(map[string]int)
+	for _, icon := range rest {
+		for _, tag := range familyTags(icon) {
+			tagCount[icon.Provider+"/"+tag]++
+		}
+	}
+	for _, icon := range rest {
+		best, bestCount := "", 0
+		for _, tag := range familyTags(icon) {
+			n := tagCount[icon.Provider+"/"+tag]
+			if n > bestCount || (n == bestCount && tag < best) {
+				best, bestCount = tag, n
+			}
+		}
+		if bestCount >= minFamilySize {
+			assign(icon, FamilyByTag, strings.ToUpper(best[:1])+best[1:])
+		}
+	}
+
+	families := make([]Family, 0, len(groups))
+	members := make(map[string]*Family)
+	for _, f := range groups {
+		if len(f.Members) < minFamilySize {
+			continue
+		}
+		sort.Strings(f.Members)
+		families = append(families, *f)
+		for _, slug := range f.Members {
+			members[slug] = f
+		}
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].ID < families[j].ID })
+
+	for _, icon := range icons {
+		f, ok := members[icon.Slug]
+		if !ok {
+			continue
+		}
+		icon.FamilyID = f.ID
+		icon.FamilyName = f.Name
+		icon.setProvenance(SourceRules, timestamp, "family_id", "family_name")
+	}
+
+	log.Printf("👪 Clustered %d icons into %d families", len(members), len(families))
+	return families
+}
+
+// familyTags returns the distinct tags of icon that can name a family,
+// skipping tags that merely repeat the provider
+func familyTags(icon *IconPayload) []string {
+	provider := providerDir(icon.Provider)
+	seen := make(map[string]bool)
+	var tags []string
+	for _, tag := range jsonToArray(icon.Tags) {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == provider || seen[tag] || strings.EqualFold(tag, icon.Provider) {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -0,0 +1,143 @@
+package icons
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const filterFile = "blocklist.yaml"
+
+// FilterRules matches icons by slug, title regex or category
+type FilterRules struct {
+	Slugs      []string `yaml:"slugs,omitempty"`
+	Titles     []string `yaml:"titles,omitempty"`
+	Categories []string `yaml:"categories,omitempty"`
+
+	titleRgx []*regexp.Regexp
+}
+
+// IconFilter excludes blocked icons and, when an allowlist is given, keeps
+// only allowed ones
+type IconFilter struct {
+	Block FilterRules `yaml:"block"`
+	Allow FilterRules `yaml:"allow"`
+}
+
+// LoadIconFilter reads a blocklist/allowlist file, a missing file yields nil
+func LoadIconFilter(path string) (*IconFilter, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading filter %s: %w", path, err)
+	}
+
+	var f IconFilter
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("error parsing filter %s: %w", path, err)
+	}
+	if err := f.Block.compile(); err != nil {
+		return nil, err
+	}
+	if err := f.Allow.compile(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *FilterRules) compile() error {
+	r.titleRgx = r.titleRgx[:0]
+	for _, pattern := range r.Titles {
+		rgx, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid title pattern %q: %w", pattern, err)
+		}
+		r.titleRgx = append(r.titleRgx, rgx)
+	}
+	return nil
+}
+
+func (r *FilterRules) empty() bool {
+	return len(r.Slugs) == 0 && len(r.Titles) == 0 && len(r.Categories) == 0
+}
+
+func (r *FilterRules) match(slug, title, category string) bool {
+	for _, s := range r.Slugs {
+		if s == slug {
+			return true
+		}
+	}
+	for _, c := range r.Categories {
+		if strings.EqualFold(c, category) {
+			return true
+		}
+	}
+	for _, rgx := range r.titleRgx {
+		if rgx.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// Keep reports whether an icon passes the filter
+func (f *IconFilter) Keep(slug, title, category string) bool {
+	if f == nil {
+		return true
+	}
+	if f.Block.match(slug, title, category) {
+		return false
+	}
+	return f.Allow.empty() || f.Allow.match(slug, title, category)
+}
+
+// filterPending drops pending icons rejected by f before enrichment. Slug
+// rules match the slugs icons are published with, collision suffixes
+// included, and block rules also match the slug before the suffix, so a
+// survivor of a collision is never published under a blocked slug. Dropping
+// icons changes how the rest collide, so filtering repeats until the
+// published slugs settle
+func filterPending(pending []PendingIcon, f *IconFilter, slugs SlugPolicy) ([]PendingIcon, int) {
+	if f == nil {
+		return pending, 0
+	}
+	total := len(pending)
+	for {
+		final := publishedSlugs(pending, slugs)
+		kept := make([]PendingIcon, 0, len(pending))
+		for i, p := range pending {
+			base := slugs.Slug(p.Category, p.Title)
+			if !f.Keep(final[i], p.Title, p.Category) || f.Block.match(base, p.Title, p.Category) {
+				continue
+			}
+			kept = append(kept, p)
+		}
+		if len(kept) == len(pending) {
+			return kept, total - len(kept)
+		}
+		pending = kept
+	}
+}
+
+// publishedSlugs returns the slugs of pending once resolveSlugCollisions
+// suffixed the colliding ones
+func publishedSlugs(pending []PendingIcon, slugs SlugPolicy) []string {
+	icons := make([]*IconPayload, len(pending))
+	for i, p := range pending {
+		icons[i] = &IconPayload{Slug: slugs.Slug(p.Category, p.Title), URL: pendingURL(p)}
+	}
+	resolveSlugCollisions(icons, "")
+	final := make([]string, len(icons))
+	for i, icon := range icons {
+		final[i] = icon.Slug
+	}
+	return final
+}
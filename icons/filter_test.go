@@ -0,0 +1,53 @@
+package icons
+
+import "testing"
+
+func TestFilterPendingBlockedCollision(t *testing.T) {
+	compute := PendingIcon{Category: "aws", Title: "Gateway", URL: "https://example.com/aws/compute/gateway.svg"}
+	network := PendingIcon{Category: "aws", Title: "Gateway", URL: "https://example.com/aws/network/gateway.svg"}
+	base := SafeSlugs.Slug("aws", "Gateway")
+
+	tests := []struct {
+		name    string
+		blocked string
+		want    []string
+	}{
+		{name: "first of the pair", blocked: base, want: nil},
+		{name: "suffixed", blocked: base + "-network", want: []string{base}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &IconFilter{Block: FilterRules{Slugs: []string{tt.blocked}}}
+			kept, excluded := filterPending([]PendingIcon{compute, network}, f, SafeSlugs)
+			got := publishedSlugs(kept, SafeSlugs)
+			if len(got) != len(tt.want) {
+				t.Fatalf("published %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("published %v, want %v", got, tt.want)
+				}
+			}
+			if excluded != 2-len(tt.want) {
+				t.Errorf("excluded %d, want %d", excluded, 2-len(tt.want))
+			}
+		})
+	}
+}
+
+func TestFilterPendingSettles(t *testing.T) {
+	// the third icon takes the suffix of the blocked second once it is gone
+	pending := []PendingIcon{
+		{Category: "aws", Title: "Gateway", URL: "https://example.com/aws/a/gateway.svg"},
+		{Category: "aws", Title: "Gateway", URL: "https://example.com/aws/compute/gateway.svg"},
+		{Category: "aws", Title: "Gateway", URL: "https://example.com/aws/x/compute/gateway.svg"},
+	}
+	blocked := SafeSlugs.Slug("aws", "Gateway") + "-compute"
+	f := &IconFilter{Block: FilterRules{Slugs: []string{blocked}}}
+	kept, _ := filterPending(pending, f, SafeSlugs)
+	for _, slug := range publishedSlugs(kept, SafeSlugs) {
+		if slug == blocked {
+			t.Fatalf("published blocked slug %s", slug)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package icons
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+)
+
+// FixtureMode selects whether outbound HTTP is recorded or replayed
+type FixtureMode string
+
+const (
+	FixtureOff    FixtureMode = ""
+	FixtureRecord FixtureMode = "record"
+	FixtureReplay FixtureMode = "replay"
+)
+
+// fixtureRequest describes a recorded request for humans reading fixtures
+type fixtureRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// fixtureTransport records every response into dir or replays them from it
+type fixtureTransport struct {
+	dir  string
+	mode FixtureMode
+	next http.RoundTripper
+}
+
+func newFixtureTransport(dir string, mode FixtureMode, next http.RoundTripper) *fixtureTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &fixtureTransport{dir: dir, mode: mode, next: next}
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	key := fixtureKey(req.Method, req.URL.String(), body)
+	path := filepath.Join(t.dir, req.URL.Hostname(), key+".http")
+
+	if t.mode == FixtureReplay {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("replay: no fixture for %s %s", req.Method, req.URL)
+		}
+		return http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), req)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || t.mode != FixtureRecord {
+		return resp, err
+	}
+
+	raw, err := httputil.DumpResponse(resp, true)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, raw, 0600); err != nil {
+		return nil, err
+	}
+	meta := fixtureRequest{Method: req.Method, URL: req.URL.String(), Body: string(body)}
+	if err := writeJSON(filepath.Join(t.dir, req.URL.Hostname(), key+".json"), meta); err != nil {
+		return nil, err
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), req)
+}
+
+// fixtureKey identifies a request by method, URL and body
+func fixtureKey(method, url string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, url)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,262 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultFlatSetPattern takes the file name without extension as the
+	// icon name
+	defaultFlatSetPattern = `^(?:.*/)?(?P<name>[^/]+)\.svg$`
+	// defaultFlatSetCategory is used when no category rule matches
+	defaultFlatSetCategory = "General"
+)
+
+// FlatSetMapping describes a simple icon collection, a flat list of SVG
+// files in a directory or under a base URL, so it can be ingested without
+// writing a source
+type FlatSetMapping struct {
+	// Provider is the provider key, registered with DisplayName and
+	// Homepage unless already known
+	Provider    string `yaml:"provider"`
+	DisplayName string `yaml:"display_name,omitempty"`
+	Homepage    string `yaml:"homepage,omitempty"`
+
+	// Dir is a local directory walked for SVG files
+	Dir string `yaml:"dir,omitempty"`
+	// BaseURL is the URL the files are served from, listed by Index, a JSON
+	// listing relative to BaseURL defaulting to icons.json
+	BaseURL string `yaml:"base_url,omitempty"`
+	Index   string `yaml:"index,omitempty"`
+
+	// Pattern matches the slash separated file paths, its name group is the
+	// icon name and its optional category group the category. Files not
+	// matching are skipped
+	Pattern string `yaml:"pattern,omitempty"`
+	// Categories map icon names to categories, the first match wins
+	Categories      []FlatSetCategory `yaml:"categories,omitempty"`
+	DefaultCategory string            `yaml:"default_category,omitempty"`
+
+	// License and LicenseURL are stored as the x_license and x_license_url
+	// extensions of every icon
+	License    string `yaml:"license,omitempty"`
+	LicenseURL string `yaml:"license_url,omitempty"`
+
+	pattern *regexp.Regexp
+	rules   []*regexp.Regexp
+}
+
+// FlatSetCategory assigns Category to the icons whose name matches Match
+type FlatSetCategory struct {
+	Match    string `yaml:"match"`
+	Category string `yaml:"category"`
+}
+
+// LoadFlatSetMapping reads and checks a flat icon set mapping file
+func LoadFlatSetMapping(path string) (*FlatSetMapping, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading mapping %s: %w", path, err)
+	}
+
+	var m FlatSetMapping
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error parsing mapping %s: %w", path, err)
+	}
+	if m.Dir != "" && !filepath.IsAbs(m.Dir) {
+		m.Dir = filepath.Join(filepath.Dir(path), m.Dir)
+	}
+	if err := m.compile(); err != nil {
+		return nil, fmt.Errorf("invalid mapping %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+func (m *FlatSetMapping) compile() error {
+	if m.Provider == "" {
+		return fmt.Errorf("no provider")
+	}
+	if (m.Dir == "") == (m.BaseURL == "") {
+		return fmt.Errorf("exactly one of dir and base_url is required")
+	}
+
+	pattern := firstNonEmpty(m.Pattern, defaultFlatSetPattern)
+	rgx, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	if rgx.SubexpIndex("name") < 0 {
+		return fmt.Errorf("pattern %q has no name group", pattern)
+	}
+	m.pattern = rgx
+
+	m.rules = m.rules[:0]
+	for _, c := range m.Categories {
+		rgx, err := regexp.Compile(c.Match)
+		if err != nil {
+			return fmt.Errorf("invalid category match %q: %w", c.Match, err)
+		}
+		m.rules = append(m.rules, rgx)
+	}
+	return nil
+}
+
+// match returns the icon name and category of a file path, ok is false for
+// files outside the set
+func (m *FlatSetMapping) match(file string) (name, category string, ok bool) {
+	groups := m.pattern.FindStringSubmatch(file)
+	if groups == nil {
+		return "", "", false
+	}
+	name = groups[m.pattern.SubexpIndex("name")]
+	if name == "" {
+		return "", "", false
+	}
+	if i := m.pattern.SubexpIndex("category"); i >= 0 && groups[i] != "" {
+		return name, groups[i], true
+	}
+	for i, rgx := range m.rules {
+		if rgx.MatchString(name) {
+			return name, m.Categories[i].Category, true
+		}
+	}
+	return name, firstNonEmpty(m.DefaultCategory, defaultFlatSetCategory), true
+}
+
+// extensions returns the license metadata stored on every icon of the set
+func (m *FlatSetMapping) extensions() map[string]any {
+	ext := make(map[string]any)
+	if m.License != "" {
+		ext["license"] = m.License
+	}
+	if m.LicenseURL != "" {
+		ext["license_url"] = m.LicenseURL
+	}
+	return ext
+}
+
+// flatSetSource ingests the icon collection described by a FlatSetMapping
+type flatSetSource struct {
+	cfg     SourceConfig
+	mapping *FlatSetMapping
+}
+
+func (s *flatSetSource) Name() string { return sourceName(s.cfg) }
+
+func (s *flatSetSource) Fetch(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	m := s.mapping
+	if _, ok := Providers.Lookup(m.Provider); !ok {
+		Providers.Register(Provider{
+			Key:         m.Provider,
+			DisplayName: firstNonEmpty(m.DisplayName, cleanDisplayName(m.Provider)),
+			Homepage:    m.Homepage,
+		})
+	}
+
+	var (
+		files map[string]string
+		err   error
+	)
+	if m.Dir != "" {
+		files, err = s.walk(ctx)
+	} else {
+		files, err = s.list(ctx, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]PendingIcon, 0, len(files))
+	seen := make(map[string]bool)
+	for _, file := range sortedKeys(files) {
+		name, category, ok := m.match(file)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		pending = append(pending, PendingIcon{
+			Category:    strings.ToUpper(m.Provider),
+			Title:       name,
+			Link:        strings.Join([]string{url.PathEscape(strings.ToLower(m.Provider)), url.PathEscape(category), url.PathEscape(name) + ".svg"}, "%2F"),
+			URL:         files[file],
+			DisplayName: cleanDisplayName(name),
+			Extensions:  m.extensions(),
+		})
+	}
+	return pending, nil
+}
+
+// walk maps the slash separated paths of the SVG files under the mapping
+// directory to their file URLs
+func (s *flatSetSource) walk(ctx context.Context) (map[string]string, error) {
+	root, err := filepath.Abs(s.mapping.Dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".svg") {
+			return ctx.Err()
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// list maps the paths of the mapping index to their URLs under the base URL
+func (s *flatSetSource) list(ctx context.Context, cfg *Config) (map[string]string, error) {
+	m := s.mapping
+	base, err := url.Parse(strings.TrimSuffix(m.BaseURL, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid base_url %q: %w", m.BaseURL, err)
+	}
+	index, err := base.Parse(firstNonEmpty(m.Index, listingPath))
+	if err != nil {
+		return nil, fmt.Errorf("invalid index %q: %w", m.Index, err)
+	}
+	body, err := getCatalog(ctx, cfg.assetClient(), index.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []listingEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing index %s: %w", index, err)
+	}
+	files := make(map[string]string, len(entries))
+	for _, e := range entries {
+		raw := firstNonEmpty(e.Path, e.URL, e.Link)
+		u, err := base.Parse(raw)
+		if err != nil || raw == "" {
+			continue
+		}
+		file := strings.TrimPrefix(u.Path, base.Path)
+		if u.Host != base.Host || !strings.HasPrefix(u.Path, base.Path) {
+			file = path.Base(u.Path)
+		}
+		files[file] = u.String()
+	}
+	return files, nil
+}
@@ -0,0 +1,137 @@
+package icons
+
+import (
+	"compress/gzip"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// corsMaxAge is how long browsers may cache a preflight response, in seconds
+const corsMaxAge = "600"
+
+// HTTPOptions sets the CORS, caching and compression headers of the HTTP
+// handlers, for browser clients such as an icon picker calling them directly
+type HTTPOptions struct {
+	// CORSOrigins lists the origins browsers may call the handlers from, "*"
+	// allows any origin
+	CORSOrigins []string
+	// CacheControl is the Cache-Control of successful responses that do not
+	// set their own, e.g. "private, max-age=300"
+	CacheControl string
+	// Compress gzips responses for clients accepting it. Brotli is not
+	// supported
+	Compress bool
+}
+
+// Handler wraps next with the headers of o. It answers CORS preflight
+// requests itself, so it has to wrap the API key checks rather than be
+// wrapped by them
+func (o HTTPOptions) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && len(o.CORSOrigins) > 0 {
+			w.Header().Add("Vary", "Origin")
+			if o.allowOrigin(origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified, Retry-After")
+				if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+					w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT")
+					w.Header().Set("Access-Control-Allow-Headers", "Authorization, X-API-Key, Content-Type, If-None-Match, If-Modified-Since")
+					w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+		}
+
+		hw := &headerWriter{ResponseWriter: w, cacheControl: o.CacheControl}
+		if o.Compress {
+			w.Header().Add("Vary", "Accept-Encoding")
+			hw.gzip = r.Method != http.MethodHead && acceptsGzip(r)
+		}
+		defer hw.close()
+		next.ServeHTTP(hw, r)
+	})
+}
+
+// allowOrigin reports whether browsers may call from origin
+func (o HTTPOptions) allowOrigin(origin string) bool {
+	return slices.Contains(o.CORSOrigins, "*") || slices.Contains(o.CORSOrigins, origin)
+}
+
+// acceptsGzip reports whether the client of r accepts gzip responses
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// headerWriter sets the cache and compression headers once the handler
+// chose its status
+type headerWriter struct {
+	http.ResponseWriter
+	cacheControl string
+	// gzip compresses a successful response
+	gzip  bool
+	gz    *gzip.Writer
+	wrote bool
+}
+
+func (w *headerWriter) WriteHeader(status int) {
+	if w.wrote {
+		return
+	}
+	w.wrote = true
+	h := w.Header()
+	if status == http.StatusOK {
+		if w.cacheControl != "" && h.Get("Cache-Control") == "" {
+			h.Set("Cache-Control", w.cacheControl)
+		}
+		if w.gzip && h.Get("Content-Encoding") == "" && h.Get("Content-Type") != "" {
+			h.Del("Content-Length")
+			h.Del("Accept-Ranges")
+			h.Set("Content-Encoding", "gzip")
+			// the compressed body is another representation of the same
+			// content, weak ETags still match If-None-Match
+			if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+				h.Set("ETag", "W/"+etag)
+			}
+			w.gz = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends what was written so far, for streaming handlers
+func (w *headerWriter) Flush() {
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// close flushes the compressed body
+func (w *headerWriter) close() {
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
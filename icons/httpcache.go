@@ -0,0 +1,141 @@
+package icons
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+)
+
+// cacheEntry is the metadata stored next to a cached response
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// cacheTransport caches GET responses on disk keyed by URL and revalidates
+// them with ETag / Last-Modified conditional requests
+type cacheTransport struct {
+	dir     string
+	offline bool
+	next    http.RoundTripper
+}
+
+func newCacheTransport(dir string, offline bool, next http.RoundTripper) *cacheTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cacheTransport{dir: dir, offline: offline, next: next}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	key := cacheKey(req.URL.String())
+	entry, cached := t.load(key)
+
+	if t.offline {
+		if cached == nil {
+			return nil, fmt.Errorf("offline: %s is not cached", req.URL)
+		}
+		return t.response(key, req)
+	}
+
+	if entry != nil {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		if cached != nil {
+			log.Printf("⚠️  %s unreachable, serving cached copy: %v", req.URL, err)
+			return t.response(key, req)
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return t.response(key, req)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	raw, err := httputil.DumpResponse(resp, true)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.store(key, req.URL.String(), resp.Header, raw); err != nil {
+		log.Printf("⚠️  Failed to cache %s: %v", req.URL, err)
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), req)
+}
+
+func (t *cacheTransport) load(key string) (*cacheEntry, []byte) {
+	meta, err := os.ReadFile(filepath.Join(t.dir, key+".json"))
+	if err != nil {
+		return nil, nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(filepath.Join(t.dir, key+".http"))
+	if err != nil {
+		return nil, nil
+	}
+	return &entry, raw
+}
+
+func (t *cacheTransport) response(key string, req *http.Request) (*http.Response, error) {
+	f, err := os.Open(filepath.Join(t.dir, key+".http"))
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(raw)), req)
+}
+
+func (t *cacheTransport) store(key, url string, header http.Header, raw []byte) error {
+	if err := os.MkdirAll(t.dir, 0750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(t.dir, key+".http"), raw, 0600); err != nil {
+		return err
+	}
+	return writeJSON(filepath.Join(t.dir, key+".json"), cacheEntry{
+		URL:          url,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	})
+}
+
+// cacheKey returns the file name used for a URL
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
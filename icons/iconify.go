@@ -0,0 +1,234 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultIconifyWorkers = 4
+	defaultIconifyRate    = 10
+	// iconifyField is the provenance field of Iconify IDs
+	iconifyField = "iconify_id"
+)
+
+var iconifyNameRgx = regexp.MustCompile(`[^a-z0-9-]`)
+
+// iconifyMatch is the Iconify ID resolved for an icon
+type iconifyMatch struct {
+	id       string
+	verified bool
+}
+
+// iconifyVerifier resolves Iconify IDs on its own workers, sharing a cache of
+// search results and a request rate limit between them
+type iconifyVerifier struct {
+	client    *http.Client
+	workers   int
+	tick      *time.Ticker
+	fallbacks bool
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+func newIconifyVerifier(cfg *Config) *iconifyVerifier {
+	v := &iconifyVerifier{client: cfg.client(), workers: cfg.IconifyWorkers, fallbacks: cfg.IconifyFallbacks, cache: make(map[string]string)}
+	if v.workers < 1 {
+		v.workers = 1
+	}
+	if cfg.IconifyRate > 0 {
+		v.tick = time.NewTicker(time.Second / time.Duration(cfg.IconifyRate))
+	}
+	return v
+}
+
+// close stops the rate limiter
+func (v *iconifyVerifier) close() {
+	if v.tick != nil {
+		v.tick.Stop()
+	}
+}
+
+// start verifies the Iconify IDs of pending in the background, the returned
+// function waits for them and returns the matches in the order of pending
+func (v *iconifyVerifier) start(ctx context.Context, pending []PendingIcon, slugs SlugPolicy) func() []iconifyMatch {
+	matches := make([]iconifyMatch, len(pending))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < v.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				p := pending[i]
+				start := time.Now()
+				matches[i].id, matches[i].verified = v.verify(ctx, p.Category, p.Title, slugs.Slug(p.Category, p.Title))
+				stageClock.add(stageIconify, 1, time.Since(start))
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range pending {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() []iconifyMatch {
+		wg.Wait()
+		return matches
+	}
+}
+
+// verify resolves an Iconify ID, reporting whether the API matched it or it
+// was derived from the provider and title. The bare title and slug are only
+// searched with fallbacks enabled
+func (v *iconifyVerifier) verify(ctx context.Context, provider, title, slug string) (string, bool) {
+	queries := []string{fmt.Sprintf("%s %s", provider, title)}
+	if v.fallbacks {
+		queries = append(queries, title, slug)
+	}
+	for _, query := range queries {
+		if id := v.search(ctx, query); id != "" {
+			return id, true
+		}
+	}
+	return deriveIconifyID(provider, title), false
+}
+
+// search returns the first Iconify icon matching query, empty when none does
+// or the API could not be reached; only answered queries are cached
+func (v *iconifyVerifier) search(ctx context.Context, query string) string {
+	v.mu.Lock()
+	id, ok := v.cache[query]
+	v.mu.Unlock()
+	if ok {
+		return id
+	}
+
+	if v.tick != nil {
+		select {
+		case <-v.tick.C:
+		case <-ctx.Done():
+			return ""
+		}
+	}
+
+	u := fmt.Sprintf("%s/search?query=%s&limit=3", iconifyAPIURL, url.QueryEscape(query))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return ""
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	var result IconifySearchResult
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&result) != nil {
+		return ""
+	}
+	if result.Total > 0 && len(result.Icons) > 0 {
+		id = result.Icons[0]
+	}
+	v.mu.Lock()
+	v.cache[query] = id
+	v.mu.Unlock()
+	return id
+}
+
+// deriveIconifyID guesses the Iconify ID of an icon the API did not match
+func deriveIconifyID(provider, title string) string {
+	name := iconifyNameRgx.ReplaceAllString(strings.ToLower(strings.ReplaceAll(title, " ", "-")), "")
+	return fmt.Sprintf("logos:%s-%s", strings.ToLower(provider), name)
+}
+
+// applyIconify sets the verified Iconify IDs of icons, matched by position
+func applyIconify(icons []*IconPayload, matches []iconifyMatch, timestamp string) int {
+	verified := 0
+	for i, icon := range icons {
+		if i >= len(matches) || !matches[i].verified {
+			continue
+		}
+		icon.IconifyID = matches[i].id
+		icon.setProvenance(SourceIconify, timestamp, iconifyField)
+		verified++
+	}
+	return verified
+}
+
+// IconifyReport summarizes the Iconify IDs checked by Reverify
+type IconifyReport struct {
+	Checked int `json:"checked"`
+	// Resolved lists the icons the API did not match before and does now
+	Resolved []string `json:"resolved"`
+	// Changed lists the icons the API matched before with another ID
+	Changed []string `json:"changed"`
+	// Unresolved counts the icons the API still does not match
+	Unresolved int `json:"unresolved"`
+}
+
+// Reverify resolves the Iconify IDs of the existing output of cfg again
+// without scraping, as Iconify adds collections frequently, and publishes
+// the result like any other run. IDs set by overrides are left alone, and
+// verified IDs are kept when the API does not answer
+func Reverify(ctx context.Context, cfg *Config) (*RunReport, error) {
+	return updateOutput(ctx, cfg, func(ctx context.Context, report *RunReport, icons []*IconPayload, timestamp string) error {
+		var checked []*IconPayload
+		for _, icon := range icons {
+			if icon.Provenance[iconifyField].Source != SourceOverride {
+				checked = append(checked, icon)
+			}
+		}
+		pending := make([]PendingIcon, len(checked))
+		previous := make([]FieldProvenance, len(checked))
+		ids := make([]string, len(checked))
+		for i, icon := range checked {
+			pending[i] = pendingFromIcon(icon)
+			previous[i], ids[i] = icon.Provenance[iconifyField], icon.IconifyID
+		}
+
+		mark := stageClock.start()
+		iconify := newIconifyVerifier(cfg)
+		defer iconify.close()
+		matches := iconify.start(ctx, pending, cfg.SlugPolicy)()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		applyIconify(checked, matches, timestamp)
+		stageClock.done(stageIconify, mark, len(checked))
+
+		result := &IconifyReport{Checked: len(checked), Resolved: []string{}, Changed: []string{}}
+		for i, icon := range checked {
+			wasVerified := previous[i].Source == SourceIconify
+			switch {
+			case !matches[i].verified:
+				if !wasVerified {
+					result.Unresolved++
+				}
+			case !wasVerified:
+				result.Resolved = append(result.Resolved, icon.Slug)
+			case ids[i] != icon.IconifyID:
+				result.Changed = append(result.Changed, icon.Slug)
+			}
+		}
+		report.Iconify = result
+		log.Printf("🔎 Iconify: %d newly resolved, %d changed, %d still unresolved of %d",
+			len(result.Resolved), len(result.Changed), result.Unresolved, result.Checked)
+		return nil
+	})
+}
@@ -1,4 +1,3 @@
-
 package icons
 
 import (
@@ -6,48 +5,91 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/gocolly/colly"
 	"github.com/google/uuid"
 )
 
-// PendingIcon holds icon data before enrichment
+// PendingIcon holds icon data before enrichment, its JSON form is returned
+// by source plugins
 type PendingIcon struct {
-	Category    string
-	Title       string
-	Link        string
-	DisplayName string
+	Category string `json:"category"`
+	Title    string `json:"title"`
+	// RawTitle is the scraped title before stop tokens were stripped
+	RawTitle string `json:"raw_title,omitempty"`
+	Link     string `json:"link"`
+	// URL locates the artwork, defaulting to Link under sourceURL
+	URL         string `json:"url,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	// Variants are alternative renderings grouped under this icon
+	Variants []IconVariant `json:"variants,omitempty"`
+	// Extensions are source metadata stored on the icon, see SetExtension
+	Extensions map[string]any `json:"extensions,omitempty"`
 }
 
 // IconPayload represents the enhanced structure for RAG + D2 diagram generation
 type IconPayload struct {
-	ID              string  `json:"id"`
-	Slug            string  `json:"slug"`
-	IconifyID       string  `json:"iconify_id"`
-	Provider        string  `json:"provider"`
-	URL             string  `json:"url"`
-	SemanticProfile string  `json:"semantic_profile"`
-	DisplayName     string  `json:"display_name"`
-	Aliases         string  `json:"aliases"`
-	Description     string  `json:"description"`
-	TechnicalIntent string  `json:"technical_intent"`
-	ShapeType       string  `json:"shape_type"`
-	DefaultWidth    int     `json:"default_width"`
-	IsContainer     bool    `json:"is_container"`
-	IconPosition    string  `json:"icon_position"`
-	ColorTheme      string  `json:"color_theme"`
-	Popularity      float32 `json:"popularity"`
-	Tags            string  `json:"tags"`
-	LastScraped     string  `json:"last_scraped"`
+	ID               string   `json:"id"`
+	Slug             string   `json:"slug"`
+	IconifyID        string   `json:"iconify_id"`
+	Provider         string   `json:"provider"`
+	Category         string   `json:"category,omitempty"`
+	Subcategory      string   `json:"subcategory,omitempty"`
+	CategoryID       string   `json:"category_id,omitempty"`
+	URL              string   `json:"url"`
+	SemanticProfile  string   `json:"semantic_profile"`
+	DisplayName      string   `json:"display_name"`
+	RawTitle         string   `json:"raw_title,omitempty"`
+	Aliases          string   `json:"aliases"`
+	Description      string   `json:"description"`
+	TechnicalIntent  string   `json:"technical_intent"`
+	ShapeType        string   `json:"shape_type"`
+	DefaultWidth     int      `json:"default_width"`
+	IsContainer      bool     `json:"is_container"`
+	IconPosition     string   `json:"icon_position"`
+	ColorTheme       string   `json:"color_theme"`
+	Popularity       float32  `json:"popularity"`
+	Tags             string   `json:"tags"`
+	SearchText       string   `json:"search_text,omitempty"`
+	Keywords         []string `json:"keywords,omitempty"`
+	EnrichmentStatus string   `json:"enrichment_status,omitempty"`
+	ApprovalStatus   string   `json:"approval_status,omitempty"`
+	FamilyID         string   `json:"family_id,omitempty"`
+	FamilyName       string   `json:"family_name,omitempty"`
+	Document         string   `json:"document,omitempty"`
+	QualityScore     float32  `json:"quality_score"`
+	QualityIssues    []string `json:"quality_issues,omitempty"`
+	LocalPath        string   `json:"local_path,omitempty"`
+	ContentSHA256    string   `json:"content_sha256,omitempty"`
+	DarkModeLegible  *bool    `json:"dark_mode_legible,omitempty"`
+	DarkLocalPath    string   `json:"dark_local_path,omitempty"`
+	DarkURL          string   `json:"dark_url,omitempty"`
+	MonoLocalPath    string   `json:"mono_local_path,omitempty"`
+	MonoURL          string   `json:"mono_url,omitempty"`
+
+	Identifiers *MachineIdentifiers `json:"identifiers,omitempty"`
+	Variants    []IconVariant       `json:"variants,omitempty"`
+	// Disambiguation lists icons easily confused with this one, see
+	// disambiguate
+	Disambiguation []Confusable `json:"disambiguation,omitempty"`
+	// FirstSeen and LastSeen are the first and latest runs that found the
+	// icon, LastModified the latest run that changed it, see stampLifecycle
+	FirstSeen    string `json:"first_seen"`
+	LastSeen     string `json:"last_seen"`
+	LastModified string `json:"last_modified"`
+
+	Provenance map[string]FieldProvenance `json:"provenance,omitempty"`
+
+	// Extensions hold custom metadata, serialized as x_<key> fields
+	Extensions map[string]any `json:"-"`
 }
 
 // LLMEnrichmentResponse from HTTP LLM service
@@ -60,6 +102,8 @@ type LLMEnrichmentResponse struct {
 	ShapeType       string   `json:"shape_type"`
 	IsContainer     bool     `json:"is_container"`
 	BrandColor      string   `json:"brand_color"`
+	// Confidence is the confidence of the enricher per field, 0 to 1
+	Confidence map[string]float32 `json:"confidence,omitempty"`
 }
 
 // BatchClassifyRequest for parallel LLM processing
@@ -86,25 +130,29 @@ type IconifySearchResult struct {
 }
 
 const (
-	sourceURL = "https://icons.terrastruct.com"
-	outputDir = "output"
-	jsonFile  = "icons_rag.json"
+	sourceURL     = "https://icons.terrastruct.com"
+	iconifyAPIURL = "https://api.iconify.design"
+	outputDir     = "output"
+	jsonFile      = "icons_rag.json"
 
 	useLLMEnrichment   = false
 	llmServiceURL      = "http://localhost:5000/classify"
 	llmBatchURL        = "http://localhost:5000/batch"
 	llmHealthURL       = "http://localhost:5000/health"
+	llmQueriesURL      = "http://localhost:5000/queries"
 	useBatchProcessing = true
 	batchSize          = 5
 
 	testingMode = false
 	testLimit   = 10
+
+	// minExpectedIcons guards against silently producing an empty corpus
+	minExpectedIcons = 500
 )
 
 var (
-	categories          = make(map[string]bool)
 	escapeRgx           = regexp.MustCompile(`\\u([0-9a-fA-F]{4})`)
-	httpClient          = &http.Client{Timeout: 30000000 * time.Second}
+	defaultHTTPClient   = &http.Client{Timeout: 30000000 * time.Second}
 	containerPatterns   = regexp.MustCompile(`(?i)(vpc|vnet|subnet|network|cluster|namespace|resource.?group)`)
 	llmServiceAvailable = false
 
@@ -114,92 +162,82 @@ var (
 	}
 )
 
-func Generate() error {
-	log.Println("🚀 Enhanced Icon Generator - JSON Output Only")
-	if testingMode {
-		log.Printf("🧪 TESTING MODE: %d icons per category", testLimit)
-	}
+// Generate scrapes, enriches and writes the icon corpus
+func Generate(opts ...Option) error {
+	return Run(NewConfig(opts...))
+}
 
-	if useLLMEnrichment {
-		if checkLLMService() {
-			log.Println("✅ LLM service connected")
-			llmServiceAvailable = true
-		} else {
-			log.Println("⚠️  LLM service unavailable - using fallback")
-			llmServiceAvailable = false
-		}
+// Run executes a generation run with cfg, flushing the icons processed so
+// far as partial output on SIGINT or SIGTERM
+func Run(cfg *Config) error {
+	ctx, stop := withShutdown(context.Background())
+	defer stop()
+	_, err := GenerateAll(ctx, cfg)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("interrupted, partial output not published: %w", err)
 	}
+	return err
+}
 
-	if err := os.MkdirAll(outputDir, 0750); err != nil {
-		log.Fatalf("Failed to create output directory: %v", err)
+// process enriches pendingIcons and runs the post-processing and quality
+// gates, returning the icons ready to be written. When ctx is canceled it
+// returns the icons enriched so far along with the error
+func process(ctx context.Context, cfg *Config, pendingIcons []PendingIcon, timestamp string) ([]*IconPayload, error) {
+	allIcons, err := enrichPending(ctx, cfg, pendingIcons, timestamp)
+	if err != nil {
+		return allIcons, err
 	}
-
-	pendingIcons := make([]PendingIcon, 0)
-	categoryCount := make(map[string]int)
-
-	c := colly.NewCollector()
-	c.OnError(func(r *colly.Response, err error) {
-		log.Fatalf("Scraping error: %v", err)
-	})
-
-	c.OnHTML("div", func(e *colly.HTMLElement) {
-		if e.Attr("class") == "icon" {
-			unescaped := getUnescaped(e.Attr("onclick"))
-			link := strings.TrimSuffix(strings.TrimPrefix(unescaped, "clickIcon(\""), "\")")
-
-			if link != "" && strings.Contains(link, "%") {
-				parts := strings.Split(link, "%")
-				if len(parts) > 0 {
-					category := strings.ToUpper(parts[0])
-
-					if testingMode && categoryCount[category] >= testLimit {
-						return
-					}
-
-					categories[category] = true
-					categoryCount[category]++
-					title := e.Attr("data-search")
-
-					pendingIcons = append(pendingIcons, PendingIcon{
-						Category:    category,
-						Title:       title,
-						Link:        link,
-						DisplayName: cleanDisplayName(title),
-					})
-				}
-			}
+	if err := postProcess(ctx, cfg, allIcons, allIcons, timestamp); err != nil {
+		if ctx.Err() != nil {
+			return allIcons, err
 		}
-	})
+		return nil, err
+	}
+	return allIcons, nil
+}
 
-	_ = c.Visit(sourceURL)
+// enrichPending turns pendingIcons into icons, enriched and with verified
+// Iconify IDs. When ctx is canceled it returns the icons enriched so far
+// along with the error
+func enrichPending(ctx context.Context, cfg *Config, pendingIcons []PendingIcon, timestamp string) ([]*IconPayload, error) {
+	allIcons := make([]*IconPayload, 0)
+	var retries []retryItem
 
-	log.Printf("✅ Collected %d icons from %d categories", len(pendingIcons), len(categories))
+	// Iconify verification runs on its own workers while icons are enriched
+	iconify := newIconifyVerifier(cfg)
+	defer iconify.close()
+	iconifyMatches := iconify.start(ctx, pendingIcons, cfg.SlugPolicy)
 
-	allIcons := make([]*IconPayload, 0)
-	providerIcons := make(map[string][]*IconPayload)
-	timestamp := time.Now().UTC().Format(time.RFC3339)
+	mark := stageClock.start()
 
 	if useLLMEnrichment && llmServiceAvailable && useBatchProcessing {
 		log.Printf("⚡ Batch processing %d icons...", len(pendingIcons))
 
-		for i := 0; i < len(pendingIcons); i += batchSize {
+		for i := 0; i < len(pendingIcons) && ctx.Err() == nil; i += batchSize {
 			end := i + batchSize
 			if end > len(pendingIcons) {
 				end = len(pendingIcons)
 			}
 
 			batch := pendingIcons[i:end]
-			enrichments := batchEnrichIcons(batch)
+			enrichments, err := batchEnrichIcons(cfg.client(), batch)
+			if err != nil {
+				log.Printf("⚠️  Batch %d-%d failed, queued for retry: %v", i+1, end, err)
+			}
 
 			for j, pending := range batch {
 				var enrichment LLMEnrichmentResponse
-				if j < len(enrichments) {
+				ok := err == nil && j < len(enrichments)
+				if ok {
 					enrichment = enrichments[j]
 				}
 
-				icon := createIconPayload(pending.Category, pending.Title, pending.Link, pending.DisplayName, enrichment, timestamp)
+				icon := createIconPayload(pending, enrichment, cfg.SlugPolicy, cfg.Seed, timestamp)
+				icon.EnrichmentStatus = EnrichmentLLM
+				if !ok {
+					retries = append(retries, retryItem{Pending: pending, Icon: icon})
+				}
 				allIcons = append(allIcons, icon)
-				providerIcons[icon.Provider] = append(providerIcons[icon.Provider], icon)
 			}
 
 			log.Printf("   Processed batch %d-%d of %d", i+1, end, len(pendingIcons))
@@ -207,44 +245,280 @@ func Generate() error {
 	} else {
 		log.Printf("🔄 Processing %d icons individually...", len(pendingIcons))
 		for _, pending := range pendingIcons {
+			if ctx.Err() != nil {
+				break
+			}
 			var enrichment LLMEnrichmentResponse
+			status := EnrichmentDisabled
+			var err error
 			if useLLMEnrichment && llmServiceAvailable {
-				enrichment = getLLMEnrichment(pending.Category, pending.Title, pending.DisplayName)
+				status = EnrichmentLLM
+				enrichment, err = getLLMEnrichment(cfg.client(), pending.Category, pending.Title, pending.DisplayName)
 			}
 
-			icon := createIconPayload(pending.Category, pending.Title, pending.Link, pending.DisplayName, enrichment, timestamp)
+			icon := createIconPayload(pending, enrichment, cfg.SlugPolicy, cfg.Seed, timestamp)
+			icon.EnrichmentStatus = status
+			if err != nil {
+				retries = append(retries, retryItem{Pending: pending, Icon: icon})
+			}
 			allIcons = append(allIcons, icon)
-			providerIcons[icon.Provider] = append(providerIcons[icon.Provider], icon)
 		}
 	}
 
+	if len(retries) > 0 {
+		if ctx.Err() != nil {
+			for _, item := range retries {
+				fallbackEnrichment(item, timestamp)
+			}
+		} else {
+			retryEnrichment(cfg.client(), retries, timestamp)
+		}
+	}
+
+	stageClock.done(stageEnrich, mark, len(allIcons))
 	log.Printf("✅ Enrichment complete: %d icons processed", len(allIcons))
 
-	for category := range categories {
-		path := filepath.Join(outputDir, strings.ToLower(category))
-		os.MkdirAll(path, 0750)
+	n := applyIconify(allIcons, iconifyMatches(), timestamp)
+	log.Printf("🔎 Verified %d of %d Iconify IDs", n, len(allIcons))
+	return allIcons, ctx.Err()
+}
+
+// postProcess runs the corpus stages and quality gates over allIcons,
+// downloading the assets of fresh only, the icons enriched by this run
+func postProcess(ctx context.Context, cfg *Config, allIcons, fresh []*IconPayload, timestamp string) error {
+	// stages where the first icon wins then pick the same one every run
+	sortIcons(allIcons)
+	mark := stageClock.start()
+
+	rules, err := compileWasmRules(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	annotateIcons(ctx, allIcons, rules, timestamp, log.Printf)
+	rules.close(ctx)
+	stageClock.done(stageAnnotate, mark, len(allIcons))
+
+	mark = stageClock.start()
+	serviceCategories, err := classifyServices(allIcons, timestamp)
+	if err != nil {
+		return err
+	}
+	if err := cfg.writeJSON(filepath.Join(cfg.OutputDir, ontologyFile), serviceCategories); err != nil {
+		return err
 	}
 
-	for provider, icons := range providerIcons {
-		providerKey := getProviderKey(provider)
-		path := filepath.Join(outputDir, providerKey, fmt.Sprintf("%s.json", providerKey))
-		if err := writeJSON(path, icons); err != nil {
-			log.Fatalf("Failed to write %s: %v", path, err)
+	collisions := resolveSlugCollisions(allIcons, timestamp)
+	if len(collisions) > 0 {
+		path := filepath.Join(cfg.OutputDir, collisionsFile)
+		if err := cfg.writeJSON(path, collisions); err != nil {
+			return err
 		}
-		log.Printf("📝 %s: %d icons", provider, len(icons))
+		log.Printf("⚠️  Resolved %d slug collisions, see %s", len(collisions), path)
 	}
+	stageClock.done(stageClassify, mark, len(allIcons))
 
-	ragPath := filepath.Join(outputDir, jsonFile)
-	if err := writeJSON(ragPath, allIcons); err != nil {
-		log.Fatalf("Failed to write RAG JSON: %v", err)
+	if cfg.DownloadAssets {
+		mark = stageClock.start()
+		failed, err := downloadAssets(ctx, cfg, fresh, timestamp)
+		if err != nil {
+			return err
+		}
+		log.Printf("🖼️  Downloaded %d assets (%d failed)", len(fresh)-failed, failed)
+
+		if err := recolorIcons(cfg, allIcons, timestamp, log.Printf); err != nil {
+			return err
+		}
+		stageClock.done(stageAssets, mark, len(allIcons))
 	}
-	log.Printf("🎯 RAG-optimized JSON: %s (%d icons)", ragPath, len(allIcons))
 
-	log.Println("✅ Generation complete!")
+	mark = stageClock.start()
+	if n := inferShapes(cfg.OutputDir, allIcons, timestamp); n > 0 {
+		log.Printf("🔷 Inferred shape type of %d icons", n)
+	}
+	stageClock.done(stageShapes, mark, len(allIcons))
+
+	mark = stageClock.start()
+	var usage *UsageStats
+	if cfg.UsageFile != "" {
+		if usage, err = LoadUsage(cfg.UsageFile); err != nil {
+			return err
+		}
+	}
+	var overrides map[string]Override
+	if cfg.OverridesFile != "" {
+		if overrides, err = LoadOverrides(cfg.OverridesFile); err != nil {
+			return err
+		}
+	}
+	reviewIcons(cfg, allIcons, usage, overrides, timestamp, log.Printf)
+	warnUnmatchedOverrides(allIcons, overrides)
+	stageClock.done(stageOverrides, mark, len(allIcons))
+
+	mark = stageClock.start()
+	families := clusterFamilies(allIcons, timestamp)
+	if err := cfg.writeJSON(filepath.Join(cfg.OutputDir, familiesFile), families); err != nil {
+		return err
+	}
+	stageClock.done(stageFamilies, mark, len(allIcons))
+
+	mark = stageClock.start()
+	var curated []ConfusableGroup
+	if cfg.DisambiguationFile != "" {
+		if curated, err = LoadConfusables(cfg.DisambiguationFile); err != nil {
+			return err
+		}
+	}
+	if n := disambiguate(allIcons, curated, timestamp); n > 0 {
+		log.Printf("🔀 Recorded confusable icons for %d icons", n)
+	}
+	stageClock.done(stageDisambiguate, mark, len(allIcons))
+
+	mark = stageClock.start()
+	docTmpl, err := newDocumentTemplate(cfg)
+	if err != nil {
+		return err
+	}
+	if err := renderDocuments(docTmpl, allIcons, timestamp); err != nil {
+		return err
+	}
+	stageClock.done(stageDocuments, mark, len(allIcons))
+
+	mark = stageClock.start()
+	quality := scoreQuality(allIcons, collidedSlugs(collisions))
+	if err := cfg.writeJSON(filepath.Join(cfg.OutputDir, qualityFile), quality); err != nil {
+		return err
+	}
+	log.Printf("📊 Quality: average %.2f, min %.2f", quality.Average, quality.Min)
+	if quality.Average < cfg.MinQuality {
+		return fmt.Errorf("corpus quality %.2f below threshold %.2f, see %s", quality.Average, cfg.MinQuality, qualityFile)
+	}
+
+	if len(cfg.Assertions) > 0 {
+		results, err := evaluateAssertions(cfg.Assertions, allIcons)
+		if results != nil {
+			if werr := cfg.writeJSON(filepath.Join(cfg.OutputDir, assertionsFile), results); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	stageClock.done(stageQuality, mark, len(allIcons))
+	return nil
+}
+
+// annotateIcons runs the stages adding keywords, identifiers, curated packs,
+// extensions and WASM rules to icons, shared by batch and streamed runs
+func annotateIcons(ctx context.Context, icons []*IconPayload, rules wasmRuleSet, timestamp string, logf func(string, ...any)) {
+	if n := mergeKeywords(icons, timestamp); n > 0 {
+		logf("🏷️  Merged data-search keywords into %d icons", n)
+	}
+	if n := attachIdentifiers(icons, timestamp); n > 0 {
+		logf("🔑 Attached machine identifiers to %d icons", n)
+	}
+	if n := applyPacks(icons, timestamp); n > 0 {
+		logf("📚 Applied curated packs to %d icons", n)
+	}
+	if n := runExtenders(ctx, icons, timestamp); n > 0 {
+		logf("🧩 Extended %d icons", n)
+	}
+	if n := rules.run(ctx, icons, timestamp); n > 0 {
+		logf("🧪 Applied WASM rules to %d icons", n)
+	}
+}
+
+// recolorIcons writes the dark mode and monochrome variants of the downloaded
+// icons
+func recolorIcons(cfg *Config, icons []*IconPayload, timestamp string, logf func(string, ...any)) error {
+	if n := generateDarkVariants(cfg, icons, timestamp); n > 0 {
+		logf("🌙 Generated %d dark mode variants", n)
+	}
+	if cfg.MonochromeColor == "" {
+		return nil
+	}
+	n, err := generateMonochromeVariants(cfg, icons, timestamp)
+	if err != nil {
+		return err
+	}
+	logf("🖨️  Generated %d monochrome variants", n)
 	return nil
 }
 
-func checkLLMService() bool {
+// reviewIcons applies learned popularity and overrides to icons, then indexes
+// their search text and assigns their approval status
+func reviewIcons(cfg *Config, icons []*IconPayload, usage *UsageStats, overrides map[string]Override, timestamp string, logf func(string, ...any)) {
+	if usage != nil {
+		if n := applyUsage(icons, usage, timestamp); n > 0 {
+			logf("📈 Learned popularity of %d icons from %s", n, cfg.UsageFile)
+		}
+	}
+	if n := applyOverrides(icons, overrides, timestamp); n > 0 {
+		logf("✏️  Applied %d overrides from %s", n, cfg.OverridesFile)
+	}
+	indexSearchText(icons, timestamp)
+	assignApprovals(icons, overrides, cfg.ReviewThreshold, timestamp)
+}
+
+// writeOutputs writes the corpus, the per-provider files, the diff report
+// against the corpus at previousPath and the selected exports
+func writeOutputs(ctx context.Context, cfg *Config, allIcons []*IconPayload, previousPath string) error {
+	sortIcons(allIcons)
+	ragPath := filepath.Join(cfg.OutputDir, jsonFile)
+	previous, err := loadIcons(previousPath)
+	if err != nil {
+		log.Printf("⚠️  Failed to read previous output %s: %v", previousPath, err)
+	}
+	if previous != nil {
+		n := stampLifecycle(previous, allIcons)
+		log.Printf("🕰️  %d of %d icons new or modified since the previous run", n, len(allIcons))
+		report := diffIcons(previous, allIcons)
+		if err := cfg.writeJSON(filepath.Join(cfg.OutputDir, diffFile), report); err != nil {
+			return err
+		}
+		log.Printf("🔍 Diff: %d added, %d removed, %d changed, %d artwork changed",
+			len(report.Added), len(report.Removed), len(report.Changed), len(report.ArtworkChanged))
+	}
+
+	providerKeys, providerIcons := groupByProvider(allIcons)
+	for _, providerKey := range providerKeys {
+		icons := providerIcons[providerKey]
+		if err := os.MkdirAll(filepath.Join(cfg.OutputDir, providerKey), 0750); err != nil {
+			return err
+		}
+		path := filepath.Join(cfg.OutputDir, providerKey, fmt.Sprintf("%s.json", providerKey))
+		if err := cfg.writeJSON(path, icons); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		log.Printf("📝 %s: %d icons", icons[0].Provider, len(icons))
+
+		if cfg.CategoryFiles {
+			index, err := writeCategoryFiles(cfg, filepath.Join(cfg.OutputDir, providerKey), providerKey, icons)
+			if err != nil {
+				return err
+			}
+			log.Printf("📂 %s: %d category files", icons[0].Provider, len(index))
+		}
+	}
+
+	if err := cfg.writeJSON(ragPath, allIcons); err != nil {
+		return fmt.Errorf("failed to write RAG JSON: %w", err)
+	}
+	log.Printf("🎯 RAG-optimized JSON: %s (%d icons)", ragPath, len(allIcons))
+
+	if err := writeProfiles(cfg, allIcons); err != nil {
+		return err
+	}
+	if err := writeQueries(cfg, allIcons); err != nil {
+		return err
+	}
+	if err := writeReviewQueue(cfg, allIcons); err != nil {
+		return err
+	}
+	return runExports(ctx, cfg, allIcons)
+}
+
+func checkLLMService(client *http.Client) bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
@@ -253,7 +527,7 @@ func checkLLMService() bool {
 		return false
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return false
 	}
@@ -262,7 +536,7 @@ func checkLLMService() bool {
 	return resp.StatusCode == http.StatusOK
 }
 
-func batchEnrichIcons(pending []PendingIcon) []LLMEnrichmentResponse {
+func batchEnrichIcons(client *http.Client, pending []PendingIcon) ([]LLMEnrichmentResponse, error) {
 	batchInput := BatchClassifyRequest{
 		Icons: make([]BatchIconInput, len(pending)),
 	}
@@ -277,7 +551,7 @@ func batchEnrichIcons(pending []PendingIcon) []LLMEnrichmentResponse {
 
 	jsonData, err := json.Marshal(batchInput)
 	if err != nil {
-		return make([]LLMEnrichmentResponse, len(pending))
+		return nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 36000000*time.Second)
@@ -285,61 +559,124 @@ func batchEnrichIcons(pending []PendingIcon) []LLMEnrichmentResponse {
 
 	req, err := http.NewRequestWithContext(ctx, "POST", llmBatchURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return make([]LLMEnrichmentResponse, len(pending))
+		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return make([]LLMEnrichmentResponse, len(pending))
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return make([]LLMEnrichmentResponse, len(pending))
+		return nil, fmt.Errorf("LLM batch service returned %s", resp.Status)
 	}
 
 	var batchResp BatchClassifyResponse
 	if err := json.NewDecoder(resp.Body).Decode(&batchResp); err != nil {
-		return make([]LLMEnrichmentResponse, len(pending))
+		return nil, err
 	}
 
-	return batchResp.Results
+	return batchResp.Results, nil
 }
 
-func createIconPayload(provider, title, link, displayName string, enrichment LLMEnrichmentResponse, timestamp string) *IconPayload {
-	slug := generateSlug(provider, title)
-	iconifyID := verifyIconifyID(provider, title, slug)
+func createIconPayload(pending PendingIcon, enrichment LLMEnrichmentResponse, slugs SlugPolicy, seed int64, timestamp string) *IconPayload {
+	provider, title := pending.Category, pending.Title
+	slug := slugs.Slug(provider, title)
+
+	url := pendingURL(pending)
+
+	var category, subcategory string
+	hierarchy := linkHierarchy(pending.Link)
+	if len(hierarchy) > 0 {
+		category = hierarchy[0]
+	}
+	if len(hierarchy) > 1 {
+		subcategory = strings.Join(hierarchy[1:], "/")
+	}
+
+	icon := &IconPayload{
+		ID:           iconID(seed, url),
+		Slug:         slug,
+		IconifyID:    deriveIconifyID(provider, title),
+		Provider:     Providers.Resolve(provider).DisplayName,
+		Category:     category,
+		Subcategory:  subcategory,
+		URL:          url,
+		DisplayName:  pending.DisplayName,
+		RawTitle:     pending.RawTitle,
+		Keywords:     parseKeywords(title),
+		Variants:     pending.Variants,
+		Popularity:   calculatePopularity(pending.DisplayName),
+		FirstSeen:    timestamp,
+		LastSeen:     timestamp,
+		LastModified: timestamp,
+	}
+
+	icon.setProvenance(SourceScraper, timestamp, "id", "slug", "provider", "category", "subcategory", "url", "display_name", "raw_title", "keywords", "first_seen", "last_seen", "last_modified")
+	icon.setProvenance(SourceRules, timestamp, "popularity", "iconify_id")
+
+	for key, value := range pending.Extensions {
+		icon.SetExtension(key, value)
+	}
+	if len(pending.Extensions) > 0 {
+		icon.setProvenance(SourceScraper, timestamp, "extensions")
+	}
 
-	description := fmt.Sprintf("%s from %s. %s", displayName, provider, enrichment.TechnicalIntent)
-	iconPosition := "center"
+	applyEnrichment(icon, provider, enrichment, SourceLLM, timestamp)
+	return icon
+}
+
+// applyEnrichment sets the enrichment fields of icon, recording source for
+// every populated field
+func applyEnrichment(icon *IconPayload, provider string, enrichment LLMEnrichmentResponse, source, timestamp string) {
+	icon.Description = fmt.Sprintf("%s from %s. %s", icon.DisplayName, provider, enrichment.TechnicalIntent)
+	icon.IconPosition = "center"
 	if enrichment.IsContainer {
-		iconPosition = "top-left"
-	}
-
-	return &IconPayload{
-		ID:              uuid.New().String(),
-		Slug:            slug,
-		IconifyID:       iconifyID,
-		Provider:        getFullProviderName(provider),
-		URL:             fmt.Sprintf("%s/%s", sourceURL, link),
-		SemanticProfile: enrichment.SemanticProfile,
-		DisplayName:     displayName,
-		Aliases:         arrayToJSON(enrichment.Aliases),
-		Description:     description,
-		TechnicalIntent: enrichment.TechnicalIntent,
-		ShapeType:       enrichment.ShapeType,
-		DefaultWidth:    determineDefaultWidth(enrichment.Category),
-		IsContainer:     enrichment.IsContainer,
-		IconPosition:    iconPosition,
-		ColorTheme:      enrichment.BrandColor,
-		Popularity:      calculatePopularity(title),
-		Tags:            arrayToJSON(enrichment.Tags),
-		LastScraped:     timestamp,
-	}
-}
-
-func getLLMEnrichment(provider, title, displayName string) LLMEnrichmentResponse {
+		icon.IconPosition = "top-left"
+	}
+	icon.SemanticProfile = enrichment.SemanticProfile
+	icon.Aliases = arrayToJSON(enrichment.Aliases)
+	icon.TechnicalIntent = enrichment.TechnicalIntent
+	icon.ShapeType = enrichment.ShapeType
+	icon.DefaultWidth = determineDefaultWidth(enrichment.Category)
+	icon.IsContainer = enrichment.IsContainer
+	icon.ColorTheme = enrichment.BrandColor
+	icon.Tags = arrayToJSON(enrichment.Tags)
+
+	from := func(populated bool) string {
+		if populated {
+			return source
+		}
+		return SourceRules
+	}
+	enriched := enrichment.SemanticProfile != "" || enrichment.TechnicalIntent != "" || len(enrichment.Tags) > 0
+	icon.setProvenance(SourceRules, timestamp, "description", "default_width", "icon_position")
+	icon.setProvenance(from(enrichment.SemanticProfile != ""), timestamp, "semantic_profile")
+	icon.setProvenance(from(len(enrichment.Aliases) > 0), timestamp, "aliases")
+	icon.setProvenance(from(enrichment.TechnicalIntent != ""), timestamp, "technical_intent")
+	icon.setProvenance(from(enrichment.ShapeType != ""), timestamp, "shape_type")
+	icon.setProvenance(from(enriched || enrichment.IsContainer), timestamp, "is_container")
+	icon.setProvenance(from(enrichment.BrandColor != ""), timestamp, "color_theme")
+	icon.setProvenance(from(len(enrichment.Tags) > 0), timestamp, "tags")
+	for field, confidence := range enrichment.Confidence {
+		if field == "brand_color" {
+			field = "color_theme"
+		}
+		if enrichmentFields[field] {
+			icon.setConfidence(field, confidence)
+		}
+	}
+}
+
+// enrichmentFields are the fields enrichers report a confidence for
+var enrichmentFields = map[string]bool{
+	"semantic_profile": true, "aliases": true, "technical_intent": true, "shape_type": true,
+	"is_container": true, "color_theme": true, "tags": true,
+}
+
+func getLLMEnrichment(client *http.Client, provider, title, displayName string) (LLMEnrichmentResponse, error) {
 	payload := map[string]string{
 		"provider":     provider,
 		"title":        title,
@@ -350,78 +687,69 @@ func getLLMEnrichment(provider, title, displayName string) LLMEnrichmentResponse
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	req, _ := http.NewRequestWithContext(ctx, "POST", llmServiceURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", llmServiceURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return LLMEnrichmentResponse{}, err
+	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return LLMEnrichmentResponse{}
+		return LLMEnrichmentResponse{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return LLMEnrichmentResponse{}
+		return LLMEnrichmentResponse{}, fmt.Errorf("LLM service returned %s", resp.Status)
 	}
 
 	var enrichment LLMEnrichmentResponse
 	if err := json.NewDecoder(resp.Body).Decode(&enrichment); err != nil {
-		return LLMEnrichmentResponse{}
+		return LLMEnrichmentResponse{}, err
 	}
 
-	return enrichment
+	return enrichment, nil
 }
 
-func verifyIconifyID(provider, title, slug string) string {
-	queries := []string{
-		fmt.Sprintf("%s %s", provider, title),
-		title,
-		slug,
-	}
-
-	for _, query := range queries {
-		url := fmt.Sprintf("https://api.iconify.design/search?query=%s&limit=3", query)
-		resp, err := httpClient.Get(url)
-		if err != nil {
-			continue
-		}
+func generateSlug(provider, title string) string {
+	clean := slugCleanRgx.ReplaceAllString(
+		strings.ToLower(strings.ReplaceAll(title, " ", "-")), "")
+	return fmt.Sprintf("%s-%s", strings.ToLower(provider), clean)
+}
 
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
+// iconID derives the ID of the icon at url from the seed of the run, so runs
+// with the same seed give an icon the same ID whatever the scrape order
+func iconID(seed int64, url string) string {
+	space := uuid.NewSHA1(uuid.NameSpaceOID, []byte(strconv.FormatInt(seed, 10)))
+	return uuid.NewSHA1(space, []byte(url)).String()
+}
 
-		var result IconifySearchResult
-		if err := json.Unmarshal(body, &result); err != nil {
-			continue
+// sortIcons orders icons by provider then slug, so outputs only change when
+// the icons do
+func sortIcons(icons []*IconPayload) {
+	sort.SliceStable(icons, func(i, j int) bool {
+		if icons[i].Provider != icons[j].Provider {
+			return icons[i].Provider < icons[j].Provider
 		}
-
-		if result.Total > 0 && len(result.Icons) > 0 {
-			return result.Icons[0]
+		if icons[i].Slug != icons[j].Slug {
+			return icons[i].Slug < icons[j].Slug
 		}
-	}
-
-	providerLower := strings.ToLower(provider)
-	titleClean := regexp.MustCompile(`[^a-z0-9-]`).ReplaceAllString(
-		strings.ToLower(strings.ReplaceAll(title, " ", "-")), "")
-	return fmt.Sprintf("logos:%s-%s", providerLower, titleClean)
-}
-
-func generateSlug(provider, title string) string {
-	clean := regexp.MustCompile(`[^a-z0-9-]`).ReplaceAllString(
-		strings.ToLower(strings.ReplaceAll(title, " ", "-")), "")
-	return fmt.Sprintf("%s-%s", strings.ToLower(provider), clean)
+		return icons[i].URL < icons[j].URL
+	})
 }
 
+// cleanDisplayName title cases the words of title, respecting brand casing
 func cleanDisplayName(title string) string {
 	name := strings.TrimSpace(title)
 	name = strings.ReplaceAll(strings.ReplaceAll(name, "_", " "), "-", " ")
 	words := strings.Fields(name)
 	for i, word := range words {
-		if len(word) > 0 {
-			words[i] = strings.ToUpper(string(word[0])) + strings.ToLower(word[1:])
-		}
+		words[i] = caseWord(word, i == 0)
 	}
 	return strings.Join(words, " ")
 }
 
+// determineShapeType is the shape before artwork analysis, see inferShapes
 func determineShapeType(category string) string {
 	return "image"
 }
@@ -437,8 +765,10 @@ func determineDefaultWidth(category string) int {
 	}
 }
 
-func calculatePopularity(title string) float32 {
-	titleLower := strings.ToLower(title)
+// calculatePopularity is the static popularity of the display name of an
+// icon, generation and applyUsage must score the same input
+func calculatePopularity(displayName string) float32 {
+	titleLower := strings.ToLower(displayName)
 	for service := range popularServices {
 		if strings.Contains(titleLower, service) {
 			return 1.0
@@ -455,32 +785,6 @@ func arrayToJSON(arr []string) string {
 	return string(data)
 }
 
-func getFullProviderName(provider string) string {
-	providerMap := map[string]string{
-		"AWS": "Amazon Web Services", "AZURE": "Microsoft Azure",
-		"GCP": "Google Cloud Platform", "ESSENTIALS": "Essential Icons",
-		"DEV": "Development Tools", "INFRA": "Infrastructure",
-		"TECH": "Technology", "SOCIAL": "Social Media", "EMOTIONS": "Emojis",
-	}
-	if fullName, exists := providerMap[strings.ToUpper(provider)]; exists {
-		return fullName
-	}
-	return strings.Title(strings.ToLower(provider))
-}
-
-func getProviderKey(fullProviderName string) string {
-	reverseMap := map[string]string{
-		"Amazon Web Services": "aws", "Microsoft Azure": "azure",
-		"Google Cloud Platform": "gcp", "Essential Icons": "essentials",
-		"Development Tools": "dev", "Infrastructure": "infra",
-		"Technology": "tech", "Social Media": "social", "Emojis": "emotions",
-	}
-	if key, exists := reverseMap[fullProviderName]; exists {
-		return key
-	}
-	return strings.ToLower(fullProviderName)
-}
-
 func getUnescaped(escaped string) string {
 	return escapeRgx.ReplaceAllStringFunc(escaped, func(match string) string {
 		hexCode := match[2:]
@@ -489,15 +793,34 @@ func getUnescaped(escaped string) string {
 	})
 }
 
+// writeJSON writes data to path as indented JSON, run output goes through
+// Config.writeJSON instead
 func writeJSON(path string, data interface{}) error {
+	return encodeJSONFile(path, data, false)
+}
+
+// writeJSON writes data to path, as canonical JSON with CanonicalJSON
+func (c *Config) writeJSON(path string, data interface{}) error {
+	return encodeJSONFile(path, data, c != nil && c.CanonicalJSON)
+}
+
+func encodeJSONFile(path string, data interface{}, canonical bool) error {
 	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return fmt.Errorf("error opening file %s: %w", path, err)
 	}
 	defer f.Close()
 
+	if canonical {
+		b, err := marshalCanonical(data)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(b)
+		return err
+	}
 	e := json.NewEncoder(f)
 	e.SetEscapeHTML(false)
 	e.SetIndent("", "  ")
 	return e.Encode(data)
-}
\ No newline at end of file
+}
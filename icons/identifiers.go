@@ -0,0 +1,130 @@
+package icons
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed identifiers.yaml
+var identifiersYAML []byte
+
+// vendorTokens are dropped from icon names before matching identifiers
+var vendorTokens = map[string]bool{"amazon": true, "aws": true, "azure": true, "microsoft": true, "google": true, "gcp": true}
+
+// MachineIdentifiers map an icon to the resources infrastructure-as-code
+// tools manage for it
+type MachineIdentifiers struct {
+	// ServiceCode is the AWS service code, GCP API or Azure resource provider
+	ServiceCode string `json:"service_code,omitempty" yaml:"service_code"`
+	// ARNPrefix prefixes the ARNs of AWS resources of the service
+	ARNPrefix string `json:"arn_prefix,omitempty" yaml:"arn_prefix"`
+	// TerraformResources are resource types, e.g. aws_instance
+	TerraformResources []string `json:"terraform_resources,omitempty" yaml:"terraform"`
+	// CloudFormationTypes are resource types, e.g. AWS::EC2::Instance
+	CloudFormationTypes []string `json:"cloudformation_types,omitempty" yaml:"cloudformation"`
+	// ARMTypes are Azure Resource Manager and Bicep resource types, e.g.
+	// Microsoft.Compute/virtualMachines
+	ARMTypes []string `json:"arm_types,omitempty" yaml:"arm"`
+}
+
+// MetadataProvider attaches machine identifiers to icons
+type MetadataProvider interface {
+	// Name identifies the provider in logs
+	Name() string
+	// Identifiers returns the identifiers of icon, false when unknown
+	Identifiers(icon *IconPayload) (*MachineIdentifiers, bool)
+}
+
+var metadataProviders []MetadataProvider
+
+// RegisterMetadataProvider adds p to the providers consulted, in order, for
+// every icon
+func RegisterMetadataProvider(p MetadataProvider) {
+	metadataProviders = append(metadataProviders, p)
+}
+
+func init() {
+	catalog, err := newIdentifierCatalog(identifiersYAML)
+	if err != nil {
+		panic(err)
+	}
+	RegisterMetadataProvider(catalog)
+}
+
+// identifierEntry is a service of the embedded identifier catalog
+type identifierEntry struct {
+	Names              []string `yaml:"names"`
+	MachineIdentifiers `yaml:",inline"`
+}
+
+// identifierCatalog matches icons to the embedded cloud service catalog by
+// provider and normalized name
+type identifierCatalog map[string]map[string]*MachineIdentifiers
+
+func newIdentifierCatalog(data []byte) (identifierCatalog, error) {
+	var raw map[string][]identifierEntry
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing identifier catalog: %w", err)
+	}
+
+	catalog := make(identifierCatalog)
+	for provider, entries := range raw {
+		names := make(map[string]*MachineIdentifiers)
+		for i := range entries {
+			ids := entries[i].MachineIdentifiers
+			if provider == "aws" && ids.ARNPrefix == "" && ids.ServiceCode != "" {
+				ids.ARNPrefix = "arn:aws:" + ids.ServiceCode
+			}
+			for _, name := range entries[i].Names {
+				names[identifierKey(name)] = &ids
+			}
+		}
+		catalog[provider] = names
+	}
+	return catalog, nil
+}
+
+func (identifierCatalog) Name() string { return "catalog" }
+
+func (c identifierCatalog) Identifiers(icon *IconPayload) (*MachineIdentifiers, bool) {
+	p, ok := Providers.ByDisplayName(icon.Provider)
+	if !ok {
+		return nil, false
+	}
+	ids, ok := c[p.Key][identifierKey(icon.DisplayName)]
+	return ids, ok
+}
+
+// identifierKey normalizes a service name, dropping vendor prefixes and
+// punctuation
+func identifierKey(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for len(fields) > 1 && vendorTokens[fields[0]] {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
+
+// attachIdentifiers sets the machine identifiers of icons from the first
+// metadata provider knowing them, returning the number of icons matched
+func attachIdentifiers(icons []*IconPayload, timestamp string) int {
+	matched := 0
+	for _, icon := range icons {
+		for _, p := range metadataProviders {
+			ids, ok := p.Identifiers(icon)
+			if !ok {
+				continue
+			}
+			icon.Identifiers = ids
+			icon.setProvenance(SourceRules, timestamp, "identifiers")
+			matched++
+			break
+		}
+	}
+	return matched
+}
@@ -0,0 +1,235 @@
+package icons
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	checksumsFile = "SHA256SUMS"
+	signatureFile = "SHA256SUMS.sig"
+)
+
+// ErrIntegrity is returned by Verify when the output does not match its
+// checksums or signature
+var ErrIntegrity = errors.New("integrity check failed")
+
+// writeChecksums writes the SHA-256 of every file under dir to SHA256SUMS,
+// in sha256sum format, and signs it when keyFile is set
+func writeChecksums(dir, keyFile string) error {
+	sums, err := hashTree(dir)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(sums))
+	for p := range sums {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var b bytes.Buffer
+	for _, p := range paths {
+		fmt.Fprintf(&b, "%s  %s\n", sums[p], p)
+	}
+	if err := os.WriteFile(filepath.Join(dir, checksumsFile), b.Bytes(), 0600); err != nil {
+		return fmt.Errorf("error writing checksums: %w", err)
+	}
+	if keyFile == "" {
+		return nil
+	}
+
+	key, err := loadSigningKey(keyFile)
+	if err != nil {
+		return err
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, b.Bytes()))
+	if err := os.WriteFile(filepath.Join(dir, signatureFile), []byte(sig+"\n"), 0600); err != nil {
+		return fmt.Errorf("error writing signature: %w", err)
+	}
+	return nil
+}
+
+// hashTree returns the SHA-256 of every regular file under dir keyed by its
+// slash separated relative path, skipping the checksum and signature files
+func hashTree(dir string) (map[string]string, error) {
+	sums := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == checksumsFile || rel == signatureFile {
+			return nil
+		}
+		sum, err := fileHash(path)
+		if err != nil {
+			return err
+		}
+		sums[rel] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error hashing %s: %w", dir, err)
+	}
+	return sums, nil
+}
+
+func fileHash(path string) (string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify checks every file of a downloaded dataset against its SHA256SUMS,
+// reporting modified, missing and unlisted files
+func Verify(dir string) error {
+	listed, err := readChecksums(dir)
+	if err != nil {
+		return err
+	}
+	actual, err := hashTree(dir)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for p, sum := range listed {
+		got, ok := actual[p]
+		switch {
+		case !ok:
+			problems = append(problems, "missing "+p)
+		case got != sum:
+			problems = append(problems, "modified "+p)
+		}
+	}
+	for p := range actual {
+		if _, ok := listed[p]; !ok {
+			problems = append(problems, "unlisted "+p)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("%w: %s", ErrIntegrity, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// VerifySigned checks the detached signature of SHA256SUMS with the PEM
+// encoded Ed25519 public key in keyFile, then verifies the dataset
+func VerifySigned(dir, keyFile string) error {
+	key, err := loadPublicKey(keyFile)
+	if err != nil {
+		return err
+	}
+	sums, err := os.ReadFile(filepath.Join(dir, checksumsFile))
+	if err != nil {
+		return fmt.Errorf("error reading checksums: %w", err)
+	}
+	encoded, err := os.ReadFile(filepath.Join(dir, signatureFile))
+	if err != nil {
+		return fmt.Errorf("error reading signature: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return fmt.Errorf("error decoding signature: %w", err)
+	}
+	if !ed25519.Verify(key, sums, sig) {
+		return fmt.Errorf("%w: bad signature of %s", ErrIntegrity, checksumsFile)
+	}
+	return Verify(dir)
+}
+
+// readChecksums parses the SHA256SUMS of dir
+func readChecksums(dir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, checksumsFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading checksums: %w", err)
+	}
+	defer f.Close()
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		sum, path, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed line %q in %s", ErrIntegrity, scanner.Text(), checksumsFile)
+		}
+		sums[path] = sum
+	}
+	return sums, scanner.Err()
+}
+
+// loadSigningKey reads a PEM encoded PKCS #8 Ed25519 private key, as written
+// by `openssl genpkey -algorithm ed25519`
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing signing key %s: %w", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+	}
+	return priv, nil
+}
+
+// loadPublicKey reads a PEM encoded PKIX Ed25519 public key
+func loadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key %s: %w", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an Ed25519 key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading key %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,129 @@
+package icons
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// keywordSepRgx splits data-search phrases, e.g. "ec2, virtual machine | vm"
+	keywordSepRgx = regexp.MustCompile(`[,;|]+`)
+
+	keywordStopWords = map[string]bool{
+		"a": true, "an": true, "and": true, "for": true, "icon": true,
+		"in": true, "of": true, "on": true, "or": true, "the": true,
+		"to": true, "with": true, "svg": true,
+	}
+)
+
+// parseKeywords splits a raw data-search string into distinct lower cased
+// keywords, keeping the phrases of a delimited list as well as their words
+func parseKeywords(raw string) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+	add := func(k string) {
+		k = strings.Join(strings.Fields(k), " ")
+		if len(k) < 2 || seen[k] || keywordStopWords[k] {
+			return
+		}
+		seen[k] = true
+		keywords = append(keywords, k)
+	}
+
+	phrases := keywordSepRgx.Split(strings.ToLower(raw), -1)
+	for _, phrase := range phrases {
+		phrase = strings.TrimSpace(phrase)
+		if len(phrases) > 1 && strings.Contains(phrase, " ") {
+			add(phrase)
+		}
+		for _, word := range searchTokens(phrase) {
+			add(word)
+		}
+	}
+	return keywords
+}
+
+// mergeKeywords adds the parsed data-search keywords of every icon to its
+// aliases (phrases) and tags (words) and returns how many icons gained any
+func mergeKeywords(icons []*IconPayload, timestamp string) int {
+	merged := 0
+	for _, icon := range icons {
+		if len(icon.Keywords) == 0 {
+			continue
+		}
+		name := wordSet(icon.DisplayName)
+		var phrases, words []string
+		for _, k := range icon.Keywords {
+			switch {
+			case strings.Contains(k, " "):
+				if !strings.EqualFold(k, icon.DisplayName) {
+					phrases = append(phrases, k)
+				}
+			case !name[k]:
+				words = append(words, k)
+			}
+		}
+
+		added := false
+		if aliases, ok := mergeUnique(icon.Aliases, phrases); ok {
+			if len(jsonToArray(icon.Aliases)) == 0 {
+				icon.setProvenance(SourceScraper, timestamp, "aliases")
+			}
+			icon.Aliases = aliases
+			added = true
+		}
+		if tags, ok := mergeUnique(icon.Tags, words); ok {
+			if len(jsonToArray(icon.Tags)) == 0 {
+				icon.setProvenance(SourceScraper, timestamp, "tags")
+			}
+			icon.Tags = tags
+			added = true
+		}
+		if added {
+			merged++
+		}
+	}
+	return merged
+}
+
+// indexSearchText sets the search text of icons to their display name and
+// aliases, the fields search scores on
+func indexSearchText(icons []*IconPayload, timestamp string) {
+	for _, icon := range icons {
+		parts := []string{icon.DisplayName}
+		for _, alias := range jsonToArray(icon.Aliases) {
+			if !strings.EqualFold(alias, icon.DisplayName) {
+				parts = append(parts, alias)
+			}
+		}
+		text := strings.Join(parts, " ")
+		if text == icon.SearchText {
+			continue
+		}
+		icon.SearchText = text
+		icon.setProvenance(SourceRules, timestamp, "search_text")
+	}
+}
+
+// mergeUnique appends the values missing from the JSON array field, ignoring
+// case, and reports whether any was added
+func mergeUnique(field string, values []string) (string, bool) {
+	existing := jsonToArray(field)
+	seen := make(map[string]bool, len(existing))
+	for _, v := range existing {
+		seen[strings.ToLower(v)] = true
+	}
+	added := false
+	for _, v := range values {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		existing = append(existing, v)
+		added = true
+	}
+	if !added {
+		return field, false
+	}
+	return arrayToJSON(existing), true
+}
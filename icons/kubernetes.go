@@ -0,0 +1,115 @@
+package icons
+
+import (
+	"strings"
+)
+
+// kubernetesKind maps a Kubernetes kind to icons
+type kubernetesKind struct {
+	// Abbrev is the name of the kind in the Kubernetes community icon set
+	Abbrev string
+	// Queries find analogous icons when no Kubernetes icon matches
+	Queries []string
+}
+
+// kubernetesKinds is the curated kind table, keyed by lower cased kind
+var kubernetesKinds = map[string]kubernetesKind{
+	"pod":                      {"pod", []string{"container"}},
+	"deployment":               {"deploy", nil},
+	"replicaset":               {"rs", []string{"replica"}},
+	"statefulset":              {"sts", nil},
+	"daemonset":                {"ds", nil},
+	"job":                      {"job", []string{"batch"}},
+	"cronjob":                  {"cronjob", []string{"scheduler"}},
+	"service":                  {"svc", []string{"service mesh"}},
+	"ingress":                  {"ing", []string{"load balancing", "load balancer"}},
+	"ingressclass":             {"ing", nil},
+	"networkpolicy":            {"netpol", []string{"firewall"}},
+	"endpoints":                {"ep", nil},
+	"configmap":                {"cm", []string{"config"}},
+	"secret":                   {"secret", []string{"secrets manager", "key vault"}},
+	"persistentvolume":         {"pv", []string{"persistent disk", "block store"}},
+	"persistentvolumeclaim":    {"pvc", []string{"persistent disk"}},
+	"storageclass":             {"sc", []string{"storage"}},
+	"namespace":                {"ns", nil},
+	"node":                     {"node", []string{"virtual machine", "server"}},
+	"serviceaccount":           {"sa", []string{"identity"}},
+	"role":                     {"role", []string{"iam"}},
+	"clusterrole":              {"c-role", []string{"iam"}},
+	"rolebinding":              {"rb", []string{"iam"}},
+	"clusterrolebinding":       {"crb", []string{"iam"}},
+	"horizontalpodautoscaler":  {"hpa", []string{"auto scaling"}},
+	"limitrange":               {"limits", nil},
+	"resourcequota":            {"quota", nil},
+	"poddisruptionbudget":      {"pdb", nil},
+	"customresourcedefinition": {"crd", nil},
+}
+
+// MapKubernetesKind returns the icon of a Kubernetes kind, e.g. apps/v1
+// Deployment. Icons named after the kind win, then Kubernetes icons matching
+// the kind, then the curated analogues such as a load balancer for Ingress,
+// then the vendor of a custom API group, then the generic Kubernetes icon. It
+// returns nil when nothing matches
+func (d *Dataset) MapKubernetesKind(apiVersion, kind string) *IconPayload {
+	key := strings.ToLower(kind)
+	entry, curated := kubernetesKinds[key]
+
+	names := []string{key}
+	if curated && entry.Abbrev != key {
+		names = append(names, entry.Abbrev)
+	}
+	for _, prefix := range []string{"kubernetes", "k8s"} {
+		for _, name := range names {
+			if icon, ok := d.Get(prefix + "-" + name); ok {
+				return icon
+			}
+		}
+	}
+
+	for _, r := range d.Search(kind, SearchOptions{}) {
+		if r.Score >= kubernetesMinScore && isKubernetesIcon(r.Icon) {
+			return r.Icon
+		}
+	}
+
+	for _, query := range entry.Queries {
+		if r := d.Lookup(query, ""); r != nil && r.Score >= kubernetesMinScore {
+			return r.Icon
+		}
+	}
+
+	if group := kubernetesGroup(apiVersion); group != "" {
+		if r := d.Lookup(group, ""); r != nil && r.Score >= kubernetesMinScore {
+			return r.Icon
+		}
+	}
+
+	if r := d.Lookup("kubernetes", ""); r != nil {
+		return r.Icon
+	}
+	return nil
+}
+
+// kubernetesMinScore is the search score of a whole name word, so icons
+// only matching in their description are not returned
+const kubernetesMinScore = 10
+
+// isKubernetesIcon reports whether icon depicts a Kubernetes object
+func isKubernetesIcon(icon *IconPayload) bool {
+	words := wordSet(strings.ToLower(icon.Slug + " " + icon.DisplayName + " " + strings.Join(jsonToArray(icon.Tags), " ")))
+	return words["kubernetes"] || words["k8s"]
+}
+
+// kubernetesGroup returns the vendor of a non built-in API group, e.g.
+// argoproj.io/v1alpha1 yields argoproj
+func kubernetesGroup(apiVersion string) string {
+	i := strings.Index(apiVersion, "/")
+	if i < 0 {
+		return ""
+	}
+	group := apiVersion[:i]
+	if strings.HasSuffix(group, ".k8s.io") || !strings.Contains(group, ".") {
+		return ""
+	}
+	return group[:strings.Index(group, ".")]
+}
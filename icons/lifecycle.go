@@ -0,0 +1,70 @@
+package icons
+
+import (
+	"bytes"
+)
+
+// lifecycleFields are carried over from the previous run rather than
+// compared by stampLifecycle
+var lifecycleFields = []string{"first_seen", "last_modified"}
+
+// stampLifecycle carries first_seen and last_modified over from the icons of
+// previous with the same slug, so last_modified only moves when the content
+// of an icon changed. It returns the number of new or modified icons
+func stampLifecycle(previous, icons []*IconPayload) int {
+	bySlug := make(map[string]*IconPayload, len(previous))
+	for _, icon := range previous {
+		bySlug[icon.Slug] = icon
+	}
+
+	modified := 0
+	for _, icon := range icons {
+		old, ok := bySlug[icon.Slug]
+		if !ok {
+			modified++
+			continue
+		}
+		if old.FirstSeen != "" {
+			icon.FirstSeen = old.FirstSeen
+		}
+		if old.LastModified != "" && sameContent(old, icon) {
+			icon.LastModified = old.LastModified
+			continue
+		}
+		modified++
+	}
+	return modified
+}
+
+// sameContent reports whether a and b only differ in volatile and
+// lifecycle fields
+func sameContent(a, b *IconPayload) bool {
+	ca, err := iconContent(a)
+	if err != nil {
+		return false
+	}
+	cb, err := iconContent(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ca, cb)
+}
+
+// iconContent returns the canonical JSON of icon without its volatile and
+// lifecycle fields
+func iconContent(icon *IconPayload) ([]byte, error) {
+	data, err := marshalRaw(icon)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decodeNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := value.(map[string]any); ok {
+		stripVolatile(m, lifecycleFields...)
+	}
+	var buf bytes.Buffer
+	writeCanonical(&buf, value, "")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,3 @@
+CREATE EXTENSION IF NOT EXISTS vector;
+
+ALTER TABLE icons ADD COLUMN IF NOT EXISTS embedding vector;
@@ -0,0 +1,3 @@
+ALTER TABLE icons ADD COLUMN IF NOT EXISTS embedding_model TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE icons ADD COLUMN IF NOT EXISTS embedding_dimensions INTEGER NOT NULL DEFAULT 0;
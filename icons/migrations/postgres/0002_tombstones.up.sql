@@ -0,0 +1,5 @@
+ALTER TABLE icons ADD COLUMN IF NOT EXISTS run_id TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE icons ADD COLUMN IF NOT EXISTS deprecated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS icons_live_idx ON icons (namespace) WHERE deprecated_at IS NULL;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS icons (
+    namespace    TEXT NOT NULL DEFAULT '',
+    slug         TEXT NOT NULL,
+    id           TEXT NOT NULL,
+    provider     TEXT NOT NULL,
+    category_id  TEXT NOT NULL DEFAULT '',
+    display_name TEXT NOT NULL,
+    url          TEXT NOT NULL,
+    iconify_id   TEXT NOT NULL DEFAULT '',
+    document     TEXT NOT NULL DEFAULT '',
+    payload      TEXT NOT NULL,
+    updated_at   TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (namespace, slug)
+);
+
+CREATE INDEX IF NOT EXISTS icons_provider_idx ON icons (namespace, provider);
+
+CREATE INDEX IF NOT EXISTS icons_category_idx ON icons (namespace, category_id);
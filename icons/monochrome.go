@@ -0,0 +1,52 @@
+package icons
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// monoKeepLuminance keeps near-white paint, which usually draws inner detail
+// on a colored shape, when recoloring to a single color
+const monoKeepLuminance = 0.9
+
+// monochrome returns a recoloring mapping every paint to target except
+// near-white detail
+func monochrome(target rgb) func(rgb) rgb {
+	return func(c rgb) rgb {
+		if c.luminance() >= monoKeepLuminance {
+			return c
+		}
+		return target
+	}
+}
+
+// generateMonochromeVariants writes a single-color variant of every
+// downloaded icon for print and sketch diagrams, returning the number written
+func generateMonochromeVariants(cfg *Config, icons []*IconPayload, timestamp string) (int, error) {
+	target, ok := parseColor(cfg.MonochromeColor)
+	if !ok {
+		return 0, fmt.Errorf("invalid monochrome color %q", cfg.MonochromeColor)
+	}
+
+	generated := 0
+	for _, icon := range icons {
+		data, err := readAsset(cfg.OutputDir, icon)
+		if err != nil {
+			continue
+		}
+
+		rel := strings.TrimSuffix(icon.LocalPath, ".svg") + ".mono.svg"
+		if err := os.WriteFile(filepath.Join(cfg.OutputDir, filepath.FromSlash(rel)), recolorSVG(data, monochrome(target)), 0600); err != nil {
+			log.Printf("⚠️  Monochrome variant %s: %v", icon.Slug, err)
+			continue
+		}
+		icon.MonoLocalPath = rel
+		icon.MonoURL = assetURL(cfg, rel)
+		icon.setProvenance(SourceRules, timestamp, "mono_local_path", "mono_url")
+		generated++
+	}
+	return generated, nil
+}
@@ -0,0 +1,92 @@
+package icons
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// namespacesFile lists the corpora sharing an output directory
+const namespacesFile = "namespaces.json"
+
+var namespaceRgx = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// NamespaceEntry is an entry of namespaces.json
+type NamespaceEntry struct {
+	Namespace string `json:"namespace"`
+	Path      string `json:"path"`
+	Icons     int    `json:"icons"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// validateNamespace rejects namespaces that are not safe as a directory name
+// and an identifier prefix
+func validateNamespace(ns string) error {
+	if ns != "" && !namespaceRgx.MatchString(ns) {
+		return fmt.Errorf("invalid namespace %q: use lowercase letters, digits, - and _", ns)
+	}
+	return nil
+}
+
+// namespaceDir returns the directory the corpus of c is published to
+func (c *Config) namespaceDir() string {
+	return filepath.Join(c.OutputDir, c.Namespace)
+}
+
+// namespaced prefixes id with the namespace, e.g. infra:aws-ec2
+func namespaced(ns, id string) string {
+	if ns == "" {
+		return id
+	}
+	return ns + ":" + id
+}
+
+// updateNamespaces records the corpus of a namespace in the namespaces.json
+// of root, keeping the entries of other namespaces
+func updateNamespaces(cfg *Config, root string, entry NamespaceEntry) error {
+	path := filepath.Join(root, namespacesFile)
+	var entries []NamespaceEntry
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return fmt.Errorf("error parsing %s: %w", path, err)
+		}
+	}
+
+	replaced := false
+	for i := range entries {
+		if entries[i].Namespace == entry.Namespace {
+			entries[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Namespace < entries[j].Namespace })
+
+	// write next to the manifest and rename so readers never see half of it
+	tmp := path + ".tmp"
+	if err := cfg.writeJSON(tmp, entries); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func newNamespaceEntry(ns string, icons int) NamespaceEntry {
+	return NamespaceEntry{
+		Namespace: ns,
+		Path:      ns + "/",
+		Icons:     icons,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,152 @@
+package icons
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const preflightTimeout = 10 * time.Second
+
+// endpoint is a remote service the generator depends on
+type endpoint struct {
+	Name string
+	URL  string
+}
+
+// newTransport returns a transport honoring HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY, overridden by the proxy and CA settings of cfg
+func newTransport(cfg *Config) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		proxyCfg := httpproxy.FromEnvironment()
+		proxyCfg.HTTPProxy = cfg.ProxyURL
+		proxyCfg.HTTPSProxy = cfg.ProxyURL
+		if cfg.NoProxy != "" {
+			proxyCfg.NoProxy = cfg.NoProxy
+		}
+		proxyFunc := proxyCfg.ProxyFunc()
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if len(cfg.CACertFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, file := range cfg.CACertFiles {
+			pem, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("error reading CA bundle %s: %w", file, err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA bundle %s", file)
+			}
+		}
+		t.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return t, nil
+}
+
+// validateProxyURL checks that proxyURL, when set, is an absolute http,
+// https or socks5 URL
+func validateProxyURL(proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid proxy URL %s: scheme must be http, https or socks5", redactURL(proxyURL))
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %s: missing host", redactURL(proxyURL))
+	}
+	return nil
+}
+
+// validateBrowserSources rejects network settings headless Chrome cannot
+// honour on sources rendered with the browser backend: CA files, which it
+// only takes from the system store, and SOCKS proxy credentials, which it
+// does not support
+func validateBrowserSources(c *Config) error {
+	for _, sc := range c.Sources {
+		if sc.Backend != BackendBrowser {
+			continue
+		}
+		if len(c.CACertFiles) > 0 {
+			return fmt.Errorf("source %s: CA files cannot be used with the %s backend, add them to the system trust store instead", sourceName(sc), BackendBrowser)
+		}
+		if u, err := url.Parse(c.ProxyURL); err == nil && u.Scheme == "socks5" && u.User != nil {
+			return fmt.Errorf("source %s: the %s backend cannot authenticate to SOCKS proxy %s", sourceName(sc), BackendBrowser, redactURL(c.ProxyURL))
+		}
+	}
+	return nil
+}
+
+// Preflight checks that every endpoint used by cfg is reachable and reports
+// each unreachable one by name
+func Preflight(ctx context.Context, cfg *Config) error {
+	if err := cfg.prepare(); err != nil {
+		return err
+	}
+	client := cfg.client()
+	endpoints := []endpoint{
+		{Name: "icon source", URL: cfg.SourceURL},
+		{Name: "Iconify API", URL: iconifyAPIURL},
+	}
+	if useLLMEnrichment {
+		endpoints = append(endpoints, endpoint{Name: "LLM service", URL: llmHealthURL})
+	}
+
+	var errs []error
+	for _, ep := range endpoints {
+		if err := checkEndpoint(ctx, client, ep.URL); err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s) unreachable: %w", ep.Name, ep.URL, err))
+			continue
+		}
+		log.Printf("🌐 %s reachable", ep.Name)
+	}
+	return errors.Join(errs...)
+}
+
+func checkEndpoint(ctx context.Context, client *http.Client, u string) error {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusProxyAuthRequired {
+		return fmt.Errorf("status %s", strings.TrimSpace(resp.Status))
+	}
+	return nil
+}
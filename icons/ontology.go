@@ -0,0 +1,103 @@
+package icons
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const ontologyFile = "categories.json"
+
+//go:embed ontology.yaml
+var ontologyYAML []byte
+
+// ServiceCategory is a category of a cloud console, identified by a stable ID
+type ServiceCategory struct {
+	ID       string `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	Provider string `json:"provider" yaml:"-"`
+	Icons    int    `json:"icons" yaml:"-"`
+
+	Catalog []string `json:"-" yaml:"catalog"`
+	Names   []string `json:"-" yaml:"names"`
+	Default bool     `json:"-" yaml:"default"`
+}
+
+// ontology holds the service categories of each provider key
+type ontology map[string][]*ServiceCategory
+
+// loadOntology parses the embedded service category taxonomy
+func loadOntology() (ontology, error) {
+	o := make(ontology)
+	if err := yaml.Unmarshal(ontologyYAML, &o); err != nil {
+		return nil, fmt.Errorf("error parsing ontology: %w", err)
+	}
+	for key, categories := range o {
+		p, _ := Providers.Lookup(key)
+		for _, c := range categories {
+			c.Provider = p.DisplayName
+		}
+	}
+	return o, nil
+}
+
+// classify returns the service category of icon, nil for providers without
+// a taxonomy
+func (o ontology) classify(icon *IconPayload) *ServiceCategory {
+	p, ok := Providers.ByDisplayName(icon.Provider)
+	if !ok {
+		return nil
+	}
+	categories := o[p.Key]
+
+	key := " " + identifierKey(icon.DisplayName) + " "
+	for _, c := range categories {
+		for _, name := range c.Names {
+			if strings.Contains(key, " "+name+" ") {
+				return c
+			}
+		}
+	}
+
+	for _, c := range categories {
+		for _, name := range c.Catalog {
+			if strings.EqualFold(name, icon.Category) {
+				return c
+			}
+		}
+	}
+
+	for _, c := range categories {
+		if c.Default {
+			return c
+		}
+	}
+	return nil
+}
+
+// classifyServices sets category_id of icons and returns the categories of
+// every provider with a taxonomy, with their icon counts
+func classifyServices(icons []*IconPayload, timestamp string) ([]*ServiceCategory, error) {
+	o, err := loadOntology()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, icon := range icons {
+		c := o.classify(icon)
+		if c == nil {
+			continue
+		}
+		c.Icons++
+		icon.CategoryID = c.ID
+		icon.setProvenance(SourceRules, timestamp, "category_id")
+	}
+
+	all := make([]*ServiceCategory, 0)
+	for _, p := range Providers.All() {
+		all = append(all, o[p.Key]...)
+	}
+	return all, nil
+}
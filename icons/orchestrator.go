@@ -0,0 +1,357 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const runReportFile = "run_report.json"
+
+// SchemaVersion is the version of the output format, recorded in
+// run_report.json and bumped on breaking changes
+const SchemaVersion = 2
+
+// SourceStatus reports the outcome of one source of a run
+type SourceStatus struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Icons    int    `json:"icons"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RunReport summarizes a multi-source run
+type RunReport struct {
+	SchemaVersion int    `json:"schema_version"`
+	StartedAt     string `json:"started_at"`
+	Namespace     string `json:"namespace,omitempty"`
+	Snapshot      string `json:"snapshot,omitempty"`
+	// Seed is the seed of the run, rerunning with it reproduces the output
+	Seed int64 `json:"seed"`
+	// ContentSHA256 hashes the icons without their volatile fields, equal
+	// hashes mean a rerun changed nothing, see ContentHash
+	ContentSHA256 string `json:"content_sha256,omitempty"`
+	// Provider is set by GenerateProvider to the regenerated provider
+	Provider string         `json:"provider,omitempty"`
+	Sources  []SourceStatus `json:"sources"`
+	Sinks    []SinkStatus   `json:"sinks,omitempty"`
+	// Iconify is set by Reverify
+	Iconify *IconifyReport `json:"iconify,omitempty"`
+	// Duplicates counts icons dropped because an earlier source had the same URL
+	Duplicates int `json:"duplicates"`
+	Total      int `json:"total"`
+	// Partial is set when the run was interrupted, see checkpoint.json
+	Partial bool `json:"partial,omitempty"`
+}
+
+// finish records the total and content hash of the icons of the run
+func (r *RunReport) finish(icons []*IconPayload) error {
+	hash, err := ContentHash(icons)
+	if err != nil {
+		return err
+	}
+	r.Total, r.ContentSHA256 = len(icons), hash
+	return nil
+}
+
+// GenerateAll fetches every configured source concurrently, each with its own
+// rate limits, and merges them into a single corpus. A failing source is
+// reported in the RunReport; the run only fails when no source succeeds.
+// Output is only published once the whole run succeeded
+func GenerateAll(ctx context.Context, cfg *Config) (report *RunReport, err error) {
+	defer servePprof(cfg.PprofAddr)()
+	if err := startRun(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	run, err := openRun(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil && run.staging != "" {
+			log.Printf("⚠️  Kept previous output, partial run left in %s", run.staging)
+		}
+	}()
+	cfg = run.cfg
+
+	report = run.report()
+	mark := stageClock.start()
+	pendingIcons, err := collectPending(ctx, cfg, report)
+	if err != nil {
+		return report, err
+	}
+	stageClock.done(stageFetch, mark, len(pendingIcons))
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	allIcons, err := process(ctx, cfg, pendingIcons, timestamp)
+	if err != nil {
+		if ctx.Err() != nil && len(allIcons) > 0 {
+			if ferr := run.flushPartial(report, pendingIcons, allIcons); ferr != nil {
+				log.Printf("⚠️  Failed to flush partial output: %v", ferr)
+			}
+		}
+		return report, err
+	}
+
+	mark = stageClock.start()
+	if err := writeOutputs(ctx, cfg, allIcons, run.previous); err != nil {
+		return report, err
+	}
+	stageClock.done(stageWrite, mark, len(allIcons))
+
+	if err := runSinks(ctx, cfg, report, allIcons, &SinkRun{Previous: run.previous}); err != nil {
+		return report, err
+	}
+
+	if err := report.finish(allIcons); err != nil {
+		return report, err
+	}
+	if err := run.publish(report); err != nil {
+		return report, err
+	}
+
+	log.Println("✅ Generation complete!")
+	return report, nil
+}
+
+// startRun prepares cfg and checks the network and LLM service
+func startRun(ctx context.Context, cfg *Config) error {
+	log.Println("🚀 Enhanced Icon Generator - JSON Output Only")
+	if testingMode {
+		log.Printf("🧪 TESTING MODE: %d icons per category", testLimit)
+	}
+
+	if err := cfg.prepare(); err != nil {
+		return err
+	}
+	if err := validateProfiles(cfg.Profiles); err != nil {
+		return err
+	}
+	if err := validateNamespace(cfg.Namespace); err != nil {
+		return err
+	}
+
+	if cfg.Preflight && !cfg.Offline && cfg.FixtureMode != FixtureReplay {
+		if err := Preflight(ctx, cfg); err != nil {
+			return fmt.Errorf("preflight failed: %w", err)
+		}
+	}
+
+	if useLLMEnrichment {
+		if checkLLMService(cfg.client()) {
+			log.Println("✅ LLM service connected")
+			llmServiceAvailable = true
+		} else {
+			log.Println("⚠️  LLM service unavailable - using fallback")
+			llmServiceAvailable = false
+		}
+	}
+	return nil
+}
+
+// runDir is the directory a run writes into before it is published
+type runDir struct {
+	cfg      *Config
+	root     string
+	previous string
+	snapshot string
+	staging  string
+	started  time.Time
+}
+
+// openRun creates the directory of a new run, a snapshot when snapshots are
+// kept and a staging directory next to the output otherwise
+func openRun(cfg *Config) (*runDir, error) {
+	if err := checkSwappable(cfg); err != nil {
+		return nil, err
+	}
+	root := cfg.namespaceDir()
+	if err := os.MkdirAll(root, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	run := *cfg
+	r := &runDir{
+		cfg:      &run,
+		root:     root,
+		previous: filepath.Join(root, jsonFile),
+		started:  time.Now(),
+	}
+	if cfg.KeepSnapshots > 0 {
+		r.snapshot = snapshotName(r.started)
+		r.previous = filepath.Join(r.root, latestLink, jsonFile)
+		run.OutputDir = filepath.Join(r.root, r.snapshot)
+		log.Printf("📸 Writing snapshot %s", r.snapshot)
+	} else {
+		// Stage the run next to the output so a failure never leaves a mix of
+		// old and new files behind
+		r.staging = filepath.Clean(r.root) + stagingSuffix
+		if err := os.RemoveAll(r.staging); err != nil {
+			return nil, err
+		}
+		run.OutputDir = r.staging
+	}
+	if err := os.MkdirAll(run.OutputDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	return r, nil
+}
+
+// report returns an empty report of the run
+func (r *runDir) report() *RunReport {
+	return &RunReport{SchemaVersion: SchemaVersion, StartedAt: r.started.UTC().Format(time.RFC3339), Namespace: r.cfg.Namespace, Snapshot: r.snapshot, Seed: r.cfg.Seed}
+}
+
+// publish writes the final report and checksums, then swaps the staging
+// directory in or points the latest link at the snapshot
+func (r *runDir) publish(report *RunReport) error {
+	if err := r.cfg.writeJSON(filepath.Join(r.cfg.OutputDir, runReportFile), report); err != nil {
+		return err
+	}
+
+	// checksums go last so they cover every published file
+	if err := writeChecksums(r.cfg.OutputDir, r.cfg.SigningKeyFile); err != nil {
+		return err
+	}
+	log.Printf("🔏 Wrote %s", filepath.Join(r.cfg.OutputDir, checksumsFile))
+
+	if r.staging != "" {
+		if err := swapDir(r.staging, r.root); err != nil {
+			return err
+		}
+	}
+
+	if r.snapshot != "" {
+		if err := linkLatest(r.root, r.snapshot); err != nil {
+			return err
+		}
+		removed, err := pruneSnapshots(r.root, r.cfg.KeepSnapshots)
+		if err != nil {
+			return err
+		}
+		if len(removed) > 0 {
+			log.Printf("🧹 Removed %d old snapshots", len(removed))
+		}
+	}
+
+	if ns := r.cfg.Namespace; ns != "" {
+		return updateNamespaces(r.cfg, filepath.Dir(r.root), newNamespaceEntry(ns, report.Total))
+	}
+	return nil
+}
+
+// collectPending fetches the sources and groups, cleans and filters their
+// icons ahead of enrichment
+func collectPending(ctx context.Context, cfg *Config, report *RunReport) ([]PendingIcon, error) {
+	pendingIcons, err := fetchSources(ctx, cfg, report)
+	if werr := cfg.writeJSON(filepath.Join(cfg.OutputDir, runReportFile), report); werr != nil {
+		return nil, werr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var merged int
+	if pendingIcons, merged = groupVariants(pendingIcons); merged > 0 {
+		log.Printf("🎨 Grouped %d variants under their icons", merged)
+	}
+	if n := stripTitleNoise(pendingIcons, cfg.StopTokens); n > 0 {
+		log.Printf("🧹 Stripped noise tokens from %d titles", n)
+	}
+
+	// filters go last so they match the slugs icons are published under
+	if cfg.FilterFile != "" {
+		filter, err := LoadIconFilter(cfg.FilterFile)
+		if err != nil {
+			return nil, err
+		}
+		var excluded int
+		pendingIcons, excluded = filterPending(pendingIcons, filter, cfg.SlugPolicy)
+		if excluded > 0 {
+			log.Printf("🚫 Excluded %d icons via %s", excluded, cfg.FilterFile)
+		}
+	}
+
+	providers := make(map[string]bool)
+	for _, p := range pendingIcons {
+		providers[p.Category] = true
+	}
+	log.Printf("✅ Collected %d icons from %d categories", len(pendingIcons), len(providers))
+	return pendingIcons, nil
+}
+
+// fetchSources runs the sources of cfg concurrently and merges their icons in
+// source order, dropping icons whose URL was already seen
+func fetchSources(ctx context.Context, cfg *Config, report *RunReport) ([]PendingIcon, error) {
+	configs := cfg.sourceConfigs()
+	results := make([][]PendingIcon, len(configs))
+	errs := make([]error, len(configs))
+	report.Sources = make([]SourceStatus, len(configs))
+
+	var wg sync.WaitGroup
+	for i, sc := range configs {
+		report.Sources[i] = SourceStatus{Name: sourceName(sc), Type: sc.Type}
+
+		source, err := newSource(sc)
+		if err != nil {
+			errs[i] = err
+			report.Sources[i].Error = err.Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, sc SourceConfig, source Source) {
+			defer wg.Done()
+			start := time.Now()
+			results[i], errs[i] = source.Fetch(ctx, sc.limited(cfg))
+
+			status := &report.Sources[i]
+			status.Duration = time.Since(start).Round(time.Millisecond).String()
+			status.Icons = len(results[i])
+			if errs[i] != nil {
+				status.Error = errs[i].Error()
+				log.Printf("❌ Source %s failed after %s: %v", status.Name, status.Duration, errs[i])
+				return
+			}
+			log.Printf("📥 Source %s: %d icons in %s", status.Name, status.Icons, status.Duration)
+		}(i, sc, source)
+	}
+	wg.Wait()
+
+	var (
+		merged    []PendingIcon
+		succeeded int
+		seen      = make(map[string]bool)
+	)
+	for i, icons := range results {
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("source %s: %w", report.Sources[i].Name, errs[i])
+			continue
+		}
+		succeeded++
+		for _, icon := range icons {
+			key := icon.URL
+			if key == "" {
+				key = icon.Link
+			}
+			if seen[key] {
+				report.Duplicates++
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, icon)
+		}
+	}
+
+	if succeeded == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return merged, nil
+}
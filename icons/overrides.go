@@ -0,0 +1,154 @@
+package icons
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const overridesFile = "overrides.yaml"
+
+// Override forces field values for a single icon, unset fields are kept
+type Override struct {
+	DisplayName     *string  `yaml:"display_name,omitempty" json:"display_name,omitempty"`
+	IconifyID       *string  `yaml:"iconify_id,omitempty" json:"iconify_id,omitempty"`
+	ColorTheme      *string  `yaml:"color_theme,omitempty" json:"color_theme,omitempty"`
+	IsContainer     *bool    `yaml:"is_container,omitempty" json:"is_container,omitempty"`
+	ShapeType       *string  `yaml:"shape_type,omitempty" json:"shape_type,omitempty"`
+	DefaultWidth    *int     `yaml:"default_width,omitempty" json:"default_width,omitempty"`
+	Description     *string  `yaml:"description,omitempty" json:"description,omitempty"`
+	TechnicalIntent *string  `yaml:"technical_intent,omitempty" json:"technical_intent,omitempty"`
+	Aliases         []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+	Tags            []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	// ApprovalStatus records the decision of a curator, approved or rejected
+	ApprovalStatus *string `yaml:"approval_status,omitempty" json:"approval_status,omitempty"`
+}
+
+// LoadOverrides reads an overrides file keyed by slug, a missing file yields
+// no overrides
+func LoadOverrides(path string) (map[string]Override, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading overrides %s: %w", path, err)
+	}
+
+	overrides := make(map[string]Override)
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("error parsing overrides %s: %w", path, err)
+	}
+	for slug, o := range overrides {
+		if o.ApprovalStatus == nil {
+			continue
+		}
+		if err := validApproval(*o.ApprovalStatus); err != nil {
+			return nil, fmt.Errorf("error in overrides %s for %s: %w", path, slug, err)
+		}
+	}
+	return overrides, nil
+}
+
+// writeOverrides writes overrides to path as YAML, comments of an existing
+// file are not kept
+func writeOverrides(path string, overrides map[string]Override) error {
+	var out bytes.Buffer
+	e := yaml.NewEncoder(&out)
+	e.SetIndent(2)
+	if err := e.Encode(overrides); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Clean(path), out.Bytes(), 0600); err != nil {
+		return fmt.Errorf("error writing overrides %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyOverrides forces override values onto icons as the final pipeline
+// stage, see warnUnmatchedOverrides
+func applyOverrides(icons []*IconPayload, overrides map[string]Override, timestamp string) int {
+	applied := 0
+	for _, icon := range icons {
+		o, ok := overrides[icon.Slug]
+		if !ok {
+			continue
+		}
+		applied++
+		o.apply(icon, timestamp)
+	}
+	return applied
+}
+
+// warnUnmatchedOverrides warns about override slugs matching none of icons
+func warnUnmatchedOverrides(icons []*IconPayload, overrides map[string]Override) {
+	if len(overrides) == 0 {
+		return
+	}
+	matched := make(map[string]bool, len(icons))
+	for _, icon := range icons {
+		matched[icon.Slug] = true
+	}
+	for slug := range overrides {
+		if !matched[slug] {
+			log.Printf("⚠️  Override for unknown slug %q", slug)
+		}
+	}
+}
+
+func (o Override) apply(icon *IconPayload, timestamp string) {
+	if o.DisplayName != nil {
+		icon.DisplayName = *o.DisplayName
+		icon.setProvenance(SourceOverride, timestamp, "display_name")
+	}
+	if o.IconifyID != nil {
+		icon.IconifyID = *o.IconifyID
+		icon.setProvenance(SourceOverride, timestamp, "iconify_id")
+	}
+	if o.ColorTheme != nil {
+		icon.ColorTheme = *o.ColorTheme
+		icon.setProvenance(SourceOverride, timestamp, "color_theme")
+	}
+	if o.IsContainer != nil {
+		icon.IsContainer = *o.IsContainer
+		icon.IconPosition = "center"
+		if icon.IsContainer {
+			icon.IconPosition = "top-left"
+		}
+		icon.setProvenance(SourceOverride, timestamp, "is_container", "icon_position")
+	}
+	if o.ShapeType != nil {
+		icon.ShapeType = *o.ShapeType
+		icon.setProvenance(SourceOverride, timestamp, "shape_type")
+	}
+	if o.DefaultWidth != nil {
+		icon.DefaultWidth = *o.DefaultWidth
+		icon.setProvenance(SourceOverride, timestamp, "default_width")
+	}
+	if o.Description != nil {
+		icon.Description = *o.Description
+		icon.setProvenance(SourceOverride, timestamp, "description")
+	}
+	if o.TechnicalIntent != nil {
+		icon.TechnicalIntent = *o.TechnicalIntent
+		icon.setProvenance(SourceOverride, timestamp, "technical_intent")
+	}
+	if o.Aliases != nil {
+		icon.Aliases = arrayToJSON(o.Aliases)
+		icon.setProvenance(SourceOverride, timestamp, "aliases")
+	}
+	if o.Tags != nil {
+		icon.Tags = arrayToJSON(o.Tags)
+		icon.setProvenance(SourceOverride, timestamp, "tags")
+	}
+	if o.ApprovalStatus != nil {
+		icon.ApprovalStatus = *o.ApprovalStatus
+		icon.setProvenance(SourceOverride, timestamp, "approval_status")
+	}
+}
@@ -0,0 +1,428 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PluginProtocol is the version of the plugin protocol. Plugins are
+// executables exchanging one JSON PluginRequest per line on stdin for one
+// PluginResponse per line on stdout, stderr is passed through
+const PluginProtocol = 1
+
+// pluginProtocolEnv tells a plugin the protocol version of the host
+const pluginProtocolEnv = "ICONS_PLUGIN_PROTOCOL"
+
+// Per-call timeouts of plugins, a plugin that does not answer in time is
+// killed. Fetch and write handle a whole source or run and get longer
+const (
+	pluginCallTimeout  = 30 * time.Second
+	pluginBatchTimeout = 30 * time.Minute
+)
+
+// Methods of the plugin protocol
+const (
+	// PluginMethodInfo returns the PluginManifest, it is always sent first
+	PluginMethodInfo = "info"
+	// PluginMethodFetch takes PluginFetchParams and returns []PendingIcon
+	PluginMethodFetch = "fetch"
+	// PluginMethodExtend takes an IconPayload and returns the extensions to
+	// set on it
+	PluginMethodExtend = "extend"
+	// PluginMethodWrite takes PluginWriteParams and returns nothing
+	PluginMethodWrite = "write"
+)
+
+// Kinds of plugins listed in PluginManifest
+const (
+	PluginKindSource   = "source"
+	PluginKindExtender = "extender"
+	PluginKindSink     = "sink"
+)
+
+// PluginRequest is a call of the host
+type PluginRequest struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// PluginResponse answers the request of the same ID, Error is set when the
+// call failed
+type PluginResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// PluginManifest describes a plugin
+type PluginManifest struct {
+	Name     string   `json:"name"`
+	Protocol int      `json:"protocol"`
+	Kinds    []string `json:"kinds"`
+}
+
+// PluginFetchParams are the parameters of fetch, URL is the URL of the
+// source configuration
+type PluginFetchParams struct {
+	URL string `json:"url,omitempty"`
+}
+
+// PluginWriteParams are the parameters of write
+type PluginWriteParams struct {
+	Run   PluginRun      `json:"run"`
+	Icons []*IconPayload `json:"icons"`
+}
+
+// PluginRun is the SinkRun sent to sink plugins
+type PluginRun struct {
+	OutputDir string `json:"output_dir"`
+	Namespace string `json:"namespace,omitempty"`
+	RunID     string `json:"run_id"`
+	Previous  string `json:"previous,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+}
+
+// pluginProcess is a running plugin
+type pluginProcess struct {
+	manifest PluginManifest
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   io.ReadCloser
+	enc      *json.Encoder
+	dec      *json.Decoder
+
+	mu   sync.Mutex
+	next int
+	// failed is set once a call timed out or was cancelled and the plugin
+	// was killed, later calls fail with it
+	failed error
+}
+
+// startPlugin runs the plugin at path and checks that it speaks the protocol
+// and implements kind
+func startPlugin(ctx context.Context, path string, args []string, kind string) (*pluginProcess, error) {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = append(os.Environ(), pluginProtocolEnv+"="+strconv.Itoa(PluginProtocol))
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting plugin %s: %w", path, err)
+	}
+
+	p := &pluginProcess{cmd: cmd, stdin: stdin, stdout: stdout, enc: json.NewEncoder(stdin), dec: json.NewDecoder(stdout)}
+	if err := p.call(ctx, PluginMethodInfo, nil, &p.manifest); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	if p.manifest.Name == "" {
+		p.manifest.Name = filepath.Base(path)
+	}
+	if p.manifest.Protocol != PluginProtocol {
+		p.Close()
+		return nil, fmt.Errorf("plugin %s speaks protocol %d, want %d", p.manifest.Name, p.manifest.Protocol, PluginProtocol)
+	}
+	for _, k := range p.manifest.Kinds {
+		if k == kind {
+			return p, nil
+		}
+	}
+	p.Close()
+	return nil, fmt.Errorf("plugin %s is not a %s", p.manifest.Name, kind)
+}
+
+// call sends a request and decodes its result into result, killing the
+// plugin when ctx is done or it does not answer within the timeout of method
+func (p *pluginProcess) call(ctx context.Context, method string, params, result any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failed != nil {
+		return p.failed
+	}
+
+	timeout := pluginCallTimeout
+	if method == PluginMethodFetch || method == PluginMethodWrite {
+		timeout = pluginBatchTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// children of the plugin may keep stdout open, so it is closed too
+	stop := context.AfterFunc(ctx, func() {
+		p.cmd.Process.Kill()
+		p.stdout.Close()
+	})
+	defer stop()
+	fail := func(err error) error {
+		if ctx.Err() != nil {
+			p.failed = fmt.Errorf("%s: plugin killed: %w", method, ctx.Err())
+			return p.failed
+		}
+		return err
+	}
+
+	p.next++
+	req := PluginRequest{ID: p.next, Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		req.Params = raw
+	}
+	if err := p.enc.Encode(req); err != nil {
+		return fail(fmt.Errorf("error sending %s: %w", method, err))
+	}
+
+	var resp PluginResponse
+	if err := p.dec.Decode(&resp); err != nil {
+		return fail(fmt.Errorf("error reading %s response: %w", method, err))
+	}
+	if resp.ID != req.ID {
+		return fmt.Errorf("%s response has id %d, want %d", method, resp.ID, req.ID)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("%s: %s", method, resp.Error)
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+// Close closes the stdin of the plugin and waits for it to exit
+func (p *pluginProcess) Close() error {
+	p.stdin.Close()
+	return p.cmd.Wait()
+}
+
+// pluginSource fetches the icons of a source plugin, run once per fetch
+type pluginSource struct {
+	cfg SourceConfig
+}
+
+func (s *pluginSource) Name() string { return sourceName(s.cfg) }
+
+func (s *pluginSource) Fetch(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	p, err := startPlugin(ctx, s.cfg.Plugin, s.cfg.PluginArgs, PluginKindSource)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	var pending []PendingIcon
+	if err := p.call(ctx, PluginMethodFetch, PluginFetchParams{URL: s.cfg.URL}, &pending); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.manifest.Name, err)
+	}
+	return pending, nil
+}
+
+// PluginExtender is an Extender plugin, kept running between icons. Close
+// stops it once the runs are done. A plugin that does not answer a call in
+// time is killed and fails the remaining icons
+type PluginExtender struct {
+	p *pluginProcess
+}
+
+// NewPluginExtender starts the extender plugin at path, register it with
+// RegisterExtender
+func NewPluginExtender(path string, args ...string) (*PluginExtender, error) {
+	// the plugin outlives any single run, calls are bound by the run instead
+	p, err := startPlugin(context.Background(), path, args, PluginKindExtender)
+	if err != nil {
+		return nil, err
+	}
+	return &PluginExtender{p: p}, nil
+}
+
+func (e *PluginExtender) Name() string { return e.p.manifest.Name }
+
+func (e *PluginExtender) Extend(icon *IconPayload) error {
+	return e.ExtendContext(context.Background(), icon)
+}
+
+func (e *PluginExtender) ExtendContext(ctx context.Context, icon *IconPayload) error {
+	var extensions map[string]any
+	if err := e.p.call(ctx, PluginMethodExtend, icon, &extensions); err != nil {
+		return err
+	}
+	for key, value := range extensions {
+		icon.SetExtension(key, value)
+	}
+	return nil
+}
+
+// Close stops the plugin
+func (e *PluginExtender) Close() error {
+	return e.p.Close()
+}
+
+// PluginSink delivers the icons of a run to a sink plugin, started for every
+// run
+type PluginSink struct {
+	Path string
+	Args []string
+}
+
+func (s *PluginSink) Name() string { return "plugin:" + filepath.Base(s.Path) }
+
+func (s *PluginSink) Write(ctx context.Context, run *SinkRun, icons []*IconPayload) error {
+	p, err := startPlugin(ctx, s.Path, s.Args, PluginKindSink)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	params := PluginWriteParams{
+		Run: PluginRun{
+			OutputDir: run.OutputDir,
+			Namespace: run.Namespace,
+			RunID:     run.RunID,
+			Previous:  run.Previous,
+			Provider:  run.Provider,
+		},
+		Icons: icons,
+	}
+	if err := p.call(ctx, PluginMethodWrite, params, nil); err != nil {
+		return fmt.Errorf("plugin %s: %w", p.manifest.Name, err)
+	}
+	return nil
+}
+
+// PluginServer serves the parts a plugin implements, unset parts are not
+// advertised
+type PluginServer struct {
+	Name     string
+	Source   Source
+	Extender Extender
+	Sink     Sink
+	// Config is passed to Source and Sink, DefaultConfig when nil
+	Config *Config
+}
+
+// ServePlugin serves s on stdin and stdout until stdin is closed, plugin
+// executables call it from main. Logs go to stderr, stdout belongs to the
+// protocol
+func ServePlugin(s *PluginServer) {
+	if err := s.Serve(context.Background(), os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("❌ Plugin %s: %v", s.Name, err)
+	}
+}
+
+// Serve answers the requests read from r on w until r is closed
+func (s *PluginServer) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	cfg := s.Config
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for {
+		var req PluginRequest
+		if err := dec.Decode(&req); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		resp := PluginResponse{ID: req.ID}
+		result, err := s.handle(ctx, cfg, req)
+		if err == nil {
+			resp.Result, err = json.Marshal(result)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// handle runs the method of req
+func (s *PluginServer) handle(ctx context.Context, cfg *Config, req PluginRequest) (any, error) {
+	switch {
+	case req.Method == PluginMethodInfo:
+		return s.manifest(), nil
+
+	case req.Method == PluginMethodFetch && s.Source != nil:
+		var params PluginFetchParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		c := *cfg
+		if params.URL != "" {
+			c.SourceURL = params.URL
+		}
+		return s.Source.Fetch(ctx, &c)
+
+	case req.Method == PluginMethodExtend && s.Extender != nil:
+		var icon IconPayload
+		if err := unmarshalParams(req.Params, &icon); err != nil {
+			return nil, err
+		}
+		if err := extend(ctx, s.Extender, &icon); err != nil {
+			return nil, err
+		}
+		return icon.Extensions, nil
+
+	case req.Method == PluginMethodWrite && s.Sink != nil:
+		var params PluginWriteParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		run := &SinkRun{
+			OutputDir: params.Run.OutputDir,
+			Namespace: params.Run.Namespace,
+			RunID:     params.Run.RunID,
+			Previous:  params.Run.Previous,
+			Provider:  params.Run.Provider,
+			Config:    cfg,
+		}
+		return nil, s.Sink.Write(withRunClient(ctx, cfg.client()), run, params.Icons)
+
+	default:
+		return nil, fmt.Errorf("unsupported method %q", req.Method)
+	}
+}
+
+// manifest lists the kinds s implements
+func (s *PluginServer) manifest() PluginManifest {
+	m := PluginManifest{Name: s.Name, Protocol: PluginProtocol, Kinds: []string{}}
+	if s.Source != nil {
+		m.Kinds = append(m.Kinds, PluginKindSource)
+	}
+	if s.Extender != nil {
+		m.Kinds = append(m.Kinds, PluginKindExtender)
+	}
+	if s.Sink != nil {
+		m.Kinds = append(m.Kinds, PluginKindSink)
+	}
+	return m
+}
+
+func unmarshalParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid params: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package icons
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const profilesDir = "profiles"
+
+// OutputProfile selects the fields of an additional copy of the corpus, e.g.
+// a minimal bundle for a public CDN without internal enrichment fields
+type OutputProfile struct {
+	// Name identifies the profile and its default directory
+	Name string `yaml:"name"`
+	// Fields lists the emitted JSON fields, empty emits every field
+	Fields []string `yaml:"fields,omitempty"`
+	// Redact lists JSON fields dropped from the output
+	Redact []string `yaml:"redact,omitempty"`
+	// Dir is where the profile is written relative to OutputDir, defaulting
+	// to profiles/<name>
+	Dir string `yaml:"dir,omitempty"`
+	// Approval lists the approval statuses of the icons written, empty
+	// writes every icon. Production profiles keep auto and approved icons
+	Approval []string `yaml:"approval,omitempty"`
+}
+
+// Built-in output profiles
+var (
+	// MinimalProfile holds what a frontend needs to render an icon
+	MinimalProfile = OutputProfile{Name: "minimal", Fields: []string{"slug", "display_name", "url", "color_theme"}}
+	// FullProfile holds every field, for RAG ingestion
+	FullProfile = OutputProfile{Name: "full"}
+)
+
+// iconFields returns the JSON field names of IconPayload
+func iconFields() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(IconPayload{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// validateProfiles rejects unnamed profiles, unknown fields and directories
+// outside the output directory
+func validateProfiles(profiles []OutputProfile) error {
+	known := iconFields()
+	names := make(map[string]bool)
+	for _, p := range profiles {
+		if p.Name == "" {
+			return fmt.Errorf("output profile without name")
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate output profile %q", p.Name)
+		}
+		names[p.Name] = true
+		for _, field := range append(append([]string{}, p.Fields...), p.Redact...) {
+			if !known[field] && !strings.HasPrefix(field, extensionPrefix) {
+				return fmt.Errorf("output profile %s: unknown field %q", p.Name, field)
+			}
+		}
+		for _, status := range p.Approval {
+			if err := validApproval(status); err != nil {
+				return fmt.Errorf("output profile %s: %w", p.Name, err)
+			}
+		}
+		if !filepath.IsLocal(p.dir()) {
+			return fmt.Errorf("output profile %s: directory %q is outside the output directory", p.Name, p.Dir)
+		}
+	}
+	return nil
+}
+
+// dir returns the profile directory relative to the output directory
+func (p OutputProfile) dir() string {
+	if p.Dir != "" {
+		return filepath.Clean(p.Dir)
+	}
+	return filepath.Join(profilesDir, p.Name)
+}
+
+// keeps reports whether the profile writes icon, icons without an approval
+// status count as auto
+func (p OutputProfile) keeps(icon *IconPayload) bool {
+	if len(p.Approval) == 0 {
+		return true
+	}
+	status := icon.ApprovalStatus
+	if status == "" {
+		status = ApprovalAuto
+	}
+	return slices.Contains(p.Approval, status)
+}
+
+// project returns the fields of icon kept by the profile
+func (p OutputProfile) project(icon *IconPayload) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(icon)
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	if len(p.Fields) > 0 {
+		kept := make(map[string]json.RawMessage, len(p.Fields))
+		for _, field := range p.Fields {
+			if v, ok := all[field]; ok {
+				kept[field] = v
+			}
+		}
+		all = kept
+	}
+	for _, field := range p.Redact {
+		delete(all, field)
+	}
+	return all, nil
+}
+
+// writeProfiles writes the corpus once per profile with the selected fields
+func writeProfiles(cfg *Config, icons []*IconPayload) error {
+	for _, p := range cfg.Profiles {
+		docs := make([]map[string]json.RawMessage, 0, len(icons))
+		for _, icon := range icons {
+			if !p.keeps(icon) {
+				continue
+			}
+			doc, err := p.project(icon)
+			if err != nil {
+				return fmt.Errorf("error projecting %s for profile %s: %w", icon.Slug, p.Name, err)
+			}
+			docs = append(docs, doc)
+		}
+
+		dir := filepath.Join(cfg.OutputDir, p.dir())
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, jsonFile)
+		if err := cfg.writeJSON(path, docs); err != nil {
+			return fmt.Errorf("failed to write profile %s: %w", p.Name, err)
+		}
+		log.Printf("🗂️  Profile %s: %s, %d of %d icons", p.Name, path, len(docs), len(icons))
+	}
+	return nil
+}
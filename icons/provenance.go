@@ -0,0 +1,41 @@
+package icons
+
+// Provenance sources recorded per field
+const (
+	SourceScraper  = "scraper"
+	SourceLLM      = "llm"
+	SourceRules    = "rules"
+	SourceIconify  = "iconify"
+	SourceOverride = "override"
+	SourceUsage    = "usage"
+)
+
+// FieldProvenance records which component produced a field and when
+type FieldProvenance struct {
+	Source string `json:"source"`
+	At     string `json:"at"`
+	// Confidence is how sure the enricher was of the field, from 0 to 1, 0
+	// when it did not say
+	Confidence float32 `json:"confidence,omitempty"`
+}
+
+// setProvenance records source as the producer of fields at timestamp
+func (p *IconPayload) setProvenance(source, at string, fields ...string) {
+	if p.Provenance == nil {
+		p.Provenance = make(map[string]FieldProvenance)
+	}
+	for _, field := range fields {
+		p.Provenance[field] = FieldProvenance{Source: source, At: at}
+	}
+}
+
+// setConfidence records the confidence of the producer of field, clamped so
+// a reported 0 does not read as unknown
+func (p *IconPayload) setConfidence(field string, confidence float32) {
+	prov, ok := p.Provenance[field]
+	if !ok {
+		return
+	}
+	prov.Confidence = min(max(confidence, 0.01), 1)
+	p.Provenance[field] = prov
+}
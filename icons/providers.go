@@ -0,0 +1,131 @@
+package icons
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Provider describes an icon provider
+type Provider struct {
+	Key         string `json:"key"`
+	DisplayName string `json:"display_name"`
+	Homepage    string `json:"homepage,omitempty"`
+	ColorScheme string `json:"color_scheme,omitempty"`
+	Dir         string `json:"dir"`
+}
+
+// ProviderRegistry is the single source of truth for provider names
+type ProviderRegistry struct {
+	mu        sync.RWMutex
+	byKey     map[string]*Provider
+	byDisplay map[string]*Provider
+}
+
+// Providers is the registry used by the generator, extend it with Register
+var Providers = NewProviderRegistry(
+	Provider{Key: "aws", DisplayName: "Amazon Web Services", Homepage: "https://aws.amazon.com", ColorScheme: "#FF9900"},
+	Provider{Key: "azure", DisplayName: "Microsoft Azure", Homepage: "https://azure.microsoft.com", ColorScheme: "#0078D4"},
+	Provider{Key: "gcp", DisplayName: "Google Cloud Platform", Homepage: "https://cloud.google.com", ColorScheme: "#4285F4"},
+	Provider{Key: "essentials", DisplayName: "Essential Icons", Homepage: sourceURL},
+	Provider{Key: "dev", DisplayName: "Development Tools", Homepage: sourceURL},
+	Provider{Key: "infra", DisplayName: "Infrastructure", Homepage: sourceURL},
+	Provider{Key: "tech", DisplayName: "Technology", Homepage: sourceURL},
+	Provider{Key: "social", DisplayName: "Social Media", Homepage: sourceURL},
+	Provider{Key: "emotions", DisplayName: "Emojis", Homepage: sourceURL},
+	Provider{Key: "stack", DisplayName: "Languages & Frameworks", Homepage: "https://devicon.dev"},
+	Provider{Key: "products", DisplayName: "Databases & Brokers", Homepage: "https://devicon.dev"},
+	Provider{Key: "saas", DisplayName: "SaaS & Observability", Homepage: "https://simpleicons.org"},
+	Provider{Key: "cncf", DisplayName: "CNCF Landscape", Homepage: "https://landscape.cncf.io", ColorScheme: "#0086FF"},
+)
+
+// NewProviderRegistry returns a registry holding providers
+func NewProviderRegistry(providers ...Provider) *ProviderRegistry {
+	r := &ProviderRegistry{
+		byKey:     make(map[string]*Provider),
+		byDisplay: make(map[string]*Provider),
+	}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register adds or replaces a provider, Dir defaults to Key
+func (r *ProviderRegistry) Register(p Provider) {
+	p.Key = strings.ToLower(p.Key)
+	if p.Dir == "" {
+		p.Dir = p.Key
+	}
+	if p.DisplayName == "" {
+		p.DisplayName = p.Key
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.byKey[p.Key]; ok {
+		delete(r.byDisplay, old.DisplayName)
+	}
+	r.byKey[p.Key] = &p
+	r.byDisplay[p.DisplayName] = &p
+}
+
+// Lookup returns the provider registered under key
+func (r *ProviderRegistry) Lookup(key string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.byKey[strings.ToLower(key)]
+	if !ok {
+		return Provider{}, false
+	}
+	return *p, true
+}
+
+// ByDisplayName returns the provider with the given display name
+func (r *ProviderRegistry) ByDisplayName(name string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.byDisplay[name]
+	if !ok {
+		return Provider{}, false
+	}
+	return *p, true
+}
+
+// Resolve returns the provider for a scraped category, registering unknown
+// categories with a warning
+func (r *ProviderRegistry) Resolve(category string) Provider {
+	if p, ok := r.Lookup(category); ok {
+		return p
+	}
+
+	key := strings.ToLower(category)
+	if key == "" {
+		return Provider{}
+	}
+	log.Printf("⚠️  Unknown provider %q, registering it", key)
+	r.Register(Provider{Key: key, DisplayName: strings.ToUpper(key[:1]) + key[1:]})
+	p, _ := r.Lookup(key)
+	return p
+}
+
+// All returns the registered providers sorted by key
+func (r *ProviderRegistry) All() []Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	providers := make([]Provider, 0, len(r.byKey))
+	for _, p := range r.byKey {
+		providers = append(providers, *p)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Key < providers[j].Key })
+	return providers
+}
+
+// providerDir returns the output directory name for a provider display name
+func providerDir(displayName string) string {
+	if p, ok := Providers.ByDisplayName(displayName); ok {
+		return p.Dir
+	}
+	return strings.ToLower(displayName)
+}
@@ -0,0 +1,121 @@
+package icons
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+const (
+	qualityFile  = "quality_report.json"
+	worstIcons   = 25
+	qualityScale = 100
+)
+
+var hexColorRgx = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// qualityCheck is a weighted quality criterion
+type qualityCheck struct {
+	Issue  string
+	Weight float32
+	Pass   func(icon *IconPayload, collided map[string]bool) bool
+}
+
+var qualityChecks = []qualityCheck{
+	{"unverified_iconify_id", 0.2, func(i *IconPayload, _ map[string]bool) bool {
+		return i.Provenance["iconify_id"].Source == SourceIconify || i.Provenance["iconify_id"].Source == SourceOverride
+	}},
+	{"missing_semantic_profile", 0.15, func(i *IconPayload, _ map[string]bool) bool { return i.SemanticProfile != "" }},
+	{"missing_technical_intent", 0.15, func(i *IconPayload, _ map[string]bool) bool { return i.TechnicalIntent != "" }},
+	{"missing_tags", 0.1, func(i *IconPayload, _ map[string]bool) bool { return len(jsonToArray(i.Tags)) > 0 }},
+	{"missing_aliases", 0.1, func(i *IconPayload, _ map[string]bool) bool { return len(jsonToArray(i.Aliases)) > 0 }},
+	{"invalid_color", 0.1, func(i *IconPayload, _ map[string]bool) bool { return hexColorRgx.MatchString(i.ColorTheme) }},
+	{"dead_url", 0.1, func(i *IconPayload, _ map[string]bool) bool { return i.ContentSHA256 != "" }},
+	{"colliding_slug", 0.1, func(i *IconPayload, collided map[string]bool) bool { return !collided[i.Slug] }},
+}
+
+// QualityOffender is a low scoring icon in the quality report
+type QualityOffender struct {
+	Slug   string   `json:"slug"`
+	Score  float32  `json:"score"`
+	Issues []string `json:"issues"`
+}
+
+// QualityReport aggregates icon quality scores
+type QualityReport struct {
+	Total     int               `json:"total"`
+	Average   float32           `json:"average"`
+	Min       float32           `json:"min"`
+	Histogram map[string]int    `json:"histogram"`
+	Issues    map[string]int    `json:"issues"`
+	Worst     []QualityOffender `json:"worst"`
+}
+
+// scoreQuality scores every icon in [0, 1] and returns the corpus report,
+// collided holds slugs involved in a collision
+func scoreQuality(icons []*IconPayload, collided map[string]bool) QualityReport {
+	report := QualityReport{
+		Total:     len(icons),
+		Min:       1,
+		Histogram: map[string]int{},
+		Issues:    map[string]int{},
+		Worst:     []QualityOffender{},
+	}
+	if len(icons) == 0 {
+		report.Min = 0
+		return report
+	}
+
+	var sum float32
+	offenders := make([]QualityOffender, 0, len(icons))
+	for _, icon := range icons {
+		var score float32
+		var issues []string
+		for _, check := range qualityChecks {
+			if check.Pass(icon, collided) {
+				score += check.Weight
+				continue
+			}
+			issues = append(issues, check.Issue)
+			report.Issues[check.Issue]++
+		}
+		score = float32(int(score*qualityScale+0.5)) / qualityScale
+
+		icon.QualityScore = score
+		icon.QualityIssues = issues
+		sum += score
+		if score < report.Min {
+			report.Min = score
+		}
+		bucket := int(score * 10)
+		if bucket > 9 {
+			bucket = 9
+		}
+		report.Histogram[fmt.Sprintf("%.1f-%.1f", float32(bucket)/10, float32(bucket+1)/10)]++
+		offenders = append(offenders, QualityOffender{Slug: icon.Slug, Score: score, Issues: issues})
+	}
+	report.Average = float32(int(sum/float32(len(icons))*qualityScale+0.5)) / qualityScale
+
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].Score != offenders[j].Score {
+			return offenders[i].Score < offenders[j].Score
+		}
+		return offenders[i].Slug < offenders[j].Slug
+	})
+	if len(offenders) > worstIcons {
+		offenders = offenders[:worstIcons]
+	}
+	report.Worst = offenders
+	return report
+}
+
+// collidedSlugs returns every slug involved in a collision
+func collidedSlugs(collisions []SlugCollision) map[string]bool {
+	collided := make(map[string]bool)
+	for _, c := range collisions {
+		for _, slug := range c.Resolved {
+			collided[slug] = true
+		}
+	}
+	return collided
+}
@@ -0,0 +1,189 @@
+package icons
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	queriesFile = "queries.jsonl"
+	// queryBatch is the number of icons per request to the LLM service
+	queryBatch = 20
+)
+
+// QueryPair is a user query and the icon it should retrieve, a line of
+// queries.jsonl
+type QueryPair struct {
+	Query string `json:"query"`
+	Slug  string `json:"slug"`
+	// Source is llm or rules
+	Source string `json:"source"`
+}
+
+// queriesRequest asks the LLM service for N queries per icon
+type queriesRequest struct {
+	N     int         `json:"n"`
+	Icons []queryIcon `json:"icons"`
+}
+
+// queryIcon is an icon of a queriesRequest
+type queryIcon struct {
+	Slug            string   `json:"slug"`
+	Provider        string   `json:"provider"`
+	DisplayName     string   `json:"display_name"`
+	TechnicalIntent string   `json:"technical_intent,omitempty"`
+	Aliases         []string `json:"aliases,omitempty"`
+}
+
+// queriesResponse holds the queries of every requested icon, in order
+type queriesResponse struct {
+	Results [][]string `json:"results"`
+}
+
+// writeQueries generates cfg.SyntheticQueries queries per icon into
+// queries.jsonl, from the LLM service when it is available and from the
+// fields of the icon otherwise
+func writeQueries(cfg *Config, icons []*IconPayload) error {
+	n := cfg.SyntheticQueries
+	if n <= 0 {
+		return nil
+	}
+	path := filepath.Join(cfg.OutputDir, queriesFile)
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	e := json.NewEncoder(w)
+	e.SetEscapeHTML(false)
+	var pairs, fromLLM int
+	for i := 0; i < len(icons); i += queryBatch {
+		end := i + queryBatch
+		if end > len(icons) {
+			end = len(icons)
+		}
+		batch := icons[i:end]
+
+		var generated [][]string
+		if llmServiceAvailable {
+			if generated, err = llmQueries(cfg.client(), batch, n); err != nil {
+				log.Printf("⚠️  Query generation for icons %d-%d fell back to rules: %v", i+1, end, err)
+				generated = nil
+			}
+		}
+		for j, icon := range batch {
+			source, queries := SourceLLM, []string(nil)
+			if generated != nil {
+				queries = cleanQueries(generated[j], n)
+			}
+			if len(queries) == 0 {
+				source, queries = SourceRules, heuristicQueries(icon, n)
+			} else {
+				fromLLM++
+			}
+			for _, q := range queries {
+				if err := e.Encode(QueryPair{Query: q, Slug: icon.Slug, Source: source}); err != nil {
+					return err
+				}
+				pairs++
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	log.Printf("🗣️  Wrote %d synthetic queries to %s, %d of %d icons from the LLM", pairs, path, fromLLM, len(icons))
+	return nil
+}
+
+// llmQueries asks the LLM service for n queries for every icon of batch
+func llmQueries(client *http.Client, batch []*IconPayload, n int) ([][]string, error) {
+	body := queriesRequest{N: n, Icons: make([]queryIcon, len(batch))}
+	for i, icon := range batch {
+		body.Icons[i] = queryIcon{
+			Slug:            icon.Slug,
+			Provider:        icon.Provider,
+			DisplayName:     icon.DisplayName,
+			TechnicalIntent: icon.TechnicalIntent,
+			Aliases:         jsonToArray(icon.Aliases),
+		}
+	}
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", llmQueriesURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LLM queries service returned %s", resp.Status)
+	}
+
+	var queries queriesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&queries); err != nil {
+		return nil, err
+	}
+	if len(queries.Results) != len(batch) {
+		return nil, fmt.Errorf("LLM queries service returned %d results for %d icons", len(queries.Results), len(batch))
+	}
+	return queries.Results, nil
+}
+
+// heuristicQueries derives up to n queries from the name, aliases, intent
+// and tags of icon
+func heuristicQueries(icon *IconPayload, n int) []string {
+	name := strings.ToLower(icon.DisplayName)
+	candidates := []string{name}
+	candidates = append(candidates, jsonToArray(icon.Aliases)...)
+	if intent := strings.ToLower(strings.TrimSuffix(icon.TechnicalIntent, ".")); intent != "" {
+		candidates = append(candidates, intent)
+	}
+	if p, ok := Providers.ByDisplayName(icon.Provider); ok && p.Key != "" && !strings.Contains(name, p.Key) {
+		candidates = append(candidates, name+" on "+p.Key, p.Key+" "+name+" icon")
+	}
+	if tags := jsonToArray(icon.Tags); len(tags) > 1 {
+		candidates = append(candidates, strings.Join(tags[:2], " "))
+	}
+	return cleanQueries(candidates, n)
+}
+
+// cleanQueries lower cases and trims queries, dropping empty and duplicate
+// ones, and keeps the first n
+func cleanQueries(queries []string, n int) []string {
+	seen := make(map[string]bool, len(queries))
+	cleaned := make([]string, 0, n)
+	for _, q := range queries {
+		q = strings.Join(strings.Fields(strings.ToLower(q)), " ")
+		if q == "" || seen[q] {
+			continue
+		}
+		seen[q] = true
+		cleaned = append(cleaned, q)
+		if len(cleaned) == n {
+			break
+		}
+	}
+	return cleaned
+}
@@ -0,0 +1,228 @@
+package icons
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// GenerateProvider rescrapes and re-enriches the icons of a single provider,
+// e.g. "aws", and replaces them in the existing output of cfg, keeping the
+// icons of every other provider. The corpus stages such as families and the
+// quality gates still run over the whole corpus. Sinks storing single icons
+// only upsert and tombstone the icons of the provider
+func GenerateProvider(ctx context.Context, provider string, cfg *Config) (report *RunReport, err error) {
+	defer servePprof(cfg.PprofAddr)()
+	p, ok := Providers.Lookup(provider)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", provider)
+	}
+	if err := startRun(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	run, err := openRun(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil && run.staging != "" {
+			log.Printf("⚠️  Kept previous output, partial run left in %s", run.staging)
+		}
+	}()
+	cfg = run.cfg
+
+	existing, err := LoadDataset(run.root)
+	if err != nil {
+		return nil, fmt.Errorf("no output to update in %s: %w", run.root, err)
+	}
+	// start from the previous output so assets of other providers survive
+	if err := seedRun(filepath.Dir(run.previous), cfg.OutputDir, p.Dir); err != nil {
+		return nil, fmt.Errorf("error copying previous output: %w", err)
+	}
+
+	report = run.report()
+	report.Provider = p.DisplayName
+	mark := stageClock.start()
+	collected, err := collectPending(ctx, cfg, report)
+	if err != nil {
+		return report, err
+	}
+	var pendingIcons []PendingIcon
+	for _, pending := range collected {
+		if Providers.Resolve(pending.Category).Dir == p.Dir {
+			pendingIcons = append(pendingIcons, pending)
+		}
+	}
+	if len(pendingIcons) == 0 {
+		return report, fmt.Errorf("sources returned no icons of provider %s", p.Key)
+	}
+	stageClock.done(stageFetch, mark, len(pendingIcons))
+	log.Printf("🎯 Regenerating %d icons of %s", len(pendingIcons), p.DisplayName)
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	fresh, err := enrichPending(ctx, cfg, pendingIcons, timestamp)
+	if err != nil {
+		if ctx.Err() != nil && len(fresh) > 0 {
+			if ferr := run.flushPartial(report, pendingIcons, fresh); ferr != nil {
+				log.Printf("⚠️  Failed to flush partial output: %v", ferr)
+			}
+		}
+		return report, err
+	}
+
+	// the fresh icons take the place of the old ones to keep the corpus order
+	allIcons := make([]*IconPayload, 0, len(existing.Icons)+len(fresh))
+	spliced := false
+	for _, icon := range existing.Icons {
+		if providerDir(icon.Provider) != p.Dir {
+			allIcons = append(allIcons, icon)
+		} else if !spliced {
+			allIcons = append(allIcons, fresh...)
+			spliced = true
+		}
+	}
+	if !spliced {
+		allIcons = append(allIcons, fresh...)
+	}
+	kept := len(allIcons) - len(fresh)
+	if err := postProcess(ctx, cfg, allIcons, fresh, timestamp); err != nil {
+		return report, err
+	}
+
+	mark = stageClock.start()
+	if err := writeOutputs(ctx, cfg, allIcons, run.previous); err != nil {
+		return report, err
+	}
+	stageClock.done(stageWrite, mark, len(allIcons))
+
+	if err := runSinks(ctx, cfg, report, allIcons, &SinkRun{Previous: run.previous, Provider: p.DisplayName}); err != nil {
+		return report, err
+	}
+
+	if err := report.finish(allIcons); err != nil {
+		return report, err
+	}
+	if err := run.publish(report); err != nil {
+		return report, err
+	}
+
+	log.Printf("✅ Regenerated %d icons of %s, kept %d of other providers", len(fresh), p.DisplayName, kept)
+	return report, nil
+}
+
+// updateOutput loads the existing output of cfg into a new run, applies
+// update to its icons without scraping, runs the corpus stages and publishes
+// the result like any other run
+func updateOutput(ctx context.Context, cfg *Config, update func(ctx context.Context, report *RunReport, icons []*IconPayload, timestamp string) error) (report *RunReport, err error) {
+	defer servePprof(cfg.PprofAddr)()
+	// nothing is fetched, so there is nothing to preflight
+	c := *cfg
+	c.Preflight = false
+	if err := startRun(ctx, &c); err != nil {
+		return nil, err
+	}
+
+	run, err := openRun(&c)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil && run.staging != "" {
+			log.Printf("⚠️  Kept previous output, partial run left in %s", run.staging)
+		}
+	}()
+	cfg = run.cfg
+
+	existing, err := LoadDataset(run.root)
+	if err != nil {
+		return nil, fmt.Errorf("no output to update in %s: %w", run.root, err)
+	}
+	if err := seedRun(filepath.Dir(run.previous), cfg.OutputDir, ""); err != nil {
+		return nil, fmt.Errorf("error copying previous output: %w", err)
+	}
+	report = run.report()
+	allIcons := existing.Icons
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	if err := update(ctx, report, allIcons, timestamp); err != nil {
+		return report, err
+	}
+	if err := postProcess(ctx, cfg, allIcons, nil, timestamp); err != nil {
+		return report, err
+	}
+
+	mark := stageClock.start()
+	if err := writeOutputs(ctx, cfg, allIcons, run.previous); err != nil {
+		return report, err
+	}
+	stageClock.done(stageWrite, mark, len(allIcons))
+
+	if err := runSinks(ctx, cfg, report, allIcons, &SinkRun{Previous: run.previous}); err != nil {
+		return report, err
+	}
+
+	if err := report.finish(allIcons); err != nil {
+		return report, err
+	}
+	if err := run.publish(report); err != nil {
+		return report, err
+	}
+	log.Printf("✅ Updated %d icons in %s", len(allIcons), run.root)
+	return report, nil
+}
+
+// seedRun copies the previous output in src to the run directory dst, except
+// the directory of the regenerated provider and the files of the run itself
+func seedRun(src, dst, skipDir string) error {
+	src, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel == skipDir {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dst, rel), 0750)
+		}
+		switch rel {
+		case runReportFile, checkpointFile, checksumsFile, signatureFile:
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, filepath.Join(dst, rel))
+	})
+}
+
+// copyFile copies the regular file src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(filepath.Clean(dst), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
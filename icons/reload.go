@@ -0,0 +1,209 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DatasetVersion identifies the corpus a LiveDataset serves
+type DatasetVersion struct {
+	// StartedAt and ContentSHA256 come from the run report of the corpus,
+	// empty without one
+	StartedAt     string    `json:"started_at,omitempty"`
+	ContentSHA256 string    `json:"content_sha256,omitempty"`
+	Icons         int       `json:"icons"`
+	LoadedAt      time.Time `json:"loaded_at"`
+}
+
+// LiveDataset serves the handlers of the latest dataset of an output
+// directory. Reload swaps in a new dataset and its handlers atomically once
+// a run wrote a new corpus, requests in flight finish on the previous one
+type LiveDataset struct {
+	dir   string
+	build func(*Dataset) http.Handler
+
+	current atomic.Pointer[liveState]
+	// fingerprint is the state of the files of the current dataset and
+	// failed that of the last files that did not load, only read and
+	// written by Reload
+	fingerprint string
+	failed      string
+	// usagePath is where the usage hits are saved, empty to keep them in
+	// memory only; saveMu serializes the saves
+	usagePath string
+	saveMu    sync.Mutex
+}
+
+// liveState is a loaded dataset with its handlers
+type liveState struct {
+	d       *Dataset
+	version DatasetVersion
+	handler http.Handler
+}
+
+// NewLiveDataset loads the dataset of dir and builds its handlers with build
+func NewLiveDataset(dir string, build func(*Dataset) http.Handler) (*LiveDataset, error) {
+	l := &LiveDataset{dir: dir, build: build}
+	if _, err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *LiveDataset) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.current.Load().handler.ServeHTTP(w, r)
+}
+
+// Dataset returns the dataset served now
+func (l *LiveDataset) Dataset() *Dataset { return l.current.Load().d }
+
+// Version returns the version of the dataset served now
+func (l *LiveDataset) Version() DatasetVersion { return l.current.Load().version }
+
+// Reload loads the dataset of the directory when its files changed since the
+// last load, reporting whether it swapped datasets. A corpus that changes
+// while it is read is left for the next call, as a run is still writing it,
+// and one that failed to load is only tried again once it changes. Calls
+// must not overlap
+func (l *LiveDataset) Reload() (bool, error) {
+	before, err := datasetFingerprint(l.dir)
+	if err != nil {
+		return false, err
+	}
+	if l.current.Load() != nil && (before == l.fingerprint || before == l.failed) {
+		return false, nil
+	}
+	d, err := LoadDataset(l.dir)
+	if err != nil {
+		l.failed = before
+		return false, err
+	}
+	version := DatasetVersion{Icons: len(d.Icons), LoadedAt: time.Now().UTC()}
+	report, err := loadRunReport(resolveLatest(l.dir))
+	if err != nil {
+		l.failed = before
+		return false, err
+	}
+	if report != nil {
+		version.StartedAt, version.ContentSHA256 = report.StartedAt, report.ContentSHA256
+	}
+	after, err := datasetFingerprint(l.dir)
+	if err != nil {
+		return false, err
+	}
+	if after != before {
+		return false, fmt.Errorf("%s changed while loading it", l.dir)
+	}
+
+	// hits recorded on the previous dataset carry over to the new one
+	if prev := l.current.Load(); prev != nil {
+		d.MergeUsage(prev.d.Usage())
+	}
+	l.current.Store(&liveState{d: d, version: version, handler: l.build(d)})
+	l.fingerprint = after
+	if err := l.SaveUsage(); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+	return true, nil
+}
+
+// PersistUsage merges the usage hits saved in path into the dataset served
+// now, then saves the hits recorded since back to path on every reload and
+// on SaveUsage, so the next run learns popularity from them. Call it before
+// Watch
+func (l *LiveDataset) PersistUsage(path string) error {
+	stats, err := LoadUsage(path)
+	if err != nil {
+		return err
+	}
+	l.Dataset().MergeUsage(stats)
+	l.usagePath = path
+	return nil
+}
+
+// SaveUsage writes the usage hits of the dataset served now to the file of
+// PersistUsage, doing nothing without one
+func (l *LiveDataset) SaveUsage() error {
+	if l.usagePath == "" {
+		return nil
+	}
+	l.saveMu.Lock()
+	defer l.saveMu.Unlock()
+	if err := l.Dataset().Usage().Save(l.usagePath); err != nil {
+		return fmt.Errorf("error saving usage %s: %w", l.usagePath, err)
+	}
+	return nil
+}
+
+// Watch calls Reload every interval until ctx is done, serving the previous
+// dataset when a reload fails
+func (l *LiveDataset) Watch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		swapped, err := l.Reload()
+		switch {
+		case err != nil:
+			log.Printf("⚠️  Reloading %s failed, still serving the previous dataset: %v", l.dir, err)
+		case swapped:
+			v := l.Version()
+			log.Printf("🔄 Reloaded %d icons from %s, run started at %s", v.Icons, l.dir, v.StartedAt)
+		}
+	}
+}
+
+// HealthHandler answers the version of the dataset served now as JSON
+func (l *LiveDataset) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "ok", "dataset": l.Version()})
+	})
+}
+
+// resolveLatest returns the directory LoadDataset reads for dir, following
+// the latest link of snapshot directories
+func resolveLatest(dir string) string {
+	if _, err := os.Lstat(filepath.Join(dir, latestLink)); err == nil {
+		return filepath.Join(dir, latestLink)
+	}
+	return dir
+}
+
+// datasetFingerprint describes the files LoadDataset reads from dir: the
+// target of the latest link and the size and modification time of the run
+// report and corpus
+func datasetFingerprint(dir string) (string, error) {
+	fp := ""
+	if target, err := os.Readlink(filepath.Join(dir, latestLink)); err == nil {
+		fp = target
+	}
+	resolved := resolveLatest(dir)
+	for _, name := range []string{runReportFile, jsonFile} {
+		info, err := os.Stat(filepath.Join(resolved, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			fp += "|-"
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		fp += fmt.Sprintf("|%d:%d", info.Size(), info.ModTime().UnixNano())
+	}
+	return fp, nil
+}
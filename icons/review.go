@@ -0,0 +1,199 @@
+package icons
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	reviewQueueFile        = "review_queue.json"
+	defaultReviewThreshold = 0.5
+)
+
+// ReviewItem is an icon with enrichment fields below the review threshold.
+// Curators edit Suggested, set Approved or Rejected and merge the queue into
+// the overrides file with ApplyReview
+type ReviewItem struct {
+	Slug        string `json:"slug"`
+	DisplayName string `json:"display_name"`
+	Provider    string `json:"provider"`
+	// Fields are the fields to review with their source and confidence
+	Fields map[string]FieldProvenance `json:"fields"`
+	// Suggested holds the current values of the fields
+	Suggested Override `json:"suggested"`
+	Approved  bool     `json:"approved"`
+	// Rejected keeps the icon out of profiles limited to approved icons
+	Rejected bool `json:"rejected"`
+}
+
+// buildReviewQueue returns the icons pending review with an overridable
+// field whose confidence is below threshold, least confident first
+func buildReviewQueue(icons []*IconPayload, threshold float32) []ReviewItem {
+	queue := make([]ReviewItem, 0)
+	lowest := make(map[string]float32)
+	for _, icon := range icons {
+		if icon.ApprovalStatus == ApprovalApproved || icon.ApprovalStatus == ApprovalRejected {
+			continue
+		}
+		item := ReviewItem{Slug: icon.Slug, DisplayName: icon.DisplayName, Provider: icon.Provider, Fields: reviewFields(icon, threshold)}
+		if len(item.Fields) == 0 {
+			continue
+		}
+		for field, prov := range item.Fields {
+			item.Suggested.suggest(icon, field)
+			if c, ok := lowest[icon.Slug]; !ok || prov.Confidence < c {
+				lowest[icon.Slug] = prov.Confidence
+			}
+		}
+		queue = append(queue, item)
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		return lowest[queue[i].Slug] < lowest[queue[j].Slug]
+	})
+	return queue
+}
+
+// reviewFields returns the overridable fields of icon whose confidence is
+// below threshold
+func reviewFields(icon *IconPayload, threshold float32) map[string]FieldProvenance {
+	fields := make(map[string]FieldProvenance)
+	for field, prov := range icon.Provenance {
+		if prov.Confidence == 0 || prov.Confidence >= threshold || !new(Override).suggest(icon, field) {
+			continue
+		}
+		fields[field] = prov
+	}
+	return fields
+}
+
+// suggest sets field of o to its value in icon, false when field cannot be
+// overridden
+func (o *Override) suggest(icon *IconPayload, field string) bool {
+	switch field {
+	case "aliases":
+		o.Aliases = jsonToArray(icon.Aliases)
+	case "tags":
+		o.Tags = jsonToArray(icon.Tags)
+	case "technical_intent":
+		v := icon.TechnicalIntent
+		o.TechnicalIntent = &v
+	case "shape_type":
+		v := icon.ShapeType
+		o.ShapeType = &v
+	case "is_container":
+		v := icon.IsContainer
+		o.IsContainer = &v
+	case "color_theme":
+		v := icon.ColorTheme
+		o.ColorTheme = &v
+	default:
+		return false
+	}
+	return true
+}
+
+// writeReviewQueue writes review_queue.json with the icons below the review
+// threshold of cfg
+func writeReviewQueue(cfg *Config, icons []*IconPayload) error {
+	if cfg.ReviewThreshold <= 0 {
+		return nil
+	}
+	queue := buildReviewQueue(icons, cfg.ReviewThreshold)
+	path := filepath.Join(cfg.OutputDir, reviewQueueFile)
+	if err := cfg.writeJSON(path, queue); err != nil {
+		return err
+	}
+	if len(queue) > 0 {
+		log.Printf("👀 %d icons need review, see %s", len(queue), path)
+	}
+	return nil
+}
+
+// ApplyReview merges the suggested values of the approved items of a review
+// queue into an overrides file, creating it when missing, along with the
+// approval status of approved and rejected items. It returns the number of
+// icons merged. Comments of the overrides file are not kept
+func ApplyReview(queuePath, overridesPath string) (int, error) {
+	data, err := os.ReadFile(filepath.Clean(queuePath))
+	if err != nil {
+		return 0, fmt.Errorf("error reading review queue %s: %w", queuePath, err)
+	}
+	var queue []ReviewItem
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return 0, fmt.Errorf("error parsing review queue %s: %w", queuePath, err)
+	}
+	overrides, err := LoadOverrides(overridesPath)
+	if err != nil {
+		return 0, err
+	}
+	if overrides == nil {
+		overrides = make(map[string]Override)
+	}
+
+	merged := 0
+	for _, item := range queue {
+		o := overrides[item.Slug]
+		switch {
+		case item.Rejected:
+			status := ApprovalRejected
+			o.ApprovalStatus = &status
+		case item.Approved:
+			status := ApprovalApproved
+			o = o.merge(item.Suggested)
+			o.ApprovalStatus = &status
+		default:
+			continue
+		}
+		overrides[item.Slug] = o
+		merged++
+	}
+	if merged == 0 {
+		return 0, nil
+	}
+	if err := writeOverrides(overridesPath, overrides); err != nil {
+		return 0, err
+	}
+	return merged, nil
+}
+
+// merge returns o with the fields set in other replaced
+func (o Override) merge(other Override) Override {
+	if other.DisplayName != nil {
+		o.DisplayName = other.DisplayName
+	}
+	if other.IconifyID != nil {
+		o.IconifyID = other.IconifyID
+	}
+	if other.ColorTheme != nil {
+		o.ColorTheme = other.ColorTheme
+	}
+	if other.IsContainer != nil {
+		o.IsContainer = other.IsContainer
+	}
+	if other.ShapeType != nil {
+		o.ShapeType = other.ShapeType
+	}
+	if other.DefaultWidth != nil {
+		o.DefaultWidth = other.DefaultWidth
+	}
+	if other.Description != nil {
+		o.Description = other.Description
+	}
+	if other.TechnicalIntent != nil {
+		o.TechnicalIntent = other.TechnicalIntent
+	}
+	if other.Aliases != nil {
+		o.Aliases = other.Aliases
+	}
+	if other.Tags != nil {
+		o.Tags = other.Tags
+	}
+	if other.ApprovalStatus != nil {
+		o.ApprovalStatus = other.ApprovalStatus
+	}
+	return o
+}
@@ -0,0 +1,162 @@
+package icons
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly"
+)
+
+// ErrSourceLayoutChanged is returned when the scraped page no longer matches
+// the markup the scraper understands
+var ErrSourceLayoutChanged = errors.New("source layout changed")
+
+var (
+	// clickIcon("aws%2FCompute%2FEC2.svg"), clickIcon('...'), clickIcon( "..." );
+	onclickRgx = regexp.MustCompile(`clickIcon\s*\(\s*["']([^"']+)["']\s*\)`)
+	// fallback for any quoted .svg path inside an event handler
+	quotedSVGRgx = regexp.MustCompile(`["']([^"']+\.svg)["']`)
+
+	escapedSlashRgx = regexp.MustCompile(`(?i)%2F`)
+
+	linkAttrs = []string{"data-link", "data-url", "data-src", "data-path", "href"}
+)
+
+// scrapeStats tracks how much of the page the scraper recognised
+type scrapeStats struct {
+	Elements int
+	Parsed   int
+	Skipped  int
+}
+
+// check reports ErrSourceLayoutChanged when too few icons were recognised
+func (s scrapeStats) check(minExpected int) error {
+	if s.Parsed >= minExpected {
+		return nil
+	}
+	return fmt.Errorf("%w: found %d icon elements, parsed %d links, skipped %d (expected at least %d)",
+		ErrSourceLayoutChanged, s.Elements, s.Parsed, s.Skipped, minExpected)
+}
+
+// isIconElement reports whether the element looks like an icon tile
+func isIconElement(e *colly.HTMLElement) bool {
+	for _, class := range strings.Fields(e.Attr("class")) {
+		if class == "icon" {
+			return true
+		}
+	}
+	return e.Attr("onclick") != "" && strings.Contains(e.Attr("onclick"), "clickIcon")
+}
+
+// extractIconLink returns the escaped icon path of an icon element, trying the
+// onclick handler first and falling back to link attributes and child images
+func extractIconLink(e *colly.HTMLElement) string {
+	if onclick := getUnescaped(e.Attr("onclick")); onclick != "" {
+		if m := onclickRgx.FindStringSubmatch(onclick); m != nil {
+			return normalizeIconLink(m[1])
+		}
+		if m := quotedSVGRgx.FindStringSubmatch(onclick); m != nil {
+			return normalizeIconLink(m[1])
+		}
+	}
+
+	for _, attr := range linkAttrs {
+		if v := e.Attr(attr); strings.HasSuffix(strings.ToLower(v), ".svg") {
+			return normalizeIconLink(getUnescaped(v))
+		}
+	}
+
+	if src := e.ChildAttr("img", "src"); strings.HasSuffix(strings.ToLower(src), ".svg") {
+		return normalizeIconLink(getUnescaped(src))
+	}
+	if href := e.ChildAttr("a", "href"); strings.HasSuffix(strings.ToLower(href), ".svg") {
+		return normalizeIconLink(getUnescaped(href))
+	}
+
+	return ""
+}
+
+// normalizeIconLink converts absolute URLs and plain paths to the escaped
+// "category%2Fname.svg" form used by terrastruct links
+func normalizeIconLink(link string) string {
+	link = strings.TrimSpace(link)
+	if u, err := url.Parse(link); err == nil && u.Host != "" {
+		link = u.EscapedPath()
+	}
+	link = strings.TrimPrefix(link, "/")
+	if strings.Contains(link, "/") {
+		segments := strings.Split(link, "/")
+		for i, s := range segments {
+			if unescaped, err := url.PathUnescape(s); err == nil {
+				s = unescaped
+			}
+			segments[i] = url.PathEscape(s)
+		}
+		link = strings.Join(segments, "%2F")
+	}
+	return link
+}
+
+// linkCategory returns the top level category of an escaped icon link
+func linkCategory(link string) string {
+	if unescaped, err := url.PathUnescape(link); err == nil && strings.Contains(unescaped, "/") {
+		return strings.Split(path.Clean(unescaped), "/")[0]
+	}
+	if parts := strings.Split(link, "%"); len(parts) > 1 {
+		return parts[0]
+	}
+	return ""
+}
+
+// newCollector returns a polite colly collector configured from cfg, fetching
+// through transport
+func newCollector(cfg *Config, transport http.RoundTripper) (*colly.Collector, error) {
+	c := colly.NewCollector(colly.UserAgent(cfg.UserAgent))
+	c.IgnoreRobotsTxt = !cfg.RespectRobotsTxt || cfg.Offline
+
+	if cfg.CacheDir != "" {
+		transport = newCacheTransport(cfg.CacheDir, cfg.Offline, transport)
+	}
+	c.WithTransport(cfg.transport(transport))
+	if cfg.HTTPClient != nil {
+		if jar, ok := cfg.HTTPClient.Jar.(*cookiejar.Jar); ok {
+			c.SetCookieJar(jar)
+		}
+	}
+
+	parallelism := cfg.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if err := c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Delay:       cfg.RequestDelay,
+		RandomDelay: cfg.RandomDelay,
+		Parallelism: parallelism,
+	}); err != nil {
+		return nil, fmt.Errorf("error setting scrape limits: %w", err)
+	}
+	return c, nil
+}
+
+// linkHierarchy returns the directories between the top level category and
+// the file of an escaped icon link, e.g. aws%2FCompute%2FEC2.svg -> [Compute]
+func linkHierarchy(link string) []string {
+	unescaped, err := url.PathUnescape(link)
+	if err != nil || !strings.Contains(unescaped, "/") {
+		// bare % separators, e.g. aws%compute%ec2.svg, possibly mixed with
+		// escaped ones
+		unescaped = strings.ReplaceAll(escapedSlashRgx.ReplaceAllString(link, "/"), "%", "/")
+	}
+	segments := strings.Split(strings.Trim(path.Clean(unescaped), "/"), "/")
+	if len(segments) < 3 {
+		return nil
+	}
+	return segments[1 : len(segments)-1]
+}
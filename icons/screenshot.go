@@ -0,0 +1,68 @@
+//go:build chromedp
+
+package icons
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// waitImagesJS resolves once every image of the page is decoded
+const waitImagesJS = `Promise.all([...document.images].map((img) => img.decode().catch(() => {}))).then(() => true)`
+
+func init() {
+	RegisterPageRenderer(screenshotPages)
+}
+
+// screenshotPages renders pages in a headless browser and captures each of
+// them at the size of its content
+func screenshotPages(ctx context.Context, pages []string) ([][]byte, error) {
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, chromedp.DefaultExecAllocatorOptions[:]...)
+	defer cancelAlloc()
+	browser, cancelBrowser := chromedp.NewContext(allocCtx)
+	defer cancelBrowser()
+	if err := chromedp.Run(browser); err != nil {
+		return nil, fmt.Errorf("error starting browser: %w", err)
+	}
+
+	images := make([][]byte, 0, len(pages))
+	for i, html := range pages {
+		tab, cancel := context.WithTimeout(browser, browserTimeout)
+		var (
+			img     []byte
+			decoded bool
+		)
+		err := chromedp.Run(tab,
+			chromedp.Navigate("about:blank"),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				tree, err := page.GetFrameTree().Do(ctx)
+				if err != nil {
+					return err
+				}
+				return page.SetDocumentContent(tree.Frame.ID, html).Do(ctx)
+			}),
+			chromedp.Evaluate(waitImagesJS, &decoded, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+				return p.WithAwaitPromise(true)
+			}),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				_, _, _, _, _, size, err := page.GetLayoutMetrics().Do(ctx)
+				if err != nil {
+					return err
+				}
+				return chromedp.EmulateViewport(int64(math.Ceil(size.Width)), int64(math.Ceil(size.Height))).Do(ctx)
+			}),
+			chromedp.FullScreenshot(&img, 100),
+		)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
@@ -0,0 +1,100 @@
+package icons
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	svgShapeRgx   = regexp.MustCompile(`<(path|rect|circle|ellipse|polygon|polyline|line)\b`)
+	svgGroupRgx   = regexp.MustCompile(`<g\b`)
+	svgTextRgx    = regexp.MustCompile(`<text\b`)
+	svgEllipseRgx = regexp.MustCompile(`<ellipse\b`)
+
+	personRgx   = regexp.MustCompile(`(?i)\b(user|users|person|people|actor|customer|admin|developer|human)s?\b`)
+	databaseRgx = regexp.MustCompile(`(?i)(database|datastore|storage|bucket|sql|dynamodb|aurora|cosmos|mongo|redis|cassandra|\b(db|rds|s3)\b)`)
+	queueRgx    = regexp.MustCompile(`(?i)\b(queue|sqs|kafka|pub\s?sub|event\s?hub|topic|mq)\b`)
+	cloudRgx    = regexp.MustCompile(`(?i)^(cloud|internet|public cloud|private cloud)$`)
+)
+
+// wideAspect is the width to height ratio above which artwork is drawn as a
+// labelled rectangle rather than a square image
+const wideAspect = 2.0
+
+// svgGeometry summarizes the drawing of an SVG
+type svgGeometry struct {
+	Aspect   float64
+	Shapes   int
+	Groups   int
+	Ellipses int
+	Text     bool
+}
+
+// analyzeSVG measures the artwork of doc
+func analyzeSVG(doc svgDoc) svgGeometry {
+	return svgGeometry{
+		Aspect:   doc.Width / doc.Height,
+		Shapes:   len(svgShapeRgx.FindAllString(doc.Body, -1)),
+		Groups:   len(svgGroupRgx.FindAllString(doc.Body, -1)),
+		Ellipses: len(svgEllipseRgx.FindAllString(doc.Body, -1)),
+		Text:     svgTextRgx.MatchString(doc.Body),
+	}
+}
+
+// inferShapeType picks the D2 shape of icon from its name and, when
+// available, the geometry of its artwork
+func inferShapeType(icon *IconPayload, geo *svgGeometry) string {
+	name := icon.DisplayName + " " + strings.Join(jsonToArray(icon.Tags), " ")
+	switch {
+	case icon.IsContainer:
+		return "rectangle"
+	case personRgx.MatchString(name):
+		return "person"
+	case databaseRgx.MatchString(name):
+		return "cylinder"
+	case queueRgx.MatchString(name):
+		return "queue"
+	case cloudRgx.MatchString(strings.TrimSpace(icon.DisplayName)):
+		return "cloud"
+	}
+	if geo == nil {
+		return "image"
+	}
+	switch {
+	// stacked ellipses over a body are drawn databases
+	case geo.Ellipses >= 2 && geo.Shapes > geo.Ellipses:
+		return "cylinder"
+	// wordmarks and wide banners read better as labelled rectangles
+	case geo.Aspect >= wideAspect && (geo.Text || geo.Shapes > 1 || geo.Groups > 0):
+		return "rectangle"
+	default:
+		return "image"
+	}
+}
+
+// inferShapes sets shape_type of icons whose shape was not provided by the
+// LLM or an override, analyzing downloaded artwork under outDir
+func inferShapes(outDir string, icons []*IconPayload, timestamp string) int {
+	changed := 0
+	for _, icon := range icons {
+		if p, ok := icon.Provenance["shape_type"]; ok && p.Source != SourceRules {
+			continue
+		}
+
+		var geo *svgGeometry
+		if data, err := readAsset(outDir, icon); err == nil {
+			if doc, ok := parseSVG(data); ok {
+				g := analyzeSVG(doc)
+				geo = &g
+			}
+		}
+
+		shape := inferShapeType(icon, geo)
+		if shape != icon.ShapeType {
+			changed++
+		}
+		icon.ShapeType = shape
+		icon.setProvenance(SourceRules, timestamp, "shape_type")
+	}
+	return changed
+}
@@ -0,0 +1,96 @@
+package icons
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const checkpointFile = "checkpoint.json"
+
+// Checkpoint records how far an interrupted run got
+type Checkpoint struct {
+	StartedAt     string `json:"started_at"`
+	InterruptedAt string `json:"interrupted_at"`
+	Processed     int    `json:"processed"`
+	// Remaining are the URLs of the icons the run did not get to
+	Remaining []string `json:"remaining"`
+}
+
+// withShutdown returns a context canceled on SIGINT or SIGTERM; a second
+// signal kills the process as usual
+func withShutdown(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// flushPartial writes the icons processed before the run was interrupted to
+// the corpus and provider files of the run directory, then marks it partial
+func (r *runDir) flushPartial(report *RunReport, pending []PendingIcon, processed []*IconPayload) error {
+	if err := writeCorpus(r.cfg, r.cfg.OutputDir, processed); err != nil {
+		return err
+	}
+
+	done := make(map[string]bool, len(processed))
+	for _, icon := range processed {
+		done[icon.URL] = true
+	}
+	return r.interrupted(report, pending, done)
+}
+
+// interrupted writes the checkpoint of the icons of pending not in done and
+// a run report marked partial. The run directory is never published
+func (r *runDir) interrupted(report *RunReport, pending []PendingIcon, done map[string]bool) error {
+	checkpoint := Checkpoint{
+		StartedAt:     report.StartedAt,
+		InterruptedAt: time.Now().UTC().Format(time.RFC3339),
+		Remaining:     []string{},
+	}
+	for _, p := range pending {
+		if u := pendingURL(p); done[u] {
+			checkpoint.Processed++
+		} else {
+			checkpoint.Remaining = append(checkpoint.Remaining, u)
+		}
+	}
+	if err := r.cfg.writeJSON(filepath.Join(r.cfg.OutputDir, checkpointFile), checkpoint); err != nil {
+		return err
+	}
+
+	report.Total = checkpoint.Processed
+	report.Partial = true
+	if err := r.cfg.writeJSON(filepath.Join(r.cfg.OutputDir, runReportFile), report); err != nil {
+		return err
+	}
+	log.Printf("🛑 Interrupted: flushed %d icons to %s, %d remaining", checkpoint.Processed, r.cfg.OutputDir, len(checkpoint.Remaining))
+	return nil
+}
+
+// writeCorpus writes icons to the corpus file and the provider files of dir
+func writeCorpus(cfg *Config, dir string, icons []*IconPayload) error {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	icons = append([]*IconPayload(nil), icons...)
+	sortIcons(icons)
+	providerKeys, providerIcons := groupByProvider(icons)
+	for _, providerKey := range providerKeys {
+		if err := os.MkdirAll(filepath.Join(dir, providerKey), 0750); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, providerKey, fmt.Sprintf("%s.json", providerKey))
+		if err := cfg.writeJSON(path, providerIcons[providerKey]); err != nil {
+			return err
+		}
+	}
+	return cfg.writeJSON(filepath.Join(dir, jsonFile), icons)
+}
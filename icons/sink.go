@@ -0,0 +1,346 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SinkMode selects how a run reacts to failing sinks
+type SinkMode string
+
+const (
+	// SinkBestEffort reports failing sinks and only fails the run when every
+	// sink failed
+	SinkBestEffort SinkMode = ""
+	// SinkAllOrNothing commits transactional sinks only when every sink
+	// succeeded, rolling them back and failing the run otherwise
+	SinkAllOrNothing SinkMode = "all-or-nothing"
+)
+
+// Sink delivers the corpus of a run to a downstream store, e.g. a bucket or
+// a vector database
+type Sink interface {
+	// Name identifies the sink in the RunReport
+	Name() string
+	// Write delivers icons
+	Write(ctx context.Context, run *SinkRun, icons []*IconPayload) error
+}
+
+// TransactionalSink stages what Write delivers until Commit, so that
+// SinkAllOrNothing can undo it with Rollback when another sink fails
+type TransactionalSink interface {
+	Sink
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// SinkRun describes the run delivered to a sink
+type SinkRun struct {
+	// OutputDir is the directory of the run, holding the files written so far
+	OutputDir string
+	// Namespace is the namespace of the run, empty when there is none
+	Namespace string
+	// RunID identifies the run, sinks store it with every record to find
+	// the records of icons the run no longer has
+	RunID string
+	// Previous is the corpus file of the previous run, missing on the first
+	Previous string
+	// Provider is the display name of the provider regenerated by
+	// GenerateProvider, empty for full runs. Sinks storing single icons only
+	// write and tombstone the icons of Provider
+	Provider string
+	// Config is the configuration of the run
+	Config *Config
+
+	// modified maps the slugs of the previous corpus to their last_modified,
+	// synced holds the sinks the previous run wrote successfully
+	modified map[string]string
+	synced   map[string]bool
+
+	mu         sync.Mutex
+	skipped    map[string]int
+	reembedded map[string]int
+}
+
+// SinkStatus reports the outcome of one sink of a run
+type SinkStatus struct {
+	Name       string `json:"name"`
+	Icons      int    `json:"icons"`
+	Duration   string `json:"duration"`
+	Error      string `json:"error,omitempty"`
+	RolledBack bool   `json:"rolled_back,omitempty"`
+	// Skipped counts the icons an incremental sink left alone as unchanged
+	Skipped int `json:"skipped,omitempty"`
+	// Reembedded counts the unchanged icons embedded again because their
+	// stored embedding model was not the configured one
+	Reembedded int `json:"reembedded,omitempty"`
+}
+
+// runSinks writes icons to every sink of cfg concurrently, recording their
+// outcome in report. run carries the previous corpus and provider, the rest
+// is filled in from cfg
+func runSinks(ctx context.Context, cfg *Config, report *RunReport, icons []*IconPayload, run *SinkRun) error {
+	if len(cfg.Sinks) == 0 {
+		return nil
+	}
+	run.OutputDir, run.Namespace, run.RunID, run.Config = cfg.OutputDir, cfg.Namespace, uuid.New().String(), cfg
+	ctx = withRunClient(ctx, cfg.client())
+	if err := run.loadPrevious(); err != nil {
+		log.Printf("⚠️  Incremental sinks write every icon: %v", err)
+	}
+	atomic := cfg.SinkMode == SinkAllOrNothing
+	report.Sinks = make([]SinkStatus, len(cfg.Sinks))
+	errs := make([]error, len(cfg.Sinks))
+
+	var wg sync.WaitGroup
+	for i, sink := range cfg.Sinks {
+		report.Sinks[i] = SinkStatus{Name: sink.Name()}
+		wg.Add(1)
+		go func(i int, sink Sink) {
+			defer wg.Done()
+			start := time.Now()
+			errs[i] = sink.Write(ctx, run, icons)
+			// outside of all-or-nothing runs every sink commits on its own
+			if tx, ok := sink.(TransactionalSink); ok && !atomic {
+				errs[i] = finishSink(ctx, tx, errs[i])
+			}
+
+			status := &report.Sinks[i]
+			status.Duration = time.Since(start).Round(time.Millisecond).String()
+			if errs[i] != nil {
+				status.Error = errs[i].Error()
+				log.Printf("❌ Sink %s failed after %s: %v", status.Name, status.Duration, errs[i])
+				return
+			}
+			status.Skipped = run.skippedBy(status.Name)
+			status.Icons = len(icons) - status.Skipped
+			if status.Reembedded = run.reembeddedBy(status.Name); status.Reembedded > 0 {
+				log.Printf("🧬 Sink %s: re-embedded %d icons with another model", status.Name, status.Reembedded)
+			}
+			if status.Skipped > 0 {
+				log.Printf("📤 Sink %s: %d icons in %s, %d unchanged skipped", status.Name, status.Icons, status.Duration, status.Skipped)
+				return
+			}
+			log.Printf("📤 Sink %s: %d icons in %s", status.Name, status.Icons, status.Duration)
+		}(i, sink)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			errs[i] = fmt.Errorf("sink %s: %w", report.Sinks[i].Name, err)
+			failed++
+		}
+	}
+
+	if atomic {
+		return settleSinks(ctx, cfg.Sinks, report, errors.Join(errs...))
+	}
+	if failed == len(cfg.Sinks) {
+		return errors.Join(errs...)
+	}
+	if failed > 0 {
+		log.Printf("⚠️  %d of %d sinks failed, see %s", failed, len(cfg.Sinks), runReportFile)
+	}
+	return nil
+}
+
+// runClientKey is the context key of the HTTP client of the run sinks write
+type runClientKey struct{}
+
+// withRunClient returns ctx carrying client for the sinks of the run
+func withRunClient(ctx context.Context, client *http.Client) context.Context {
+	return context.WithValue(ctx, runClientKey{}, client)
+}
+
+// runClient returns client when set, the client of the run in ctx otherwise
+func runClient(ctx context.Context, client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	if client, ok := ctx.Value(runClientKey{}).(*http.Client); ok {
+		return client
+	}
+	return defaultHTTPClient
+}
+
+// finishSink commits tx after a successful write and rolls it back otherwise
+func finishSink(ctx context.Context, tx TransactionalSink, err error) error {
+	if err != nil {
+		if rerr := tx.Rollback(ctx); rerr != nil {
+			return errors.Join(err, fmt.Errorf("rollback: %w", rerr))
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// settleSinks commits the transactional sinks when err is nil and rolls them
+// all back otherwise
+func settleSinks(ctx context.Context, sinks []Sink, report *RunReport, err error) error {
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for i, sink := range sinks {
+		tx, ok := sink.(TransactionalSink)
+		if !ok {
+			continue
+		}
+		status := &report.Sinks[i]
+		if err != nil {
+			if rerr := tx.Rollback(ctx); rerr != nil {
+				errs = append(errs, fmt.Errorf("sink %s: rollback: %w", status.Name, rerr))
+				continue
+			}
+			status.RolledBack = true
+			status.Icons = 0
+			continue
+		}
+		if cerr := tx.Commit(ctx); cerr != nil {
+			status.Error = cerr.Error()
+			status.Icons = 0
+			errs = append(errs, fmt.Errorf("sink %s: commit: %w", status.Name, cerr))
+		}
+	}
+	if err != nil {
+		log.Printf("↩️  Rolled back transactional sinks: %v", err)
+	}
+	return errors.Join(errs...)
+}
+
+// scoped returns the icons of the provider of a partial run, all of icons
+// for full runs
+func (r *SinkRun) scoped(icons []*IconPayload) []*IconPayload {
+	if r.Provider == "" {
+		return icons
+	}
+	var scoped []*IconPayload
+	for _, icon := range icons {
+		if icon.Provider == r.Provider {
+			scoped = append(scoped, icon)
+		}
+	}
+	return scoped
+}
+
+// validateSinks rejects sinks sharing a name, as the report and incremental
+// sinks tell sinks apart by their name
+func validateSinks(sinks []Sink) error {
+	seen := make(map[string]bool, len(sinks))
+	for _, sink := range sinks {
+		name := sink.Name()
+		if seen[name] {
+			return fmt.Errorf("two sinks are named %s, give them distinct targets or IDs", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// loadPrevious reads the last_modified of the icons of the previous corpus
+// and the sinks its run wrote successfully
+func (r *SinkRun) loadPrevious() error {
+	if r.Previous == "" {
+		return nil
+	}
+	report, err := loadRunReport(filepath.Dir(r.Previous))
+	if err != nil || report == nil {
+		return err
+	}
+	previous, err := loadIcons(r.Previous)
+	if err != nil {
+		return err
+	}
+	r.synced = make(map[string]bool, len(report.Sinks))
+	for _, s := range report.Sinks {
+		r.synced[s.Name] = s.Error == "" && !s.RolledBack
+	}
+	r.modified = make(map[string]string, len(previous))
+	for _, icon := range previous {
+		r.modified[icon.Slug] = icon.LastModified
+	}
+	return nil
+}
+
+// unchanged splits icons into those sink has to write and those it already
+// holds: icons with the last_modified of the previous run, which sink wrote
+// successfully. The skipped icons are counted in the report
+func (r *SinkRun) unchanged(sink string, icons []*IconPayload) (changed, unchanged []*IconPayload) {
+	if !r.synced[sink] {
+		return icons, nil
+	}
+	for _, icon := range icons {
+		if m, ok := r.modified[icon.Slug]; ok && m != "" && m == icon.LastModified {
+			unchanged = append(unchanged, icon)
+			continue
+		}
+		changed = append(changed, icon)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.skipped == nil {
+		r.skipped = make(map[string]int)
+	}
+	r.skipped[sink] += len(unchanged)
+	return changed, unchanged
+}
+
+// skippedBy returns the number of icons sink skipped as unchanged
+func (r *SinkRun) skippedBy(sink string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.skipped[sink]
+}
+
+// invalidate moves the unchanged icons whose stored vectors are stale, i.e.
+// embedded with another model, back to the icons sink writes, at most limit
+// of them when limit is positive. The others keep their vectors until a
+// later run
+func (r *SinkRun) invalidate(sink string, changed, unchanged []*IconPayload, stale map[string]bool, limit int) ([]*IconPayload, []*IconPayload) {
+	if len(stale) == 0 || len(unchanged) == 0 {
+		return changed, unchanged
+	}
+	kept := unchanged[:0:0]
+	moved := 0
+	for _, icon := range unchanged {
+		if stale[icon.Slug] && (limit <= 0 || moved < limit) {
+			changed = append(changed, icon)
+			moved++
+			continue
+		}
+		kept = append(kept, icon)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reembedded == nil {
+		r.reembedded = make(map[string]int)
+	}
+	r.skipped[sink] -= moved
+	r.reembedded[sink] += moved
+	return changed, kept
+}
+
+// reembeddedBy returns the number of icons sink embedded again for a model
+// change
+func (r *SinkRun) reembeddedBy(sink string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reembedded[sink]
+}
+
+func tombstoneVerb(hardDelete bool) string {
+	if hardDelete {
+		return "deleted"
+	}
+	return "deprecated"
+}
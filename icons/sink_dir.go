@@ -0,0 +1,32 @@
+package icons
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// DirSink writes the corpus and provider files to Dir, e.g. a mounted bucket.
+// Writes are staged next to Dir and swapped in on Commit
+type DirSink struct {
+	Dir string
+}
+
+func (s DirSink) Name() string { return "dir:" + s.Dir }
+
+func (s DirSink) staging() string { return filepath.Clean(s.Dir) + stagingSuffix }
+
+func (s DirSink) Write(ctx context.Context, run *SinkRun, icons []*IconPayload) error {
+	if err := os.RemoveAll(s.staging()); err != nil {
+		return err
+	}
+	return writeCorpus(run.Config, s.staging(), icons)
+}
+
+func (s DirSink) Commit(ctx context.Context) error {
+	return swapDir(s.staging(), s.Dir)
+}
+
+func (s DirSink) Rollback(ctx context.Context) error {
+	return os.RemoveAll(s.staging())
+}
@@ -0,0 +1,147 @@
+package icons
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// eventSchemaVersion is the version of IconEvent, bumped on breaking changes
+const eventSchemaVersion = 1
+
+const defaultEventSubject = "icons"
+
+// Icon event types
+const (
+	EventAdded   = "added"
+	EventUpdated = "updated"
+	EventRemoved = "removed"
+)
+
+// IconEvent is the message published for every icon a run added, updated or
+// removed compared to the previous run
+type IconEvent struct {
+	Version   int    `json:"version"`
+	Type      string `json:"type"`
+	Namespace string `json:"namespace,omitempty"`
+	RunID     string `json:"run_id"`
+	Slug      string `json:"slug"`
+	ID        string `json:"id"`
+	// Fields lists the changed fields of updated icons
+	Fields []string `json:"fields,omitempty"`
+	// Icon is the icon as written by the run, missing for removed icons
+	Icon *IconPayload `json:"icon,omitempty"`
+	Time string       `json:"time"`
+}
+
+// EventPublisher publishes events to a subject, or topic, of a broker
+type EventPublisher interface {
+	Name() string
+	Publish(ctx context.Context, subject string, events []IconEvent) error
+}
+
+// EventSink publishes an IconEvent for every icon added, updated or removed
+// since the previous run, on the subjects <Subject>.added, <Subject>.updated
+// and <Subject>.removed. Events are only published on Commit, so
+// SinkAllOrNothing runs never announce changes they roll back
+type EventSink struct {
+	Publisher EventPublisher
+	// Subject defaults to icons, prefixed with the namespace of the run
+	Subject string
+
+	mu      sync.Mutex
+	pending map[string][]IconEvent
+}
+
+func (s *EventSink) Name() string { return "events:" + s.Publisher.Name() }
+
+// subject returns the subject of events of type typ in namespace ns
+func (s *EventSink) subject(ns, typ string) string {
+	subject := s.Subject
+	if subject == "" {
+		subject = defaultEventSubject
+	}
+	if ns != "" {
+		subject = ns + "." + subject
+	}
+	return subject + "." + typ
+}
+
+func (s *EventSink) Write(ctx context.Context, run *SinkRun, icons []*IconPayload) error {
+	previous, err := loadIcons(run.Previous)
+	if err != nil {
+		return fmt.Errorf("error reading previous output: %w", err)
+	}
+	pending := iconEvents(run, run.scoped(previous), run.scoped(icons))
+
+	s.mu.Lock()
+	s.pending = pending
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *EventSink) Commit(ctx context.Context) error {
+	pending := s.takePending()
+	for _, typ := range []string{EventAdded, EventUpdated, EventRemoved} {
+		events := pending[typ]
+		if len(events) == 0 {
+			continue
+		}
+		subject := s.subject(events[0].Namespace, typ)
+		if err := s.Publisher.Publish(ctx, subject, events); err != nil {
+			return fmt.Errorf("error publishing to %s: %w", subject, err)
+		}
+		log.Printf("📣 %s: %d events on %s", s.Name(), len(events), subject)
+	}
+	return nil
+}
+
+func (s *EventSink) Rollback(ctx context.Context) error {
+	s.takePending()
+	return nil
+}
+
+func (s *EventSink) takePending() map[string][]IconEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending := s.pending
+	s.pending = nil
+	return pending
+}
+
+// iconEvents returns the events of the changes between previous and icons by
+// type, in slug order
+func iconEvents(run *SinkRun, previous, icons []*IconPayload) map[string][]IconEvent {
+	now := time.Now().UTC().Format(time.RFC3339)
+	event := func(typ, slug, id string) IconEvent {
+		return IconEvent{Version: eventSchemaVersion, Type: typ, Namespace: run.Namespace, RunID: run.RunID, Slug: slug, ID: id, Time: now}
+	}
+	current := make(map[string]*IconPayload, len(icons))
+	for _, icon := range icons {
+		current[icon.Slug] = icon
+	}
+	old := make(map[string]*IconPayload, len(previous))
+	for _, icon := range previous {
+		old[icon.Slug] = icon
+	}
+
+	diff := diffIcons(previous, icons)
+	events := make(map[string][]IconEvent, 3)
+	for _, slug := range diff.Added {
+		e := event(EventAdded, slug, current[slug].ID)
+		e.Icon = current[slug]
+		events[EventAdded] = append(events[EventAdded], e)
+	}
+	for _, change := range diff.Changed {
+		e := event(EventUpdated, change.Slug, current[change.Slug].ID)
+		e.Fields = change.Fields
+		e.Icon = current[change.Slug]
+		events[EventUpdated] = append(events[EventUpdated], e)
+	}
+	for _, slug := range diff.Removed {
+		events[EventRemoved] = append(events[EventRemoved], event(EventRemoved, slug, old[slug].ID))
+	}
+	return events
+}
@@ -0,0 +1,65 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// HTTPSink uploads icons_rag.json to URL with a PUT, e.g. an S3 or GCS
+// presigned URL or an object store gateway
+type HTTPSink struct {
+	URL string
+	// Header is sent with the upload, e.g. an Authorization header
+	Header http.Header
+	// Client defaults to the client of the run
+	Client *http.Client
+}
+
+func (s HTTPSink) Name() string { return "http:" + redactURL(s.URL) }
+
+func (s HTTPSink) Write(ctx context.Context, run *SinkRun, icons []*IconPayload) error {
+	var body bytes.Buffer
+	e := json.NewEncoder(&body)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(icons); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.URL, &body)
+	if err != nil {
+		return err
+	}
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := runClient(ctx, s.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("upload returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// redactURL drops the credentials and query of u, which hold the signature of
+// presigned URLs, so it can be reported
+func redactURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "invalid-url"
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.String()
+}
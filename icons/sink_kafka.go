@@ -0,0 +1,94 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const kafkaRecordBatch = 500
+
+// KafkaRESTPublisher produces events to Kafka topics through a Confluent REST
+// Proxy at URL, keyed by slug so the events of an icon stay ordered within
+// a partition. It only speaks the v2 API of the REST Proxy, not the Kafka
+// protocol, so brokers without a REST Proxy in front are not supported
+type KafkaRESTPublisher struct {
+	URL string
+	// Header is sent with every request, e.g. an Authorization header
+	Header http.Header
+	// Client defaults to the client of the run
+	Client *http.Client
+}
+
+type kafkaRecord struct {
+	Key   string    `json:"key"`
+	Value IconEvent `json:"value"`
+}
+
+func (p KafkaRESTPublisher) Name() string { return "kafka:" + redactURL(p.URL) }
+
+func (p KafkaRESTPublisher) Publish(ctx context.Context, topic string, events []IconEvent) error {
+	for i := 0; i < len(events); i += kafkaRecordBatch {
+		end := i + kafkaRecordBatch
+		if end > len(events) {
+			end = len(events)
+		}
+		records := make([]kafkaRecord, 0, end-i)
+		for _, e := range events[i:end] {
+			records = append(records, kafkaRecord{Key: e.Slug, Value: e})
+		}
+		if err := p.produce(ctx, topic, records); err != nil {
+			return fmt.Errorf("records %d-%d: %w", i+1, end, err)
+		}
+	}
+	return nil
+}
+
+func (p KafkaRESTPublisher) produce(ctx context.Context, topic string, records []kafkaRecord) error {
+	body, err := json.Marshal(map[string]any{"records": records})
+	if err != nil {
+		return err
+	}
+	u := strings.TrimRight(p.URL, "/") + "/topics/" + url.PathEscape(topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range p.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := runClient(ctx, p.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kafka rest proxy returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	// the proxy answers 200 even when single records failed
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	for i, o := range result.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("record %d: %s", i+1, o.Error)
+		}
+	}
+	return nil
+}
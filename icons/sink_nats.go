@@ -0,0 +1,204 @@
+package icons
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultNATSPort = "4222"
+
+// NATSPublisher publishes events to a NATS server over the text client
+// protocol, e.g. nats://localhost:4222. tls://localhost:4222 always upgrades
+// the connection to TLS, nats:// does when the server requires it. This is a
+// minimal client for publishing: it does not reconnect, cluster or use
+// JetStream
+type NATSPublisher struct {
+	URL string
+	// Token, or User and Password, authenticate with the server; they fall
+	// back to the user info of URL, e.g. nats://token@localhost:4222, which
+	// keeps the credentials in the URL and is best avoided
+	Token    string
+	User     string
+	Password string
+	// TLSConfig is used for TLS connections, verifying the server name of
+	// URL against the system roots by default
+	TLSConfig *tls.Config
+	// Timeout bounds connecting and the final flush, 10s by default
+	Timeout time.Duration
+}
+
+type natsInfo struct {
+	MaxPayload  int  `json:"max_payload"`
+	TLSRequired bool `json:"tls_required"`
+}
+
+func (p NATSPublisher) Name() string { return "nats:" + redactURL(p.URL) }
+
+func (p NATSPublisher) Publish(ctx context.Context, subject string, events []IconEvent) error {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return fmt.Errorf("unsupported NATS URL scheme %q, want nats or tls", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), defaultNATSPort)
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	raw, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+	stop := context.AfterFunc(ctx, func() { raw.Close() })
+	defer stop()
+
+	var conn net.Conn = raw
+	r := bufio.NewReader(conn)
+	conn.SetDeadline(time.Now().Add(timeout))
+	info, err := natsReadInfo(r)
+	if err != nil {
+		return err
+	}
+	secure := u.Scheme == "tls" || info.TLSRequired
+	if secure {
+		// the server sends INFO in the clear and expects the client to
+		// upgrade before CONNECT
+		tc := tls.Client(raw, p.tlsConfig(u.Hostname()))
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("error negotiating TLS: %w", err)
+		}
+		conn = tc
+		r = bufio.NewReader(conn)
+	}
+	w := bufio.NewWriter(conn)
+	if err := natsConnect(r, w, p.credentials(u.User), secure); err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if info.MaxPayload > 0 && len(data) > info.MaxPayload {
+			return fmt.Errorf("event of %s is %d bytes, over the max payload of %d", e.Slug, len(data), info.MaxPayload)
+		}
+		fmt.Fprintf(w, "PUB %s %d\r\n", subject, len(data))
+		w.Write(data)
+		if _, err := w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+
+	// the server answers the PING once it processed every PUB before it
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := w.WriteString("PING\r\n"); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return natsAwait(r, w, "PONG")
+}
+
+// tlsConfig returns the TLS configuration of p for the server host
+func (p NATSPublisher) tlsConfig(host string) *tls.Config {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if p.TLSConfig != nil {
+		cfg = p.TLSConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+	return cfg
+}
+
+// credentials returns the CONNECT fields authenticating p, preferring its
+// fields over the user info of its URL
+func (p NATSPublisher) credentials(user *url.Userinfo) map[string]any {
+	switch {
+	case p.Token != "":
+		return map[string]any{"auth_token": p.Token}
+	case p.User != "":
+		return map[string]any{"user": p.User, "pass": p.Password}
+	case user == nil:
+		return nil
+	}
+	if pass, ok := user.Password(); ok {
+		return map[string]any{"user": user.Username(), "pass": pass}
+	}
+	return map[string]any{"auth_token": user.Username()}
+}
+
+// natsReadInfo reads the INFO greeting of the server
+func natsReadInfo(r *bufio.Reader) (natsInfo, error) {
+	var info natsInfo
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return info, err
+	}
+	payload, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		return info, fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+	if err := json.Unmarshal([]byte(payload), &info); err != nil {
+		return info, fmt.Errorf("error decoding INFO: %w", err)
+	}
+	return info, nil
+}
+
+// natsConnect sends CONNECT with the credentials and waits for the server to
+// accept it
+func natsConnect(r *bufio.Reader, w *bufio.Writer, credentials map[string]any, secure bool) error {
+	connect := map[string]any{"verbose": false, "pedantic": false, "tls_required": secure, "name": "terrastruct-icons", "lang": "go"}
+	for k, v := range credentials {
+		connect[k] = v
+	}
+	data, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "CONNECT %s\r\nPING\r\n", data)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return natsAwait(r, w, "PONG")
+}
+
+// natsAwait reads server messages until want, failing on -ERR and answering
+// the keep-alive PINGs of the server
+func natsAwait(r *bufio.Reader, w *bufio.Writer, want string) error {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == want:
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case line == "PING":
+			w.WriteString("PONG\r\n")
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultQdrantCollection = "icons"
+	defaultQdrantBatch      = 64
+	embedBatch              = 64
+)
+
+// Embedder returns one embedding vector per text, e.g. from an embeddings API
+type Embedder func(ctx context.Context, texts []string) ([][]float32, error)
+
+// QdrantSink upserts a point per icon into a Qdrant collection through its
+// REST API, embedding the icon documents with Embed. Points of icons the run
+// no longer has get a deprecated_at payload field, dropped again when an icon
+// comes back
+type QdrantSink struct {
+	URL string
+	// Collection defaults to icons, prefixed with the namespace of the run
+	Collection string
+	APIKey     string
+	Embed      Embedder
+	// BatchSize is the number of points per request, 64 by default
+	BatchSize int
+	// Client defaults to the client of the run
+	Client *http.Client
+	// HardDelete deletes the points of removed icons instead of deprecating
+	// them
+	HardDelete bool
+	// Incremental only embeds and upserts the icons whose last_modified
+	// changed since the previous run wrote this sink, the rest only get the
+	// run id of this run
+	Incremental bool
+	// EmbeddingModel names the model and version behind Embed, stored with
+	// every point as embedding_model along with embedding_dimensions. In
+	// incremental mode, unchanged icons stored with another model are
+	// embedded again
+	EmbeddingModel string
+	// MaxReembed caps the icons embedded again for a model change per run,
+	// spreading a migration over several runs. 0 means no cap
+	MaxReembed int
+	// NamedVectors stores two named vectors per point instead of one:
+	// document embeds the document and name the display name and aliases,
+	// which short queries match much better. The collection has to be
+	// created with both vectors
+	NamedVectors bool
+}
+
+// Named vectors of a point with QdrantSink.NamedVectors
+const (
+	documentVector = "document"
+	nameVector     = "name"
+)
+
+type qdrantPoint struct {
+	ID string `json:"id"`
+	// Vector is a vector or a map of named vectors
+	Vector  any            `json:"vector"`
+	Payload map[string]any `json:"payload"`
+}
+
+func (s QdrantSink) Name() string {
+	return "qdrant:" + strings.TrimRight(redactURL(s.URL), "/") + "/" + s.collection("")
+}
+
+// collection returns the collection of the sink in namespace ns
+func (s QdrantSink) collection(ns string) string {
+	name := s.Collection
+	if name == "" {
+		name = defaultQdrantCollection
+	}
+	if ns != "" {
+		name = ns + "_" + name
+	}
+	return name
+}
+
+func (s QdrantSink) Write(ctx context.Context, run *SinkRun, icons []*IconPayload) error {
+	if s.Embed == nil {
+		return errors.New("qdrant sink needs an Embedder")
+	}
+	size := s.BatchSize
+	if size < 1 {
+		size = defaultQdrantBatch
+	}
+	icons = run.scoped(icons)
+	var unchanged []*IconPayload
+	if s.Incremental {
+		icons, unchanged = run.unchanged(s.Name(), icons)
+		if len(unchanged) > 0 {
+			stale, err := s.stale(ctx, run.Namespace)
+			if err != nil {
+				return fmt.Errorf("error finding stale embeddings: %w", err)
+			}
+			icons, unchanged = run.invalidate(s.Name(), icons, unchanged, stale, s.MaxReembed)
+		}
+	}
+	for i := 0; i < len(icons); i += size {
+		end := i + size
+		if end > len(icons) {
+			end = len(icons)
+		}
+		if err := s.upsert(ctx, run, icons[i:end]); err != nil {
+			return fmt.Errorf("points %d-%d: %w", i+1, end, err)
+		}
+	}
+	for i := 0; i < len(unchanged); i += size {
+		end := i + size
+		if end > len(unchanged) {
+			end = len(unchanged)
+		}
+		if err := s.touch(ctx, run, unchanged[i:end]); err != nil {
+			return fmt.Errorf("unchanged points %d-%d: %w", i+1, end, err)
+		}
+	}
+	return s.tombstone(ctx, run)
+}
+
+// touch sets the run id of the points of unchanged icons without embedding
+// them again, so tombstone keeps them
+func (s QdrantSink) touch(ctx context.Context, run *SinkRun, icons []*IconPayload) error {
+	ids := make([]string, len(icons))
+	for i, icon := range icons {
+		ids[i] = pointID(run.Namespace, icon.Slug)
+	}
+	return s.post(ctx, http.MethodPost, run.Namespace, "points/payload", map[string]any{
+		"payload": map[string]any{"run_id": run.RunID},
+		"points":  ids,
+	})
+}
+
+// tombstone deprecates, or deletes, the live points of the collection, and
+// provider of partial runs, that were not upserted by the run
+func (s QdrantSink) tombstone(ctx context.Context, run *SinkRun) error {
+	var must []any
+	if run.Provider != "" {
+		must = append(must, map[string]any{"key": "provider", "match": map[string]any{"value": run.Provider}})
+	}
+	filter := map[string]any{
+		"must_not": []any{map[string]any{"key": "run_id", "match": map[string]any{"value": run.RunID}}},
+	}
+	if s.HardDelete {
+		if must != nil {
+			filter["must"] = must
+		}
+		return s.post(ctx, http.MethodPost, run.Namespace, "points/delete", map[string]any{"filter": filter})
+	}
+	filter["must"] = append(must, map[string]any{"is_empty": map[string]any{"key": "deprecated_at"}})
+	return s.post(ctx, http.MethodPost, run.Namespace, "points/payload", map[string]any{
+		"payload": map[string]any{"deprecated_at": time.Now().UTC().Format(time.RFC3339)},
+		"filter":  filter,
+	})
+}
+
+func (s QdrantSink) upsert(ctx context.Context, run *SinkRun, icons []*IconPayload) error {
+	ns := run.Namespace
+	vectors, err := embedIcons(ctx, s.Embed, icons)
+	if err != nil {
+		return err
+	}
+	var names [][]float32
+	if s.NamedVectors {
+		texts := make([]string, len(icons))
+		for i, icon := range icons {
+			texts[i] = nameText(icon)
+		}
+		if names, err = embedTexts(ctx, s.Embed, texts); err != nil {
+			return err
+		}
+	}
+
+	points := make([]qdrantPoint, len(icons))
+	for i, icon := range icons {
+		payload, err := FullProfile.project(icon)
+		if err != nil {
+			return err
+		}
+		fields := make(map[string]any, len(payload)+2)
+		for k, v := range payload {
+			fields[k] = v
+		}
+		fields["run_id"] = run.RunID
+		fields["embedding_model"] = s.EmbeddingModel
+		fields["embedding_dimensions"] = len(vectors[i])
+		if ns != "" {
+			fields["namespace"] = ns
+		}
+		points[i] = qdrantPoint{ID: pointID(ns, icon.Slug), Vector: vectors[i], Payload: fields}
+		if names != nil {
+			points[i].Vector = map[string][]float32{documentVector: vectors[i], nameVector: names[i]}
+		}
+	}
+
+	return s.post(ctx, http.MethodPut, ns, "points", map[string]any{"points": points})
+}
+
+// stale returns the slugs of the points not embedded with EmbeddingModel
+func (s QdrantSink) stale(ctx context.Context, ns string) (map[string]bool, error) {
+	stale := make(map[string]bool)
+	var offset any
+	for {
+		var page struct {
+			Result struct {
+				Points []struct {
+					Payload struct {
+						Slug string `json:"slug"`
+					} `json:"payload"`
+				} `json:"points"`
+				NextPageOffset any `json:"next_page_offset"`
+			} `json:"result"`
+		}
+		body := map[string]any{
+			"filter":       map[string]any{"must_not": []any{map[string]any{"key": "embedding_model", "match": map[string]any{"value": s.EmbeddingModel}}}},
+			"with_payload": []string{"slug"},
+			"with_vector":  false,
+			"limit":        256,
+		}
+		if offset != nil {
+			body["offset"] = offset
+		}
+		if err := s.request(ctx, http.MethodPost, ns, "points/scroll", body, &page); err != nil {
+			return nil, err
+		}
+		for _, p := range page.Result.Points {
+			stale[p.Payload.Slug] = true
+		}
+		if offset = page.Result.NextPageOffset; offset == nil {
+			return stale, nil
+		}
+	}
+}
+
+// post sends body to the points endpoint of the collection of namespace ns
+func (s QdrantSink) post(ctx context.Context, method, ns, endpoint string, body any) error {
+	return s.request(ctx, method, ns, endpoint, body, nil)
+}
+
+// request is post decoding the response into out unless it is nil
+func (s QdrantSink) request(ctx context.Context, method, ns, endpoint string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	u := fmt.Sprintf("%s/collections/%s/%s?wait=true", strings.TrimRight(s.URL, "/"), s.collection(ns), endpoint)
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("api-key", s.APIKey)
+	}
+
+	resp, err := runClient(ctx, s.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("qdrant returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// pointID derives a stable point id from the namespace and slug, so reruns
+// update the points of earlier runs instead of duplicating them
+func pointID(ns, slug string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(namespaced(ns, slug))).String()
+}
+
+// embedIcons embeds the documents of icons, or their descriptions
+func embedIcons(ctx context.Context, embed Embedder, icons []*IconPayload) ([][]float32, error) {
+	texts := make([]string, len(icons))
+	for i, icon := range icons {
+		texts[i] = icon.Document
+		if texts[i] == "" {
+			texts[i] = icon.Description
+		}
+	}
+	return embedTexts(ctx, embed, texts)
+}
+
+// embedTexts embeds texts in batches of embedBatch
+func embedTexts(ctx context.Context, embed Embedder, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += embedBatch {
+		end := i + embedBatch
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := embed(ctx, texts[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("error embedding: %w", err)
+		}
+		if len(batch) != end-i {
+			return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(batch), end-i)
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// nameText is the text of the name vector of icon: its display name and
+// aliases
+func nameText(icon *IconPayload) string {
+	return strings.Join(append([]string{icon.DisplayName}, jsonToArray(icon.Aliases)...), ", ")
+}
@@ -0,0 +1,355 @@
+package icons
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// SQLDialect selects the SQL flavor of an SQLSink
+type SQLDialect string
+
+const (
+	DialectPostgres SQLDialect = "postgres"
+	DialectSQLite   SQLDialect = "sqlite"
+)
+
+// migrationsTable records the migrations applied to a database
+const migrationsTable = "icons_schema_migrations"
+
+// SQLSink upserts the icons of a run into the icons table of DB, keyed by
+// namespace and slug, in a transaction committed with the run. Rows of icons
+// the run no longer has get a deprecated_at timestamp, cleared again when an
+// icon comes back. DB is opened by the caller with a Postgres or SQLite
+// driver; the schema is created and migrated by EnsureSchema
+type SQLSink struct {
+	DB      *sql.DB
+	Dialect SQLDialect
+	// ID tells apart the SQL sinks of a run writing different databases,
+	// it is part of the name that incremental runs track the sink by
+	ID string
+	// Embed fills the pgvector embedding column, Postgres only
+	Embed Embedder
+	// HardDelete deletes the rows of removed icons instead of deprecating them
+	HardDelete bool
+	// Incremental only embeds and upserts the icons whose last_modified
+	// changed since the previous run wrote this sink, the rest only get the
+	// run id of this run
+	Incremental bool
+	// EmbeddingModel names the model and version behind Embed, stored in the
+	// embedding_model column along with embedding_dimensions. In incremental
+	// mode, unchanged icons stored with another model are embedded again
+	EmbeddingModel string
+	// MaxReembed caps the icons embedded again for a model change per run,
+	// spreading a migration over several runs. 0 means no cap
+	MaxReembed int
+
+	mu sync.Mutex
+	tx *sql.Tx
+}
+
+func (s *SQLSink) Name() string {
+	if s.ID != "" {
+		return "sql:" + string(s.Dialect) + ":" + s.ID
+	}
+	return "sql:" + string(s.Dialect)
+}
+
+// migrationSets returns the migration directories applied to the database
+func (s *SQLSink) migrationSets() ([]string, error) {
+	switch s.Dialect {
+	case DialectPostgres:
+		if s.Embed != nil {
+			return []string{"postgres", "pgvector"}, nil
+		}
+		return []string{"postgres"}, nil
+	case DialectSQLite:
+		if s.Embed != nil {
+			return nil, errors.New("embeddings need the postgres dialect with pgvector")
+		}
+		return []string{"sqlite"}, nil
+	}
+	return nil, fmt.Errorf("unknown SQL dialect %q", s.Dialect)
+}
+
+// EnsureSchema applies the embedded migrations the database has not seen
+// yet, each in its own transaction, creating the icons table, its indexes
+// and with Embed the pgvector extension
+func (s *SQLSink) EnsureSchema(ctx context.Context) error {
+	sets, err := s.migrationSets()
+	if err != nil {
+		return err
+	}
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (name TEXT PRIMARY KEY, applied_at TEXT NOT NULL)", migrationsTable)
+	if _, err := s.DB.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("error creating %s: %w", migrationsTable, err)
+	}
+
+	for _, set := range sets {
+		names, err := fs.Glob(migrationFiles, path.Join("migrations", set, "*.up.sql"))
+		if err != nil {
+			return err
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := s.migrate(ctx, name); err != nil {
+				return fmt.Errorf("migration %s: %w", strings.TrimPrefix(name, "migrations/"), err)
+			}
+		}
+	}
+	return nil
+}
+
+// migrate applies the migration file name unless it was applied before
+func (s *SQLSink) migrate(ctx context.Context, name string) error {
+	id := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".up.sql")
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if s.Dialect == DialectPostgres {
+		// serialize concurrent runs migrating the same database
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", migrationsTable); err != nil {
+			return err
+		}
+	}
+	var applied int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE name = %s", migrationsTable, s.placeholder(1))
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	data, err := migrationFiles.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(string(data)) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (name, applied_at) VALUES (%s, CURRENT_TIMESTAMP)", migrationsTable, s.placeholder(1))
+	if _, err := tx.ExecContext(ctx, insert, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// splitStatements splits a migration on the semicolons ending its lines, as
+// not every driver executes several statements at once
+func splitStatements(script string) []string {
+	var stmts []string
+	for _, stmt := range strings.Split(script, ";\n") {
+		if stmt = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt), ";")); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// placeholder returns the n-th bind parameter of the dialect
+func (s *SQLSink) placeholder(n int) string {
+	if s.Dialect == DialectPostgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// upsertSQL inserts an icon or updates the row of its namespace and slug
+func (s *SQLSink) upsertSQL() string {
+	columns := []string{"namespace", "slug", "id", "provider", "category_id", "display_name", "url", "iconify_id", "document", "payload", "run_id"}
+	if s.Embed != nil {
+		columns = append(columns, "embedding", "embedding_model", "embedding_dimensions")
+	}
+	values := make([]string, len(columns))
+	updates := make([]string, 0, len(columns))
+	for i, c := range columns {
+		values[i] = s.placeholder(i + 1)
+		switch c {
+		case "payload":
+			if s.Dialect == DialectPostgres {
+				values[i] += "::jsonb"
+			}
+		case "embedding":
+			values[i] += "::vector"
+		}
+		if c != "namespace" && c != "slug" {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", c, c))
+		}
+	}
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP", "deprecated_at = NULL")
+	return fmt.Sprintf("INSERT INTO icons (%s) VALUES (%s) ON CONFLICT (namespace, slug) DO UPDATE SET %s",
+		strings.Join(columns, ", "), strings.Join(values, ", "), strings.Join(updates, ", "))
+}
+
+// tombstoneSQL deprecates, or deletes, the live rows of the namespace, and
+// provider of partial runs, that were not upserted by the run
+func (s *SQLSink) tombstoneSQL(run *SinkRun) (string, []any) {
+	where := fmt.Sprintf("namespace = %s AND run_id <> %s", s.placeholder(1), s.placeholder(2))
+	args := []any{run.Namespace, run.RunID}
+	if run.Provider != "" {
+		where += " AND provider = " + s.placeholder(3)
+		args = append(args, run.Provider)
+	}
+	if s.HardDelete {
+		return "DELETE FROM icons WHERE " + where, args
+	}
+	return "UPDATE icons SET deprecated_at = CURRENT_TIMESTAMP WHERE " + where + " AND deprecated_at IS NULL", args
+}
+
+func (s *SQLSink) Write(ctx context.Context, run *SinkRun, icons []*IconPayload) error {
+	if s.DB == nil {
+		return errors.New("SQL sink without database")
+	}
+	if err := s.EnsureSchema(ctx); err != nil {
+		return err
+	}
+	icons = run.scoped(icons)
+	var unchanged []*IconPayload
+	if s.Incremental {
+		icons, unchanged = run.unchanged(s.Name(), icons)
+		if s.Embed != nil && len(unchanged) > 0 {
+			stale, err := s.stale(ctx, run.Namespace)
+			if err != nil {
+				return fmt.Errorf("error finding stale embeddings: %w", err)
+			}
+			icons, unchanged = run.invalidate(s.Name(), icons, unchanged, stale, s.MaxReembed)
+		}
+	}
+
+	var vectors [][]float32
+	if s.Embed != nil {
+		var err error
+		if vectors, err = embedIcons(ctx, s.Embed, icons); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, s.upsertSQL())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for i, icon := range icons {
+		payload, err := marshalRaw(icon)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		args := []any{run.Namespace, icon.Slug, icon.ID, icon.Provider, icon.CategoryID, icon.DisplayName,
+			icon.URL, icon.IconifyID, icon.Document, string(payload), run.RunID}
+		if vectors != nil {
+			args = append(args, vectorLiteral(vectors[i]), s.EmbeddingModel, len(vectors[i]))
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error upserting %s: %w", icon.Slug, err)
+		}
+	}
+	if len(unchanged) > 0 {
+		touch, err := tx.PrepareContext(ctx, fmt.Sprintf("UPDATE icons SET run_id = %s WHERE namespace = %s AND slug = %s",
+			s.placeholder(1), s.placeholder(2), s.placeholder(3)))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		defer touch.Close()
+		for _, icon := range unchanged {
+			if _, err := touch.ExecContext(ctx, run.RunID, run.Namespace, icon.Slug); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("error touching %s: %w", icon.Slug, err)
+			}
+		}
+	}
+
+	query, args := s.tombstoneSQL(run)
+	removed, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error deprecating removed icons: %w", err)
+	}
+	if n, err := removed.RowsAffected(); err == nil && n > 0 {
+		log.Printf("🪦 %s: %d removed icons %s", s.Name(), n, tombstoneVerb(s.HardDelete))
+	}
+
+	s.mu.Lock()
+	s.tx = tx
+	s.mu.Unlock()
+	return nil
+}
+
+// stale returns the slugs of the rows of namespace ns not embedded with
+// EmbeddingModel
+func (s *SQLSink) stale(ctx context.Context, ns string) (map[string]bool, error) {
+	query := fmt.Sprintf("SELECT slug FROM icons WHERE namespace = %s AND embedding_model <> %s", s.placeholder(1), s.placeholder(2))
+	rows, err := s.DB.QueryContext(ctx, query, ns, s.EmbeddingModel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	stale := make(map[string]bool)
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		stale[slug] = true
+	}
+	return stale, rows.Err()
+}
+
+func (s *SQLSink) Commit(ctx context.Context) error {
+	tx := s.takeTx()
+	if tx == nil {
+		return nil
+	}
+	return tx.Commit()
+}
+
+func (s *SQLSink) Rollback(ctx context.Context) error {
+	tx := s.takeTx()
+	if tx == nil {
+		return nil
+	}
+	return tx.Rollback()
+}
+
+func (s *SQLSink) takeTx() *sql.Tx {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx := s.tx
+	s.tx = nil
+	return tx
+}
+
+// vectorLiteral formats v as a pgvector literal, e.g. [0.1,0.2]
+func vectorLiteral(v []float32) string {
+	parts := make([]string, len(v))
+	for i, f := range v {
+		parts[i] = strconv.FormatFloat(float64(f), 'f', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
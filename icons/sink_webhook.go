@@ -0,0 +1,185 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultWebhookRetries = 3
+	webhookBackoff        = time.Second
+	// defaultWebhookTolerance is the age of a delivery VerifyWebhook accepts
+	// when no tolerance is given
+	defaultWebhookTolerance = 5 * time.Minute
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookSignatureHeader = "X-Icons-Signature"
+	WebhookTimestampHeader = "X-Icons-Timestamp"
+)
+
+// WebhookSink POSTs the icons of a run to URL as WebhookPayload, in batches
+// of BatchSize icons or all at once. With a Secret every request is signed,
+// see WebhookSignature
+type WebhookSink struct {
+	URL    string
+	Secret string
+	// BatchSize is the number of icons per request, 0 posts the whole run
+	BatchSize int
+	// Retries is the number of retries of a request failing with a network
+	// error, 429 or a 5xx status, 3 by default and none when negative
+	Retries int
+	// Header is sent with every request, e.g. an Authorization header
+	Header http.Header
+	// Client defaults to the client of the run
+	Client *http.Client
+}
+
+// WebhookPayload is the body of a webhook request
+type WebhookPayload struct {
+	RunID     string `json:"run_id"`
+	Namespace string `json:"namespace,omitempty"`
+	// Batch counts from 1 up to Batches
+	Batch   int            `json:"batch"`
+	Batches int            `json:"batches"`
+	Total   int            `json:"total"`
+	Icons   []*IconPayload `json:"icons"`
+}
+
+// WebhookSignature returns the signature sent in X-Icons-Signature for body,
+// sent at the unix timestamp of X-Icons-Timestamp. Receivers should call
+// VerifyWebhook rather than compare it themselves
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook authenticates a webhook delivery on the receiving side. It
+// recomputes the signature of body from the headers of the request, compares
+// it in constant time and rejects timestamps further than tolerance from now,
+// 5 minutes when zero, so captured deliveries cannot be replayed later
+func VerifyWebhook(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		tolerance = defaultWebhookTolerance
+	}
+	timestamp := header.Get(WebhookTimestampHeader)
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", WebhookTimestampHeader, timestamp)
+	}
+	if age := time.Since(time.Unix(sec, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%s is %s off, over the tolerance of %s", WebhookTimestampHeader, age.Round(time.Second), tolerance)
+	}
+	want := WebhookSignature(secret, timestamp, body)
+	if !hmac.Equal([]byte(want), []byte(header.Get(WebhookSignatureHeader))) {
+		return fmt.Errorf("invalid %s", WebhookSignatureHeader)
+	}
+	return nil
+}
+
+func (s WebhookSink) Name() string { return "webhook:" + redactURL(s.URL) }
+
+func (s WebhookSink) Write(ctx context.Context, run *SinkRun, icons []*IconPayload) error {
+	if icons = run.scoped(icons); icons == nil {
+		icons = []*IconPayload{}
+	}
+	size := s.BatchSize
+	if size < 1 || size > len(icons) {
+		size = len(icons)
+	}
+	batches := 1
+	if size > 0 {
+		batches = (len(icons) + size - 1) / size
+	}
+
+	for b := 0; b < batches; b++ {
+		start, end := b*size, (b+1)*size
+		if end > len(icons) {
+			end = len(icons)
+		}
+		body, err := json.Marshal(WebhookPayload{
+			RunID:     run.RunID,
+			Namespace: run.Namespace,
+			Batch:     b + 1,
+			Batches:   batches,
+			Total:     len(icons),
+			Icons:     icons[start:end],
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.deliver(ctx, body); err != nil {
+			return fmt.Errorf("batch %d of %d: %w", b+1, batches, err)
+		}
+	}
+	return nil
+}
+
+// deliver posts body, retrying with a growing backoff while the failure is
+// temporary
+func (s WebhookSink) deliver(ctx context.Context, body []byte) error {
+	retries := s.Retries
+	switch {
+	case retries == 0:
+		retries = defaultWebhookRetries
+	case retries < 0:
+		retries = 0
+	}
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(webhookBackoff * time.Duration(attempt)):
+			}
+		}
+		var retry bool
+		if retry, err = s.post(ctx, body); err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// post sends body once, reporting whether a failure is worth a retry
+func (s WebhookSink) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		req.Header.Set(WebhookSignatureHeader, WebhookSignature(s.Secret, timestamp, body))
+	}
+
+	resp, err := runClient(ctx, s.Client).Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return false, nil
+}
@@ -0,0 +1,146 @@
+package icons
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	collisionsFile = "slug_collisions.json"
+	maxSlugLength  = 64
+)
+
+var (
+	slugCleanRgx = regexp.MustCompile(`[^a-z0-9-]`)
+	dashRunRgx   = regexp.MustCompile(`-{2,}`)
+)
+
+// SlugPolicy controls how slugs are generated from provider and title
+type SlugPolicy struct {
+	// Legacy keeps the original slugs, which may hold empty segments,
+	// repeated dashes and be arbitrarily long
+	Legacy bool
+	// MaxLength caps slugs, longer ones are truncated and suffixed with a
+	// short hash of the full slug
+	MaxLength int
+}
+
+// Slug policies
+var (
+	// SafeSlugs are URL and file system safe, the default
+	SafeSlugs = SlugPolicy{MaxLength: maxSlugLength}
+	// LegacySlugs reproduce the slugs of earlier releases
+	LegacySlugs = SlugPolicy{Legacy: true}
+)
+
+// reservedNames are file names Windows refuses regardless of extension
+var reservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// Slug returns the slug of the icon titled title of provider
+func (p SlugPolicy) Slug(provider, title string) string {
+	if p.Legacy {
+		return generateSlug(provider, title)
+	}
+
+	slug := dashRunRgx.ReplaceAllString(generateSlug(provider, title), "-")
+	slug = strings.Trim(slug, "-")
+	if slug == "" {
+		slug = "icon"
+	}
+	if reservedNames[slug] {
+		slug += "-icon"
+	}
+	if p.MaxLength > len(shortHash(slug))+1 && len(slug) > p.MaxLength {
+		hash := shortHash(slug)
+		slug = strings.TrimRight(slug[:p.MaxLength-len(hash)-1], "-") + "-" + hash
+	}
+	return slug
+}
+
+// SlugCollision reports icons that generated the same slug and how they were
+// disambiguated
+type SlugCollision struct {
+	Slug     string            `json:"slug"`
+	Resolved map[string]string `json:"resolved"`
+}
+
+// resolveSlugCollisions renames icons sharing a slug, keeping the slug of the
+// first icon by URL and suffixing the others with their category or a short
+// hash of their URL
+func resolveSlugCollisions(icons []*IconPayload, timestamp string) []SlugCollision {
+	bySlug := make(map[string][]*IconPayload)
+	taken := make(map[string]bool)
+	for _, icon := range icons {
+		bySlug[icon.Slug] = append(bySlug[icon.Slug], icon)
+		taken[icon.Slug] = true
+	}
+
+	slugs := make([]string, 0, len(bySlug))
+	for slug, group := range bySlug {
+		if len(group) > 1 {
+			slugs = append(slugs, slug)
+		}
+	}
+	sort.Strings(slugs)
+
+	collisions := make([]SlugCollision, 0, len(slugs))
+	for _, slug := range slugs {
+		group := bySlug[slug]
+		sort.Slice(group, func(i, j int) bool { return group[i].URL < group[j].URL })
+
+		collision := SlugCollision{Slug: slug, Resolved: map[string]string{group[0].URL: slug}}
+		for _, icon := range group[1:] {
+			candidate := slug
+			if sub := urlCategory(icon.URL); sub != "" {
+				candidate = slug + "-" + sub
+			}
+			if taken[candidate] {
+				candidate = slug + "-" + shortHash(icon.URL)
+			}
+			taken[candidate] = true
+			icon.Slug = candidate
+			icon.setProvenance(SourceRules, timestamp, "slug")
+			collision.Resolved[icon.URL] = candidate
+		}
+		collisions = append(collisions, collision)
+	}
+	return collisions
+}
+
+// urlCategory returns the slugified parent directory of an icon URL below
+// its top level category
+func urlCategory(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	p, err := url.PathUnescape(u.EscapedPath())
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(path.Clean(p), "/"), "/")
+	if len(segments) < 3 {
+		return ""
+	}
+	return slugify(segments[len(segments)-2])
+}
+
+// slugify lowercases s and keeps only slug characters
+func slugify(s string) string {
+	return strings.Trim(slugCleanRgx.ReplaceAllString(strings.ToLower(strings.ReplaceAll(s, " ", "-")), ""), "-")
+}
+
+// shortHash returns the first 8 hex characters of the SHA-256 of s
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:8]
+}
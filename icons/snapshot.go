@@ -0,0 +1,178 @@
+package icons
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// latestLink points at the newest successful snapshot
+	latestLink = "latest"
+	// snapshotLayout names snapshot directories, sortable and safe on every
+	// filesystem
+	snapshotLayout = "2006-01-02T15-04-05Z"
+
+	// stagingSuffix and previousSuffix name the sibling directories used
+	// while replacing a non-snapshot output directory
+	stagingSuffix  = ".staging"
+	previousSuffix = ".previous"
+)
+
+// snapshotName returns the directory name of a run started at t
+func snapshotName(t time.Time) string {
+	return t.UTC().Format(snapshotLayout)
+}
+
+// isSnapshot reports whether name is a snapshot directory name
+func isSnapshot(name string) bool {
+	_, err := time.Parse(snapshotLayout, name)
+	return err == nil
+}
+
+// Snapshots lists the snapshot directories under outputDir, oldest first
+func Snapshots(outputDir string) ([]string, error) {
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && isSnapshot(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LatestSnapshot returns the snapshot the latest link points at
+func LatestSnapshot(outputDir string) (string, error) {
+	target, err := os.Readlink(filepath.Join(outputDir, latestLink))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
+
+// Rollback points the latest link of outputDir back at snapshot
+func Rollback(outputDir, snapshot string) error {
+	if !isSnapshot(snapshot) {
+		return fmt.Errorf("invalid snapshot name %q", snapshot)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, snapshot)); err != nil {
+		return fmt.Errorf("snapshot %s: %w", snapshot, err)
+	}
+	return linkLatest(outputDir, snapshot)
+}
+
+// linkLatest atomically replaces the latest link of outputDir
+func linkLatest(outputDir, snapshot string) error {
+	tmp := filepath.Join(outputDir, latestLink+".tmp")
+	os.Remove(tmp)
+	if err := os.Symlink(snapshot, tmp); err != nil {
+		return fmt.Errorf("failed to link %s: %w", snapshot, err)
+	}
+	return os.Rename(tmp, filepath.Join(outputDir, latestLink))
+}
+
+// pruneSnapshots keeps the newest keep successful snapshots of outputDir
+// and removes the older ones, never the one latest points at. Failed and
+// partial snapshots do not count toward keep, those older than the oldest
+// kept snapshot are removed and newer ones left for inspection
+func pruneSnapshots(outputDir string, keep int) ([]string, error) {
+	names, err := Snapshots(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	latest, _ := LatestSnapshot(outputDir)
+
+	var removed []string
+	good := 0
+	for i := len(names) - 1; i >= 0; i-- {
+		name := names[i]
+		if snapshotSucceeded(filepath.Join(outputDir, name)) {
+			if good++; good <= keep {
+				continue
+			}
+		} else if good < keep {
+			continue
+		}
+		if name == latest {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(outputDir, name)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
+
+// snapshotSucceeded reports whether the run of the snapshot dir was
+// published: its report is complete and its checksums, written last, exist
+func snapshotSucceeded(dir string) bool {
+	report, err := loadRunReport(dir)
+	if err != nil || report == nil || report.Partial {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, checksumsFile))
+	return err == nil
+}
+
+// checkSwappable rejects runs whose publishing would delete what else is kept
+// in the directory swapDir replaces: the cache, the fixtures or the corpora
+// of namespaces
+func checkSwappable(cfg *Config) error {
+	if cfg.KeepSnapshots > 0 {
+		return nil
+	}
+	root := cfg.namespaceDir()
+	for _, d := range []struct{ name, dir string }{{"cache", cfg.CacheDir}, {"fixture", cfg.FixtureDir}} {
+		if d.dir != "" && withinDir(root, d.dir) {
+			return fmt.Errorf("%s directory %s is inside %s, which every run replaces: move it out", d.name, d.dir, root)
+		}
+	}
+	if cfg.Namespace == "" {
+		if _, err := os.Stat(filepath.Join(root, namespacesFile)); err == nil {
+			return fmt.Errorf("%s holds namespaced corpora, which a run without a namespace would replace: set a namespace or another output directory", root)
+		}
+	}
+	return nil
+}
+
+// withinDir reports whether path is dir or below it
+func withinDir(dir, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// swapDir replaces target with staging. The previous target is moved aside
+// first and restored if staging cannot be moved into place
+func swapDir(staging, target string) error {
+	previous := filepath.Clean(target) + previousSuffix
+	if err := os.RemoveAll(previous); err != nil {
+		return err
+	}
+	if err := os.Rename(target, previous); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to move aside %s: %w", target, err)
+	}
+	if err := os.Rename(staging, target); err != nil {
+		if rerr := os.Rename(previous, target); rerr != nil {
+			return fmt.Errorf("failed to publish %s: %w (restoring previous output: %v)", staging, err, rerr)
+		}
+		return fmt.Errorf("failed to publish %s: %w", staging, err)
+	}
+	return os.RemoveAll(previous)
+}
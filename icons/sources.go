@@ -0,0 +1,373 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+// Source types selectable in SourceConfig
+const (
+	SourceTerrastruct = "terrastruct"
+	SourceIconifySet  = "iconify"
+	SourceLocalDir    = "local"
+	// SourceStack lists the curated languages and frameworks of
+	// packs/stack.yaml
+	SourceStack = "stack"
+	// SourceProducts lists the curated databases and message brokers of
+	// packs/products.yaml
+	SourceProducts = "products"
+	// SourceSaaS lists the curated SaaS and observability vendors of
+	// packs/saas.yaml
+	SourceSaaS = "saas"
+	// SourceCNCF ingests the projects of the CNCF landscape, URL overrides
+	// the landscape.yml read
+	SourceCNCF = "cncf"
+	// SourceFlatSet ingests any flat icon collection described by the YAML
+	// mapping file of Mapping, see FlatSetMapping
+	SourceFlatSet = "flatset"
+	// SourcePlugin runs the source plugin executable of Plugin, see
+	// PluginServer
+	SourcePlugin = "plugin"
+)
+
+// Source produces the pending icons of one catalog
+type Source interface {
+	// Name identifies the source in run reports
+	Name() string
+	// Fetch returns the icons of the catalog
+	Fetch(ctx context.Context, cfg *Config) ([]PendingIcon, error)
+}
+
+// SourceConfig configures a source of a multi-source run
+type SourceConfig struct {
+	Type string
+	Name string
+
+	// URL is the catalog URL of terrastruct sources
+	URL string
+	// Collections are the Iconify prefixes of iconify sources
+	Collections []string
+	// Dir is the directory of local sources
+	Dir string
+	// Mapping is the YAML mapping file of flatset sources
+	Mapping string
+	// Plugin is the executable of plugin sources, run with PluginArgs
+	Plugin     string
+	PluginArgs []string
+	// Strategies are tried in order by terrastruct style sources until one
+	// succeeds, defaulting to the JSON listing, the sitemap and the HTML
+	Strategies []string
+	// ListingURL and SitemapURL default to icons.json and sitemap.xml under
+	// URL
+	ListingURL string
+	SitemapURL string
+	// Pagination makes terrastruct style sources crawl multi-page catalogs
+	Pagination Pagination
+	// Backend fetches the pages of terrastruct style sources, BackendBrowser
+	// renders client-side galleries and waits for WaitSelector to appear
+	Backend      string
+	WaitSelector string
+
+	// RequestDelay and Parallelism override the run limits for this source
+	RequestDelay time.Duration
+	Parallelism  int
+}
+
+// SourceFactory builds a Source from its configuration
+type SourceFactory func(SourceConfig) (Source, error)
+
+var sourceFactories = map[string]SourceFactory{
+	SourceTerrastruct: func(sc SourceConfig) (Source, error) { return &terrastructSource{cfg: sc}, nil },
+	SourceIconifySet: func(sc SourceConfig) (Source, error) {
+		if len(sc.Collections) == 0 {
+			return nil, fmt.Errorf("iconify source %q has no collections", sc.Name)
+		}
+		return &iconifySetSource{cfg: sc}, nil
+	},
+	SourceLocalDir: func(sc SourceConfig) (Source, error) {
+		if sc.Dir == "" {
+			return nil, fmt.Errorf("local source %q has no directory", sc.Name)
+		}
+		return &localDirSource{cfg: sc}, nil
+	},
+	SourceStack:    func(sc SourceConfig) (Source, error) { return &curatedSource{cfg: sc, pack: "stack"}, nil },
+	SourceProducts: func(sc SourceConfig) (Source, error) { return &curatedSource{cfg: sc, pack: "products"}, nil },
+	SourceSaaS:     func(sc SourceConfig) (Source, error) { return &curatedSource{cfg: sc, pack: "saas"}, nil },
+	SourceCNCF:     func(sc SourceConfig) (Source, error) { return &cncfSource{cfg: sc}, nil },
+	SourceFlatSet: func(sc SourceConfig) (Source, error) {
+		if sc.Mapping == "" {
+			return nil, fmt.Errorf("flatset source %q has no mapping", sc.Name)
+		}
+		m, err := LoadFlatSetMapping(sc.Mapping)
+		if err != nil {
+			return nil, err
+		}
+		return &flatSetSource{cfg: sc, mapping: m}, nil
+	},
+	SourcePlugin: func(sc SourceConfig) (Source, error) {
+		if sc.Plugin == "" {
+			return nil, fmt.Errorf("plugin source %q has no executable", sc.Name)
+		}
+		return &pluginSource{cfg: sc}, nil
+	},
+}
+
+// RegisterSource makes a source type available to SourceConfig
+func RegisterSource(typ string, factory SourceFactory) {
+	sourceFactories[typ] = factory
+}
+
+// newSource builds the source described by sc
+func newSource(sc SourceConfig) (Source, error) {
+	factory, ok := sourceFactories[sc.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown source type %q", sc.Type)
+	}
+	return factory(sc)
+}
+
+// sourceName returns the configured name or the type of sc
+func sourceName(sc SourceConfig) string {
+	if sc.Name != "" {
+		return sc.Name
+	}
+	return sc.Type
+}
+
+// limited returns a copy of cfg using the rate limits of sc
+func (sc SourceConfig) limited(cfg *Config) *Config {
+	c := *cfg
+	if sc.RequestDelay > 0 {
+		c.RequestDelay = sc.RequestDelay
+	}
+	if sc.Parallelism > 0 {
+		c.Parallelism = sc.Parallelism
+	}
+	if sc.URL != "" {
+		c.SourceURL = sc.URL
+	}
+	return &c
+}
+
+// terrastructSource scrapes the icons.terrastruct.com catalog
+type terrastructSource struct {
+	cfg SourceConfig
+}
+
+func (s *terrastructSource) Name() string { return sourceName(s.cfg) }
+
+func (s *terrastructSource) Fetch(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	strategies := s.cfg.Strategies
+	if len(strategies) == 0 {
+		strategies = defaultStrategies
+	}
+
+	var errs []error
+	for _, name := range strategies {
+		fetch, ok := s.strategy(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown fetch strategy %q", name)
+		}
+		icons, err := fetch(ctx, cfg)
+		if err == nil {
+			log.Printf("🧭 %s: fetched %d icons via the %s strategy", s.Name(), len(icons), name)
+			return icons, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// scrapeHTML parses icon tiles out of the catalog pages
+func (s *terrastructSource) scrapeHTML(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	transport, release, err := fetchTransport(ctx, cfg, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	c, err := newCollector(cfg, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	var scrapeErr error
+	c.OnError(func(r *colly.Response, err error) {
+		if scrapeErr == nil {
+			scrapeErr = fmt.Errorf("scraping error: %w", err)
+		}
+	})
+
+	links := newIconLinks(cfg.SourceURL)
+	c.OnHTML("div, a", func(e *colly.HTMLElement) {
+		if !isIconElement(e) {
+			return
+		}
+		title := e.Attr("data-search")
+		if title == "" {
+			title = e.Attr("title")
+		}
+		links.add(extractIconLink(e), title)
+	})
+
+	crawler := newCrawler(ctx, c, s.cfg.Pagination)
+	if err := crawler.Crawl(cfg.SourceURL); err != nil {
+		return nil, err
+	}
+	if scrapeErr != nil {
+		return nil, scrapeErr
+	}
+	if pages := crawler.Pages(); pages > 1 {
+		log.Printf("📄 %s: crawled %d pages", s.Name(), pages)
+	}
+	return links.result(cfg.MinExpectedIcons)
+}
+
+// iconifyCollection is the response of the Iconify /collection endpoint
+type iconifyCollection struct {
+	Prefix        string              `json:"prefix"`
+	Uncategorized []string            `json:"uncategorized"`
+	Categories    map[string][]string `json:"categories"`
+}
+
+// iconifySetSource lists whole Iconify collections such as logos or devicon
+type iconifySetSource struct {
+	cfg SourceConfig
+}
+
+func (s *iconifySetSource) Name() string { return sourceName(s.cfg) }
+
+func (s *iconifySetSource) Fetch(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	client := cfg.assetClient()
+	pending := make([]PendingIcon, 0)
+	for _, prefix := range s.cfg.Collections {
+		collection, err := fetchIconifyCollection(ctx, client, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		groups := map[string][]string{"": collection.Uncategorized}
+		for category, names := range collection.Categories {
+			groups[category] = names
+		}
+		categories := make([]string, 0, len(groups))
+		for category := range groups {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+
+		seen := make(map[string]bool)
+		for _, category := range categories {
+			for _, name := range groups[category] {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+				link := url.PathEscape(prefix)
+				if category != "" {
+					link += "%2F" + url.PathEscape(category)
+				}
+				link += "%2F" + url.PathEscape(name) + ".svg"
+				pending = append(pending, PendingIcon{
+					Category:    strings.ToUpper(prefix),
+					Title:       name,
+					Link:        link,
+					URL:         fmt.Sprintf("%s/%s/%s.svg", iconifyAPIURL, prefix, name),
+					DisplayName: cleanDisplayName(name),
+				})
+			}
+		}
+		if cfg.RequestDelay > 0 {
+			time.Sleep(cfg.RequestDelay)
+		}
+	}
+	return pending, nil
+}
+
+func fetchIconifyCollection(ctx context.Context, client *http.Client, prefix string) (*iconifyCollection, error) {
+	u := fmt.Sprintf("%s/collection?prefix=%s", iconifyAPIURL, url.QueryEscape(prefix))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("iconify collection %s: unexpected status %s", prefix, resp.Status)
+	}
+
+	var collection iconifyCollection
+	if err := json.NewDecoder(resp.Body).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("iconify collection %s: %w", prefix, err)
+	}
+	return &collection, nil
+}
+
+// localDirSource reads SVG files from a directory laid out as
+// <provider>/<category>/<name>.svg
+type localDirSource struct {
+	cfg SourceConfig
+}
+
+func (s *localDirSource) Name() string { return sourceName(s.cfg) }
+
+func (s *localDirSource) Fetch(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	root, err := filepath.Abs(s.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]PendingIcon, 0)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".svg") {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		segments := strings.Split(filepath.ToSlash(rel), "/")
+		if len(segments) == 1 {
+			segments = append([]string{s.Name()}, segments...)
+		}
+		for i, seg := range segments {
+			segments[i] = url.PathEscape(seg)
+		}
+
+		title := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		pending = append(pending, PendingIcon{
+			Category:    strings.ToUpper(segments[0]),
+			Title:       title,
+			Link:        strings.Join(segments, "%2F"),
+			URL:         (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(),
+			DisplayName: cleanDisplayName(title),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
@@ -0,0 +1,246 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Fetch strategies of terrastruct style sources
+const (
+	StrategyListing = "listing"
+	StrategySitemap = "sitemap"
+	StrategyHTML    = "html"
+
+	listingPath = "icons.json"
+	sitemapPath = "sitemap.xml"
+	// maxSitemapDepth bounds nested sitemap indexes
+	maxSitemapDepth = 2
+)
+
+// defaultStrategies prefer structured listings over parsing the catalog markup
+var defaultStrategies = []string{StrategyListing, StrategySitemap, StrategyHTML}
+
+// fetchStrategy produces the pending icons of a source in one way
+type fetchStrategy func(ctx context.Context, cfg *Config) ([]PendingIcon, error)
+
+// strategy returns the fetch strategy called name
+func (s *terrastructSource) strategy(name string) (fetchStrategy, bool) {
+	switch name {
+	case StrategyListing:
+		return s.fetchListing, true
+	case StrategySitemap:
+		return s.fetchSitemap, true
+	case StrategyHTML:
+		return s.scrapeHTML, true
+	default:
+		return nil, false
+	}
+}
+
+// iconLinks turns icon links into pending icons, dropping duplicates and
+// applying the testing limit
+type iconLinks struct {
+	base        string
+	seen        map[string]bool
+	perCategory map[string]int
+	icons       []PendingIcon
+	stats       scrapeStats
+}
+
+func newIconLinks(base string) *iconLinks {
+	return &iconLinks{
+		base:        strings.TrimSuffix(base, "/"),
+		seen:        make(map[string]bool),
+		perCategory: make(map[string]int),
+		icons:       make([]PendingIcon, 0),
+	}
+}
+
+// add records the icon at link, an escaped path below the catalog, titled
+// after its file name when title is empty
+func (l *iconLinks) add(link, title string) {
+	l.stats.Elements++
+	category := strings.ToUpper(linkCategory(link))
+	if link == "" || category == "" || l.seen[link] {
+		l.stats.Skipped++
+		return
+	}
+	l.seen[link] = true
+	l.stats.Parsed++
+
+	if testingMode && l.perCategory[category] >= testLimit {
+		return
+	}
+	l.perCategory[category]++
+
+	// titles may be escaped like the link, e.g. Compute%2FEC2
+	if unescaped, err := url.PathUnescape(title); err == nil {
+		title = unescaped
+	}
+	if title == "" {
+		if unescaped, err := url.PathUnescape(link); err == nil {
+			title = strings.TrimSuffix(path.Base(unescaped), path.Ext(unescaped))
+		}
+	}
+	l.icons = append(l.icons, PendingIcon{
+		Category:    category,
+		Title:       title,
+		Link:        link,
+		URL:         fmt.Sprintf("%s/%s", l.base, link),
+		DisplayName: cleanDisplayName(title),
+	})
+}
+
+// result returns the pending icons, or ErrSourceLayoutChanged when fewer than
+// minExpected were recognised
+func (l *iconLinks) result(minExpected int) ([]PendingIcon, error) {
+	if err := l.stats.check(minExpected); err != nil {
+		return nil, err
+	}
+	return l.icons, nil
+}
+
+// relativeLink returns the escaped icon link of raw, an SVG URL or path
+// below base
+func relativeLink(base, raw string) string {
+	if !strings.HasSuffix(strings.ToLower(raw), ".svg") {
+		return ""
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return normalizeIconLink(raw)
+	}
+	u, err := b.Parse(raw)
+	if err != nil || u.Host != b.Host {
+		return ""
+	}
+	rel := strings.TrimPrefix(u.EscapedPath(), strings.TrimSuffix(b.EscapedPath(), "/"))
+	return normalizeIconLink(rel)
+}
+
+// listingEntry is an icon of a JSON listing, given as a path or an object
+type listingEntry struct {
+	Path   string `json:"path"`
+	URL    string `json:"url"`
+	Link   string `json:"link"`
+	Title  string `json:"title"`
+	Name   string `json:"name"`
+	Search string `json:"search"`
+}
+
+func (e *listingEntry) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &e.Path)
+	}
+	type plain listingEntry
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// fetchListing reads a JSON array of icon paths or objects from the listing URL
+func (s *terrastructSource) fetchListing(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	listingURL := s.cfg.ListingURL
+	if listingURL == "" {
+		listingURL = catalogURL(cfg.SourceURL, listingPath)
+	}
+	body, err := getCatalog(ctx, cfg.assetClient(), listingURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []listingEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing listing %s: %w", listingURL, err)
+	}
+	links := newIconLinks(cfg.SourceURL)
+	for _, e := range entries {
+		raw := firstNonEmpty(e.Path, e.URL, e.Link)
+		title := firstNonEmpty(e.Search, e.Title, e.Name)
+		links.add(relativeLink(cfg.SourceURL, raw), title)
+	}
+	return links.result(cfg.MinExpectedIcons)
+}
+
+// sitemap is a sitemap or sitemap index document
+type sitemap struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// fetchSitemap collects the SVG URLs of the sitemap and its nested sitemaps
+func (s *terrastructSource) fetchSitemap(ctx context.Context, cfg *Config) ([]PendingIcon, error) {
+	sitemapURL := s.cfg.SitemapURL
+	if sitemapURL == "" {
+		sitemapURL = catalogURL(cfg.SourceURL, sitemapPath)
+	}
+	client := cfg.assetClient()
+	links := newIconLinks(cfg.SourceURL)
+
+	var walk func(u string, depth int) error
+	walk = func(u string, depth int) error {
+		body, err := getCatalog(ctx, client, u)
+		if err != nil {
+			return err
+		}
+		var sm sitemap
+		if err := xml.Unmarshal(body, &sm); err != nil {
+			return fmt.Errorf("error parsing sitemap %s: %w", u, err)
+		}
+		for _, loc := range sm.URLs {
+			if link := relativeLink(cfg.SourceURL, strings.TrimSpace(loc)); link != "" {
+				links.add(link, "")
+			}
+		}
+		if depth >= maxSitemapDepth {
+			return nil
+		}
+		for _, nested := range sm.Sitemaps {
+			if err := walk(strings.TrimSpace(nested), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(sitemapURL, 0); err != nil {
+		return nil, err
+	}
+	return links.result(cfg.MinExpectedIcons)
+}
+
+// catalogURL returns name resolved under the catalog URL
+func catalogURL(base, name string) string {
+	return strings.TrimSuffix(base, "/") + "/" + name
+}
+
+// getCatalog fetches a listing or sitemap document
+func getCatalog(ctx context.Context, client *http.Client, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", rawURL, resp.Status)
+	}
+	return readLimited(resp.Body, maxAssetSize)
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,373 @@
+package icons
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// GenerateStream runs the pipeline icon by icon: every icon is enriched,
+// post-processed and appended to the provider and corpus files as soon as it
+// is ready and in input order, so memory stays flat however large the corpus
+// grows and reruns write the same files. Stages that need the whole corpus at
+// once are skipped: colliding slugs get a hash suffix, and families,
+// categories.json, the quality report, assertions, diffs, category files,
+// profiles, exports and sinks are not produced
+func GenerateStream(ctx context.Context, cfg *Config) (report *RunReport, err error) {
+	defer servePprof(cfg.PprofAddr)()
+	if err := startRun(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	run, err := openRun(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil && run.staging != "" {
+			log.Printf("⚠️  Kept previous output, partial run left in %s", run.staging)
+		}
+	}()
+	cfg = run.cfg
+
+	report = run.report()
+	pendingIcons, err := collectPending(ctx, cfg, report)
+	if err != nil {
+		return report, err
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	stages, err := newIconStages(ctx, cfg, timestamp)
+	if err != nil {
+		return report, err
+	}
+	defer stages.iconify.close()
+	defer stages.wasm.close(ctx)
+	writers, err := newStreamWriters(cfg.OutputDir, cfg.CanonicalJSON)
+	if err != nil {
+		return report, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := cfg.Parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	// jobs are queued in input order and their results written in that order,
+	// at most workers icons ahead of the writer
+	jobs := make(chan *streamJob)
+	queue := make(chan *streamJob, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				job.out <- stages.run(ctx, job)
+			}
+		}()
+	}
+	go func() {
+		defer close(queue)
+		defer close(jobs)
+		for _, p := range pendingIcons {
+			job := stages.claimSlug(p)
+			select {
+			case queue <- job:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				close(job.out)
+				return
+			}
+		}
+	}()
+
+	// icons still in flight when the run is interrupted are written too
+	var writeErr error
+	done := make(map[string]bool)
+	for job := range queue {
+		icon, ok := <-job.out
+		if !ok || writeErr != nil {
+			continue
+		}
+		if writeErr = writers.write(icon); writeErr != nil {
+			cancel()
+		}
+		done[icon.URL] = true
+	}
+	if err := writers.close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if writeErr != nil {
+		return report, writeErr
+	}
+	if err := ctx.Err(); err != nil {
+		if ierr := run.interrupted(report, pendingIcons, done); ierr != nil {
+			log.Printf("⚠️  Failed to write checkpoint: %v", ierr)
+		}
+		return report, err
+	}
+
+	report.Total = writers.total
+	log.Printf("🎯 Streamed %d icons to %s", writers.total, filepath.Join(cfg.OutputDir, jsonFile))
+	if err := run.publish(report); err != nil {
+		return report, err
+	}
+
+	log.Println("✅ Generation complete!")
+	return report, nil
+}
+
+// iconStages holds what the per-icon stages of a streamed run share
+type iconStages struct {
+	cfg       *Config
+	timestamp string
+	client    *http.Client
+	ontology  ontology
+	overrides map[string]Override
+	usage     *UsageStats
+	doc       *template.Template
+	iconify   *iconifyVerifier
+	wasm      wasmRuleSet
+
+	// mu guards the ontology, slugs is only used by claimSlug
+	mu    sync.Mutex
+	slugs map[string]bool
+}
+
+func newIconStages(ctx context.Context, cfg *Config, timestamp string) (*iconStages, error) {
+	s := &iconStages{cfg: cfg, timestamp: timestamp, slugs: make(map[string]bool)}
+	var err error
+	if s.ontology, err = loadOntology(); err != nil {
+		return nil, err
+	}
+	if cfg.OverridesFile != "" {
+		if s.overrides, err = LoadOverrides(cfg.OverridesFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.UsageFile != "" {
+		if s.usage, err = LoadUsage(cfg.UsageFile); err != nil {
+			return nil, err
+		}
+	}
+	if s.doc, err = newDocumentTemplate(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.DownloadAssets {
+		s.client = cfg.assetClient()
+	}
+	if s.wasm, err = compileWasmRules(ctx, cfg); err != nil {
+		return nil, err
+	}
+	s.iconify = newIconifyVerifier(cfg)
+	return s, nil
+}
+
+// run takes a pending icon through enrichment and every per-icon stage
+func (s *iconStages) run(ctx context.Context, job *streamJob) *IconPayload {
+	cfg, ts, p := s.cfg, s.timestamp, job.pending
+
+	var enrichment LLMEnrichmentResponse
+	status := EnrichmentDisabled
+	var err error
+	if useLLMEnrichment && llmServiceAvailable {
+		status = EnrichmentLLM
+		enrichment, err = getLLMEnrichment(cfg.client(), p.Category, p.Title, p.DisplayName)
+	}
+	icon := createIconPayload(p, enrichment, cfg.SlugPolicy, cfg.Seed, ts)
+	icon.EnrichmentStatus = status
+	if err != nil {
+		recoverEnrichment(cfg.client(), retryItem{Pending: p, Icon: icon}, ts)
+	}
+	if id, ok := s.iconify.verify(ctx, p.Category, p.Title, icon.Slug); ok {
+		icon.IconifyID = id
+		icon.setProvenance(SourceIconify, ts, "iconify_id")
+	}
+
+	icons := []*IconPayload{icon}
+	annotateIcons(ctx, icons, s.wasm, ts, discardf)
+
+	s.mu.Lock()
+	if c := s.ontology.classify(icon); c != nil {
+		icon.CategoryID = c.ID
+		icon.setProvenance(SourceRules, ts, "category_id")
+	}
+	s.mu.Unlock()
+	if job.slug != job.base {
+		icon.Slug = job.slug
+		icon.setProvenance(SourceRules, ts, "slug")
+	}
+
+	if cfg.DownloadAssets && ctx.Err() == nil {
+		if err := downloadAsset(ctx, s.client, cfg.OutputDir, icon, ts); err != nil {
+			log.Printf("⚠️  Asset %s: %v", icon.Slug, err)
+		}
+		downloadVariants(ctx, s.client, cfg.OutputDir, icon)
+		if err := recolorIcons(cfg, icons, ts, discardf); err != nil {
+			log.Printf("⚠️  Monochrome variant %s: %v", icon.Slug, err)
+		}
+		if cfg.RequestDelay > 0 {
+			time.Sleep(cfg.RequestDelay)
+		}
+	}
+	inferShapes(cfg.OutputDir, icons, ts)
+
+	reviewIcons(cfg, icons, s.usage, s.overrides, ts, discardf)
+	if err := renderDocuments(s.doc, icons, ts); err != nil {
+		log.Printf("⚠️  Document %s: %v", icon.Slug, err)
+	}
+	scoreQuality(icons, map[string]bool{icon.Slug: job.slug != job.base})
+	return icon
+}
+
+// streamJob is a pending icon of a streamed run with the slug claimed for it
+// in input order, its result is sent on out
+type streamJob struct {
+	pending PendingIcon
+	base    string
+	slug    string
+	out     chan *IconPayload
+}
+
+// claimSlug returns a job for p, suffixing its slug with a short hash of its
+// URL when an earlier icon took it; jobs are claimed in input order so
+// reruns pick the same slugs
+func (s *iconStages) claimSlug(p PendingIcon) *streamJob {
+	job := &streamJob{pending: p, base: s.cfg.SlugPolicy.Slug(p.Category, p.Title), out: make(chan *IconPayload, 1)}
+	job.slug = job.base
+	if s.slugs[job.slug] {
+		job.slug += "-" + shortHash(pendingURL(p))
+	}
+	s.slugs[job.slug] = true
+	return job
+}
+
+// discardf drops the stage summaries of single icons
+func discardf(string, ...any) {}
+
+// streamWriters append icons to the corpus file and their provider file
+type streamWriters struct {
+	dir       string
+	all       *jsonArrayWriter
+	providers map[string]*jsonArrayWriter
+	canonical bool
+	total     int
+}
+
+func newStreamWriters(dir string, canonical bool) (*streamWriters, error) {
+	all, err := createJSONArray(filepath.Join(dir, jsonFile), canonical)
+	if err != nil {
+		return nil, err
+	}
+	return &streamWriters{dir: dir, all: all, providers: make(map[string]*jsonArrayWriter), canonical: canonical}, nil
+}
+
+func (s *streamWriters) write(icon *IconPayload) error {
+	key := providerDir(icon.Provider)
+	w, ok := s.providers[key]
+	if !ok {
+		if err := os.MkdirAll(filepath.Join(s.dir, key), 0750); err != nil {
+			return err
+		}
+		var err error
+		if w, err = createJSONArray(filepath.Join(s.dir, key, fmt.Sprintf("%s.json", key)), s.canonical); err != nil {
+			return err
+		}
+		s.providers[key] = w
+	}
+	if err := w.Append(icon); err != nil {
+		return err
+	}
+	if err := s.all.Append(icon); err != nil {
+		return err
+	}
+	s.total++
+	return nil
+}
+
+func (s *streamWriters) close() error {
+	var first error
+	for key, w := range s.providers {
+		if err := w.Close(); err != nil && first == nil {
+			first = err
+		}
+		log.Printf("📝 %s: %d icons", key, w.n)
+	}
+	if err := s.all.Close(); err != nil && first == nil {
+		first = err
+	}
+	return first
+}
+
+// jsonArrayWriter appends values to a JSON array file as they arrive, in the
+// layout of writeJSON, canonical like Config.writeJSON with canonical set
+type jsonArrayWriter struct {
+	f         *os.File
+	w         *bufio.Writer
+	n         int
+	canonical bool
+}
+
+func createJSONArray(path string, canonical bool) (*jsonArrayWriter, error) {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", path, err)
+	}
+	w := bufio.NewWriter(f)
+	if _, err := w.WriteString("["); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &jsonArrayWriter{f: f, w: w, canonical: canonical}, nil
+}
+
+// Append writes v as the next array element
+func (a *jsonArrayWriter) Append(v any) error {
+	data, err := marshalRaw(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if a.canonical {
+		value, err := decodeNumbers(data)
+		if err != nil {
+			return err
+		}
+		writeCanonical(&buf, value, "  ")
+	} else if err := json.Indent(&buf, data, "  ", "  "); err != nil {
+		return err
+	}
+	if a.n > 0 {
+		a.w.WriteString(",")
+	}
+	a.w.WriteString("\n  ")
+	_, err = a.w.Write(buf.Bytes())
+	a.n++
+	return err
+}
+
+// Close terminates the array and closes the file
+func (a *jsonArrayWriter) Close() error {
+	if a.n > 0 {
+		a.w.WriteString("\n")
+	}
+	a.w.WriteString("]\n")
+	if err := a.w.Flush(); err != nil {
+		a.f.Close()
+		return err
+	}
+	return a.f.Close()
+}
@@ -0,0 +1,179 @@
+package icons
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxSuggestions is the number of completions kept per trie node
+	maxSuggestions = 10
+	// maxSuggestDepth caps the depth of the trie, longer prefixes filter the
+	// completions of the node at that depth
+	maxSuggestDepth = 24
+)
+
+// Suggestion is a completion of a typed prefix: a display name or alias of
+// an icon
+type Suggestion struct {
+	// Text is the completed display name or alias
+	Text        string `json:"text"`
+	Slug        string `json:"slug"`
+	DisplayName string `json:"display_name"`
+	Provider    string `json:"provider"`
+}
+
+// suggestTrie maps every word start of the display names and aliases of a
+// corpus to its best completions, precomputed so lookups only walk the
+// prefix
+type suggestTrie struct {
+	root *suggestNode
+}
+
+type suggestNode struct {
+	children map[rune]*suggestNode
+	top      []suggestEntry
+}
+
+// suggestEntry is a completion ranked within a node
+type suggestEntry struct {
+	icon *IconPayload
+	text string
+	// key is the lower cased text from the word the prefix matched
+	key string
+	// start reports whether the prefix matched the start of text
+	start bool
+}
+
+// better ranks completions matching the start of their text first, then
+// popular icons, then short texts, then icons with short names, which are
+// usually the service rather than one of its parts
+func (e suggestEntry) better(other suggestEntry) bool {
+	switch {
+	case e.start != other.start:
+		return e.start
+	case e.icon.Popularity != other.icon.Popularity:
+		return e.icon.Popularity > other.icon.Popularity
+	case len(e.text) != len(other.text):
+		return len(e.text) < len(other.text)
+	case e.text != other.text:
+		return e.text < other.text
+	case len(e.icon.DisplayName) != len(other.icon.DisplayName):
+		return len(e.icon.DisplayName) < len(other.icon.DisplayName)
+	}
+	return e.icon.Slug < other.icon.Slug
+}
+
+// newSuggestTrie indexes the display names and aliases of icons at every
+// word start, so "ec" completes Amazon EC2
+func newSuggestTrie(icons []*IconPayload) *suggestTrie {
+	t := &suggestTrie{root: &suggestNode{}}
+	for _, icon := range icons {
+		texts := append([]string{icon.DisplayName}, jsonToArray(icon.Aliases)...)
+		for _, text := range texts {
+			text = strings.Join(strings.Fields(text), " ")
+			lower := strings.ToLower(text)
+			for _, i := range wordStarts(lower) {
+				t.insert(suggestEntry{icon: icon, text: text, key: lower[i:], start: i == 0})
+			}
+		}
+	}
+	return t
+}
+
+// wordStarts returns the byte offsets of the words of s
+func wordStarts(s string) []int {
+	var starts []int
+	prev := ' '
+	for i, r := range s {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+			starts = append(starts, i)
+		}
+		prev = r
+	}
+	return starts
+}
+
+// insert adds e to the nodes of the prefixes of its key
+func (t *suggestTrie) insert(e suggestEntry) {
+	n := t.root
+	depth := 0
+	for _, r := range e.key {
+		if depth == maxSuggestDepth {
+			break
+		}
+		child := n.children[r]
+		if child == nil {
+			if n.children == nil {
+				n.children = make(map[rune]*suggestNode)
+			}
+			child = &suggestNode{}
+			n.children[r] = child
+		}
+		n = child
+		depth++
+		n.add(e)
+	}
+}
+
+// add keeps e if it ranks among the best maxSuggestions completions of n,
+// one per icon
+func (n *suggestNode) add(e suggestEntry) {
+	for i, existing := range n.top {
+		if existing.icon != e.icon {
+			continue
+		}
+		if !e.better(existing) {
+			return
+		}
+		n.top = append(n.top[:i], n.top[i+1:]...)
+		break
+	}
+	i := sort.Search(len(n.top), func(i int) bool { return e.better(n.top[i]) })
+	if i == maxSuggestions {
+		return
+	}
+	n.top = append(n.top, suggestEntry{})
+	copy(n.top[i+1:], n.top[i:])
+	n.top[i] = e
+	if len(n.top) > maxSuggestions {
+		n.top = n.top[:maxSuggestions]
+	}
+}
+
+// Suggest returns up to limit completions of prefix, at most 10, ranked by
+// the popularity of their icons as of loading the dataset
+func (d *Dataset) Suggest(prefix string, limit int) []Suggestion {
+	prefix = strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	suggestions := make([]Suggestion, 0)
+	if prefix == "" || d.suggest == nil {
+		return suggestions
+	}
+	if limit <= 0 || limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+
+	n := d.suggest.root
+	depth := 0
+	for _, r := range prefix {
+		if depth == maxSuggestDepth {
+			break
+		}
+		if n = n.children[r]; n == nil {
+			return suggestions
+		}
+		depth++
+	}
+	for _, e := range n.top {
+		if len(suggestions) == limit {
+			break
+		}
+		if !strings.HasPrefix(e.key, prefix) {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{Text: e.text, Slug: e.icon.Slug, DisplayName: e.icon.DisplayName, Provider: e.icon.Provider})
+	}
+	return suggestions
+}
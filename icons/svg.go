@@ -0,0 +1,79 @@
+package icons
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	svgOpenRgx    = regexp.MustCompile(`(?s)<svg\b[^>]*>`)
+	svgCloseRgx   = regexp.MustCompile(`(?s)</svg>\s*$`)
+	svgViewBoxRgx = regexp.MustCompile(`viewBox\s*=\s*["']([^"']+)["']`)
+	svgWidthRgx   = regexp.MustCompile(`\swidth\s*=\s*["']([0-9.]+)`)
+	svgHeightRgx  = regexp.MustCompile(`\sheight\s*=\s*["']([0-9.]+)`)
+	svgPrologRgx  = regexp.MustCompile(`(?s)<\?xml.*?\?>|<!DOCTYPE.*?>|<!--.*?-->`)
+)
+
+// svgDoc is a parsed SVG root element
+type svgDoc struct {
+	Open    string
+	Body    string
+	MinX    float64
+	MinY    float64
+	Width   float64
+	Height  float64
+	ViewBox string
+}
+
+// parseSVG splits an SVG document into its root element and inner markup
+func parseSVG(data []byte) (svgDoc, bool) {
+	src := strings.TrimSpace(svgPrologRgx.ReplaceAllString(string(data), ""))
+	loc := svgOpenRgx.FindStringIndex(src)
+	if loc == nil {
+		return svgDoc{}, false
+	}
+	doc := svgDoc{Open: src[loc[0]:loc[1]]}
+	body := src[loc[1]:]
+	if end := svgCloseRgx.FindStringIndex(body); end != nil {
+		body = body[:end[0]]
+	}
+	doc.Body = strings.TrimSpace(body)
+
+	if m := svgViewBoxRgx.FindStringSubmatch(doc.Open); m != nil {
+		fields := strings.Fields(strings.ReplaceAll(m[1], ",", " "))
+		if len(fields) == 4 {
+			doc.MinX, _ = strconv.ParseFloat(fields[0], 64)
+			doc.MinY, _ = strconv.ParseFloat(fields[1], 64)
+			doc.Width, _ = strconv.ParseFloat(fields[2], 64)
+			doc.Height, _ = strconv.ParseFloat(fields[3], 64)
+		}
+	}
+	if doc.Width == 0 {
+		if m := svgWidthRgx.FindStringSubmatch(doc.Open); m != nil {
+			doc.Width, _ = strconv.ParseFloat(m[1], 64)
+		}
+	}
+	if doc.Height == 0 {
+		if m := svgHeightRgx.FindStringSubmatch(doc.Open); m != nil {
+			doc.Height, _ = strconv.ParseFloat(m[1], 64)
+		}
+	}
+	if doc.Width == 0 || doc.Height == 0 {
+		doc.Width, doc.Height = 64, 64
+	}
+	doc.ViewBox = strings.Join([]string{
+		formatFloat(doc.MinX), formatFloat(doc.MinY), formatFloat(doc.Width), formatFloat(doc.Height),
+	}, " ")
+	return doc, true
+}
+
+// inline returns the document as a single line SVG
+func (d svgDoc) inline() string {
+	body := strings.Join(strings.Fields(d.Body), " ")
+	return `<svg xmlns="http://www.w3.org/2000/svg" viewBox="` + d.ViewBox + `">` + body + `</svg>`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
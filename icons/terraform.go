@@ -0,0 +1,90 @@
+package icons
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// terraformType returns the resource type of a Terraform resource type or
+// address, e.g. module.net.aws_subnet.private[0] yields aws_subnet
+func terraformType(address string) string {
+	parts := strings.Split(address, ".")
+	for i := 0; i < len(parts); i++ {
+		switch {
+		case parts[i] == "module":
+			i++
+		case parts[i] == "data":
+		default:
+			return strings.TrimSpace(parts[i])
+		}
+	}
+	return ""
+}
+
+// terraformIcon returns the icon of a resource type, falling back to the
+// longest known type prefixing it, e.g. aws_s3_bucket_policy maps to the
+// icon of aws_s3_bucket
+func (d *Dataset) terraformIcon(typ string) (*IconPayload, bool) {
+	for t := typ; t != ""; {
+		if icon, ok := d.byTerraform[t]; ok {
+			return icon, true
+		}
+		i := strings.LastIndex(t, "_")
+		if i < 0 {
+			break
+		}
+		t = t[:i]
+	}
+	return nil, false
+}
+
+// MapTerraformResources resolves Terraform resource types or addresses, as
+// listed by terraform state list, to icons using their machine identifiers.
+// Resources without a matching icon are left out
+func (d *Dataset) MapTerraformResources(state []string) map[string]*IconPayload {
+	mapped := make(map[string]*IconPayload, len(state))
+	for _, address := range state {
+		if icon, ok := d.terraformIcon(terraformType(address)); ok {
+			mapped[address] = icon
+		}
+	}
+	return mapped
+}
+
+// tfState is the part of a Terraform state file listing resources
+type tfState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module string `json:"module"`
+		Mode   string `json:"mode"`
+		Type   string `json:"type"`
+		Name   string `json:"name"`
+	} `json:"resources"`
+}
+
+// TerraformStateResources returns the managed resource addresses of a
+// Terraform state file, ready for MapTerraformResources
+func TerraformStateResources(r io.Reader) ([]string, error) {
+	var state tfState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("error parsing terraform state: %w", err)
+	}
+	if state.Version < 4 {
+		return nil, fmt.Errorf("unsupported terraform state version %d", state.Version)
+	}
+
+	addresses := make([]string, 0, len(state.Resources))
+	for _, res := range state.Resources {
+		if res.Mode != "managed" {
+			continue
+		}
+		address := res.Type + "." + res.Name
+		if res.Module != "" {
+			address = res.Module + "." + address
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
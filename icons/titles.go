@@ -0,0 +1,76 @@
+package icons
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// defaultStopTokens are noise words stripped from scraped titles
+	defaultStopTokens = []string{"icon", "icons", "logo", "logos", "filled", "symbol", "glyph", "svg", "png"}
+
+	// dimensionTokenRgx matches size tokens such as 64x64, 32px or @2x
+	dimensionTokenRgx = regexp.MustCompile(`(?i)^(\d+x\d+|\d+px|@\dx)$`)
+	titleSepRgx       = regexp.MustCompile(`[\s_-]+`)
+)
+
+// stopTokens matches noise tokens of titles case-insensitively
+type stopTokens map[string]bool
+
+func newStopTokens(tokens []string) stopTokens {
+	set := make(stopTokens, len(tokens))
+	for _, t := range tokens {
+		set[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return set
+}
+
+func (s stopTokens) match(token string) bool {
+	return s[strings.ToLower(token)] || dimensionTokenRgx.MatchString(token)
+}
+
+// strip removes noise tokens from title, keeping the separators between the
+// remaining words; a title made only of noise is kept as is
+func (s stopTokens) strip(title string) string {
+	parts := titleSepRgx.Split(strings.TrimSpace(title), -1)
+	seps := titleSepRgx.FindAllString(strings.TrimSpace(title), -1)
+
+	var b strings.Builder
+	kept := 0
+	for i, part := range parts {
+		if part == "" || s.match(part) {
+			continue
+		}
+		if kept > 0 {
+			b.WriteString(seps[i-1])
+		}
+		b.WriteString(part)
+		kept++
+	}
+	if kept == 0 {
+		return title
+	}
+	return b.String()
+}
+
+// stripTitleNoise removes stop tokens from the titles and display names of
+// pending icons before slugs are generated, preserving the original title
+func stripTitleNoise(pending []PendingIcon, tokens []string) int {
+	if len(tokens) == 0 {
+		return 0
+	}
+	stop := newStopTokens(tokens)
+	stripped := 0
+	for i := range pending {
+		p := &pending[i]
+		title := stop.strip(p.Title)
+		if title == p.Title {
+			continue
+		}
+		p.RawTitle = p.Title
+		p.Title = title
+		p.DisplayName = stop.strip(p.DisplayName)
+		stripped++
+	}
+	return stripped
+}
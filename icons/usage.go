@@ -0,0 +1,182 @@
+package icons
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	usageFile = "usage.json"
+	// usageWeight is the share of popularity learned from usage, the rest
+	// comes from the static popular services list
+	usageWeight = 0.7
+	// queryHitWeight credits the top result of a query without a click
+	queryHitWeight = 0.25
+)
+
+// UsageStats holds hit counts learned from search analytics
+type UsageStats struct {
+	UpdatedAt string             `json:"updated_at"`
+	Hits      map[string]float64 `json:"hits"`
+}
+
+// UsageEvent is a line of a click or query log, Slug is the clicked icon and
+// Query the search that led to it; a query without a click credits its top
+// result
+type UsageEvent struct {
+	Slug     string  `json:"slug,omitempty"`
+	Query    string  `json:"query,omitempty"`
+	Provider string  `json:"provider,omitempty"`
+	Count    float64 `json:"count,omitempty"`
+}
+
+// LoadUsage reads persisted usage stats, a missing file yields empty stats
+func LoadUsage(path string) (*UsageStats, error) {
+	stats := &UsageStats{Hits: make(map[string]float64)}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading usage %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("error parsing usage %s: %w", path, err)
+	}
+	if stats.Hits == nil {
+		stats.Hits = make(map[string]float64)
+	}
+	return stats, nil
+}
+
+// Save writes the usage stats to path
+func (u *UsageStats) Save(path string) error {
+	u.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return writeJSON(path, u)
+}
+
+// RecordHit counts a retrieval of the icon with slug and reports whether the
+// slug exists
+func (d *Dataset) RecordHit(slug string) bool {
+	return d.recordHits(slug, 1)
+}
+
+func (d *Dataset) recordHits(slug string, n float64) bool {
+	if _, ok := d.bySlug[slug]; !ok {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.usage == nil {
+		d.usage = &UsageStats{Hits: make(map[string]float64)}
+	}
+	d.usage.Hits[slug] += n
+	return true
+}
+
+// ImportUsageLog counts the JSON lines click and query events of r and
+// returns how many were credited to an icon
+func (d *Dataset) ImportUsageLog(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	credited := 0
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var event UsageEvent
+		if err := json.Unmarshal([]byte(text), &event); err != nil {
+			return credited, fmt.Errorf("error parsing usage log line %d: %w", line, err)
+		}
+		count := event.Count
+		if count <= 0 {
+			count = 1
+		}
+
+		slug := event.Slug
+		if slug == "" && event.Query != "" {
+			if match := d.Lookup(event.Query, event.Provider); match != nil {
+				slug = match.Icon.Slug
+				count *= queryHitWeight
+			}
+		}
+		if slug != "" && d.recordHits(slug, count) {
+			credited++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return credited, fmt.Errorf("error reading usage log: %w", err)
+	}
+	return credited, nil
+}
+
+// MergeUsage adds previously persisted hit counts, e.g. from LoadUsage, to
+// the dataset
+func (d *Dataset) MergeUsage(stats *UsageStats) {
+	for slug, n := range stats.Hits {
+		d.recordHits(slug, n)
+	}
+}
+
+// Usage returns a copy of the hit counts recorded so far
+func (d *Dataset) Usage() *UsageStats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	stats := &UsageStats{Hits: make(map[string]float64)}
+	if d.usage != nil {
+		stats.UpdatedAt = d.usage.UpdatedAt
+		for slug, n := range d.usage.Hits {
+			stats.Hits[slug] = n
+		}
+	}
+	return stats
+}
+
+// RecomputePopularity blends the recorded usage into the popularity of every
+// icon, so frequently retrieved icons rank higher
+func (d *Dataset) RecomputePopularity() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.usage == nil {
+		return 0
+	}
+	return applyUsage(d.Icons, d.usage, time.Now().UTC().Format(time.RFC3339))
+}
+
+// applyUsage sets the popularity of icons with hits to a blend of the static
+// score and their log scaled share of the most used icon
+func applyUsage(icons []*IconPayload, usage *UsageStats, timestamp string) int {
+	var max float64
+	for _, n := range usage.Hits {
+		max = math.Max(max, n)
+	}
+	if max <= 0 {
+		return 0
+	}
+
+	applied := 0
+	for _, icon := range icons {
+		hits := usage.Hits[icon.Slug]
+		if hits <= 0 {
+			continue
+		}
+		learned := math.Log1p(hits) / math.Log1p(max)
+		static := float64(calculatePopularity(icon.DisplayName))
+		popularity := float32((1-usageWeight)*static + usageWeight*learned)
+		icon.Popularity = float32(int(popularity*qualityScale+0.5)) / qualityScale
+		icon.setProvenance(SourceUsage, timestamp, "popularity")
+		applied++
+	}
+	return applied
+}
@@ -0,0 +1,403 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validation rules, as reported in ValidationIssue.Rule and listed in
+// ValidationRules.Warnings
+const (
+	RuleSlug          = "slug"
+	RuleDuplicateSlug = "duplicate_slug"
+	RuleRequired      = "required"
+	RuleEnum          = "enum"
+	RulePattern       = "pattern"
+	RuleUnique        = "unique"
+	RuleIconify       = "iconify"
+)
+
+// Validation severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+const (
+	// iconifyBatch is the number of icons looked up per Iconify API request
+	iconifyBatch = 50
+	// maxIconSetSize bounds the answer to one lookup, the SVG bodies of
+	// iconifyBatch icons
+	maxIconSetSize = 8 << 20
+)
+
+// ValidationRules configures Validate, fields are named by their JSON name,
+// e.g. display_name
+type ValidationRules struct {
+	// SlugPattern is a regular expression every slug must match
+	SlugPattern string `yaml:"slug_pattern"`
+	// Required fields must not be empty, lists such as tags need an entry
+	Required []string `yaml:"required"`
+	// Enums restrict fields to the listed values when set
+	Enums map[string][]string `yaml:"enums"`
+	// Patterns are regular expressions fields must match when set
+	Patterns map[string]string `yaml:"patterns"`
+	// Unique fields must differ between icons, slugs always must
+	Unique []string `yaml:"unique"`
+	// Iconify looks up every Iconify ID in the Iconify API to catch dead IDs
+	Iconify bool `yaml:"iconify"`
+	// Client is used for the Iconify lookups, a client with a timeout by
+	// default
+	Client *http.Client `yaml:"-"`
+	// Warnings lists the rules reported as warnings, which do not fail
+	// validation
+	Warnings []string `yaml:"warnings"`
+}
+
+// DefaultValidationRules checks slugs and the fields diagrams rely on
+func DefaultValidationRules() *ValidationRules {
+	return &ValidationRules{
+		SlugPattern: `^[a-z0-9]+(-[a-z0-9]+)*$`,
+		Required:    []string{"id", "slug", "iconify_id", "provider", "display_name", "url"},
+	}
+}
+
+// LoadValidationRules reads validation rules from a YAML file
+func LoadValidationRules(path string) (*ValidationRules, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading rules %s: %w", path, err)
+	}
+	var rules ValidationRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("error parsing rules %s: %w", path, err)
+	}
+	return &rules, nil
+}
+
+// ValidationIssue is a rule an icon breaks
+type ValidationIssue struct {
+	Slug     string `json:"slug"`
+	Rule     string `json:"rule"`
+	Field    string `json:"field,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ValidationReport is the result of Validate
+type ValidationReport struct {
+	Dir      string            `json:"dir"`
+	Icons    int               `json:"icons"`
+	Errors   int               `json:"errors"`
+	Warnings int               `json:"warnings"`
+	Issues   []ValidationIssue `json:"issues"`
+}
+
+// Failed reports whether any rule reported as an error was broken
+func (r *ValidationReport) Failed() bool {
+	return r.Errors > 0
+}
+
+// validator holds the compiled rules of a validation
+type validator struct {
+	rules    *ValidationRules
+	slug     *regexp.Regexp
+	patterns map[string]*regexp.Regexp
+	enums    map[string]map[string]bool
+	warnings map[string]bool
+	report   *ValidationReport
+}
+
+// Validate lints the output in dir against rules, with
+// DefaultValidationRules when rules is nil. It reports every broken rule
+// instead of stopping at the first, and only fails when the output cannot be
+// read or the Iconify API cannot be reached
+func Validate(ctx context.Context, dir string, rules *ValidationRules) (*ValidationReport, error) {
+	if rules == nil {
+		rules = DefaultValidationRules()
+	}
+	v, err := newValidator(rules)
+	if err != nil {
+		return nil, err
+	}
+	dir, icons, err := readCorpus(dir)
+	if err != nil {
+		return nil, err
+	}
+	v.report.Dir, v.report.Icons = dir, len(icons)
+
+	seen := make(map[string]bool, len(icons))
+	unique := make(map[string]map[string]string, len(rules.Unique))
+	for _, field := range rules.Unique {
+		unique[field] = make(map[string]string)
+	}
+	for i, icon := range icons {
+		if icon == nil {
+			v.add(ValidationIssue{Slug: fmt.Sprintf("#%d", i+1), Rule: RuleRequired, Field: "slug", Message: "empty icon"})
+			continue
+		}
+		if seen[icon.Slug] && icon.Slug != "" {
+			v.add(ValidationIssue{Slug: icon.Slug, Rule: RuleDuplicateSlug, Field: "slug", Value: icon.Slug, Message: "slug is used by another icon"})
+		}
+		seen[icon.Slug] = true
+		if err := v.check(icon, unique); err != nil {
+			return nil, err
+		}
+	}
+
+	if rules.Iconify {
+		if err := v.checkIconify(ctx, icons); err != nil {
+			return nil, err
+		}
+	}
+	return v.report, nil
+}
+
+func newValidator(rules *ValidationRules) (*validator, error) {
+	v := &validator{
+		rules:    rules,
+		patterns: make(map[string]*regexp.Regexp, len(rules.Patterns)),
+		enums:    make(map[string]map[string]bool, len(rules.Enums)),
+		warnings: make(map[string]bool, len(rules.Warnings)),
+		report:   &ValidationReport{Issues: []ValidationIssue{}},
+	}
+	if rules.SlugPattern != "" {
+		rgx, err := regexp.Compile(rules.SlugPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slug pattern: %w", err)
+		}
+		v.slug = rgx
+	}
+	for field, pattern := range rules.Patterns {
+		rgx, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of %s: %w", field, err)
+		}
+		v.patterns[field] = rgx
+	}
+	for field, values := range rules.Enums {
+		v.enums[field] = make(map[string]bool, len(values))
+		for _, value := range values {
+			v.enums[field][value] = true
+		}
+	}
+	for _, rule := range rules.Warnings {
+		v.warnings[rule] = true
+	}
+
+	known := iconFields()
+	fields := append(append([]string{}, rules.Required...), rules.Unique...)
+	fields = append(fields, sortedKeys(rules.Enums)...)
+	for _, field := range append(fields, sortedKeys(rules.Patterns)...) {
+		if !known[field] && !strings.HasPrefix(field, extensionPrefix) {
+			return nil, fmt.Errorf("validation rules: unknown field %q", field)
+		}
+	}
+	return v, nil
+}
+
+func (v *validator) add(issue ValidationIssue) {
+	issue.Severity = SeverityError
+	if v.warnings[issue.Rule] {
+		issue.Severity = SeverityWarning
+		v.report.Warnings++
+	} else {
+		v.report.Errors++
+	}
+	v.report.Issues = append(v.report.Issues, issue)
+}
+
+// check applies the field rules to icon, unique maps the values of unique
+// fields seen so far to their icons
+func (v *validator) check(icon *IconPayload, unique map[string]map[string]string) error {
+	fields, err := fieldValues(icon)
+	if err != nil {
+		return err
+	}
+	if v.slug != nil && icon.Slug != "" && !v.slug.MatchString(icon.Slug) {
+		v.add(ValidationIssue{Slug: icon.Slug, Rule: RuleSlug, Field: "slug", Value: icon.Slug, Message: fmt.Sprintf("slug does not match %s", v.slug)})
+	}
+	for _, field := range v.rules.Required {
+		if len(fields[field]) == 0 {
+			v.add(ValidationIssue{Slug: icon.Slug, Rule: RuleRequired, Field: field, Message: field + " is empty"})
+		}
+	}
+	for _, field := range sortedKeys(v.enums) {
+		for _, value := range fields[field] {
+			if !v.enums[field][value] {
+				v.add(ValidationIssue{Slug: icon.Slug, Rule: RuleEnum, Field: field, Value: value, Message: fmt.Sprintf("%s is not one of %s", field, strings.Join(v.rules.Enums[field], ", "))})
+			}
+		}
+	}
+	for _, field := range sortedKeys(v.patterns) {
+		for _, value := range fields[field] {
+			if !v.patterns[field].MatchString(value) {
+				v.add(ValidationIssue{Slug: icon.Slug, Rule: RulePattern, Field: field, Value: value, Message: fmt.Sprintf("%s does not match %s", field, v.patterns[field])})
+			}
+		}
+	}
+	for _, field := range v.rules.Unique {
+		for _, value := range fields[field] {
+			if other, ok := unique[field][value]; ok && other != icon.Slug {
+				v.add(ValidationIssue{Slug: icon.Slug, Rule: RuleUnique, Field: field, Value: value, Message: fmt.Sprintf("%s is also used by %s", field, other)})
+				continue
+			}
+			unique[field][value] = icon.Slug
+		}
+	}
+	return nil
+}
+
+// fieldValues returns the values of the fields of icon by JSON name, lists
+// such as tags give a value per entry and empty fields none
+func fieldValues(icon *IconPayload) (map[string][]string, error) {
+	data, err := json.Marshal(icon)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string][]string, len(raw))
+	for field, value := range raw {
+		switch value := value.(type) {
+		case nil:
+		case string:
+			if strings.HasPrefix(value, "[") {
+				if list := jsonToArray(value); list != nil {
+					fields[field] = list
+					continue
+				}
+			}
+			if value != "" {
+				fields[field] = []string{value}
+			}
+		case []any:
+			for _, item := range value {
+				fields[field] = append(fields[field], fmt.Sprint(item))
+			}
+		case map[string]any:
+			if len(value) > 0 {
+				data, _ := json.Marshal(value)
+				fields[field] = []string{string(data)}
+			}
+		default:
+			fields[field] = []string{fmt.Sprint(value)}
+		}
+	}
+	return fields, nil
+}
+
+// checkIconify looks up the Iconify IDs of icons in batches per icon set,
+// reporting the IDs the API does not know
+func (v *validator) checkIconify(ctx context.Context, icons []*IconPayload) error {
+	bySet := make(map[string][]*IconPayload)
+	for _, icon := range icons {
+		if icon == nil || icon.IconifyID == "" {
+			continue
+		}
+		prefix, _, ok := strings.Cut(icon.IconifyID, ":")
+		if !ok {
+			v.add(ValidationIssue{Slug: icon.Slug, Rule: RuleIconify, Field: "iconify_id", Value: icon.IconifyID, Message: "iconify_id is not of the form <set>:<name>"})
+			continue
+		}
+		bySet[prefix] = append(bySet[prefix], icon)
+	}
+
+	for _, prefix := range sortedKeys(bySet) {
+		set := bySet[prefix]
+		for start := 0; start < len(set); start += iconifyBatch {
+			batch := set[start:min(start+iconifyBatch, len(set))]
+			names := make([]string, len(batch))
+			for i, icon := range batch {
+				_, names[i], _ = strings.Cut(icon.IconifyID, ":")
+			}
+			known, err := iconifyKnown(ctx, v.client(), prefix, names)
+			if err != nil {
+				return fmt.Errorf("error checking Iconify IDs of %s: %w", prefix, err)
+			}
+			for i, icon := range batch {
+				if !known[names[i]] {
+					v.add(ValidationIssue{Slug: icon.Slug, Rule: RuleIconify, Field: "iconify_id", Value: icon.IconifyID, Message: "iconify_id does not exist in Iconify"})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// client returns the client of the Iconify lookups
+func (v *validator) client() *http.Client {
+	if v.rules.Client != nil {
+		return v.rules.Client
+	}
+	return defaultHTTPClient
+}
+
+// iconifyKnown returns which of names exist in the Iconify icon set prefix,
+// an unknown set knows none
+func iconifyKnown(ctx context.Context, client *http.Client, prefix string, names []string) (map[string]bool, error) {
+	u := fmt.Sprintf("%s/%s.json?icons=%s", iconifyAPIURL, url.PathEscape(prefix), url.QueryEscape(strings.Join(names, ",")))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	known := make(map[string]bool, len(names))
+	if resp.StatusCode == http.StatusNotFound {
+		return known, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := readLimited(resp.Body, maxIconSetSize)
+	if err != nil {
+		return nil, err
+	}
+	// the API answers 404 as a bare number with status 200 for unknown sets
+	if strings.TrimSpace(string(data)) == "404" {
+		return known, nil
+	}
+	var set struct {
+		Icons   map[string]json.RawMessage `json:"icons"`
+		Aliases map[string]json.RawMessage `json:"aliases"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("error decoding icon set: %w", err)
+	}
+	for name := range set.Icons {
+		known[name] = true
+	}
+	for name := range set.Aliases {
+		known[name] = true
+	}
+	return known, nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
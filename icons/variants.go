@@ -0,0 +1,121 @@
+package icons
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// variantSuffixRgx matches the filename suffixes providers use for
+// alternative renderings of an icon
+var variantSuffixRgx = regexp.MustCompile(`(?i)[-_ ](light-bg|dark-bg|light|dark|outlined|outline|filled|fill|colou?r|white|black|mono|monochrome)$`)
+
+// variantNames normalizes suffix spellings
+var variantNames = map[string]string{
+	"outlined":   "outline",
+	"fill":       "filled",
+	"colour":     "color",
+	"monochrome": "mono",
+}
+
+// IconVariant is an alternative rendering of an icon
+type IconVariant struct {
+	Name          string `json:"name"`
+	Title         string `json:"title"`
+	URL           string `json:"url"`
+	LocalPath     string `json:"local_path,omitempty"`
+	ContentSHA256 string `json:"content_sha256,omitempty"`
+}
+
+// splitVariant returns the title without its variant suffix and the
+// normalized variant name, empty when title has no suffix
+func splitVariant(title string) (string, string) {
+	m := variantSuffixRgx.FindStringSubmatchIndex(title)
+	if m == nil || m[0] == 0 {
+		return title, ""
+	}
+	name := strings.ToLower(title[m[2]:m[3]])
+	if n, ok := variantNames[name]; ok {
+		name = n
+	}
+	return title[:m[0]], name
+}
+
+// groupVariants merges pending icons that are variants of the same icon into
+// one entry. The unsuffixed icon is kept as the entry when present, otherwise
+// the first variant; every suffixed icon is listed in Variants
+func groupVariants(pending []PendingIcon) ([]PendingIcon, int) {
+	index := make(map[string]int)
+	grouped := make([]PendingIcon, 0, len(pending))
+	merged := 0
+
+	for _, p := range pending {
+		base, name := splitVariant(p.Title)
+		key := p.Category + "/" + strings.Join(linkHierarchy(p.Link), "/") + "/" + strings.ToLower(base)
+
+		var variant *IconVariant
+		if name != "" {
+			variant = &IconVariant{Name: name, Title: p.Title, URL: pendingURL(p)}
+		}
+
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(grouped)
+			if variant != nil {
+				p.Title = base
+				p.DisplayName = cleanDisplayName(base)
+				p.Variants = append(p.Variants, *variant)
+			}
+			grouped = append(grouped, p)
+			continue
+		}
+
+		merged++
+		entry := &grouped[i]
+		if variant != nil {
+			entry.Variants = append(entry.Variants, *variant)
+			continue
+		}
+		// the unsuffixed icon replaces a variant-only entry
+		p.Variants = entry.Variants
+		*entry = p
+	}
+	return grouped, merged
+}
+
+// pendingURL returns the artwork URL of p
+func pendingURL(p PendingIcon) string {
+	if p.URL != "" {
+		return p.URL
+	}
+	return sourceURL + "/" + p.Link
+}
+
+// downloadVariants fetches the artwork of the variants of icon next to its
+// own asset
+func downloadVariants(ctx context.Context, client *http.Client, outDir string, icon *IconPayload) {
+	for i := range icon.Variants {
+		v := &icon.Variants[i]
+		data, err := fetchAsset(ctx, client, v.URL)
+		if err != nil {
+			log.Printf("⚠️  Variant %s/%s: %v", icon.Slug, v.Name, err)
+			continue
+		}
+		rel := filepath.Join(providerDir(icon.Provider), assetsDir, icon.Slug+"."+v.Name+".svg")
+		path := filepath.Join(outDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			log.Printf("⚠️  Variant %s/%s: %v", icon.Slug, v.Name, err)
+			continue
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			log.Printf("⚠️  Variant %s/%s: %v", icon.Slug, v.Name, err)
+			continue
+		}
+		v.LocalPath = filepath.ToSlash(rel)
+		v.ContentSHA256 = contentHash(data)
+	}
+}
@@ -0,0 +1,267 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	wasmRulesFile = "wasm_rules.yaml"
+	// defaultWasmTimeout bounds a rule on one icon
+	defaultWasmTimeout = time.Second
+	// defaultWasmMemoryMB bounds the memory of a rule instance
+	defaultWasmMemoryMB = 16
+)
+
+// protectedWasmFields identify an icon, rules cannot set them
+var protectedWasmFields = map[string]bool{"id": true, "slug": true, "provider": true, "provenance": true}
+
+// WasmRule runs a WebAssembly module on every icon to compute fields without
+// recompiling the generator. The module is a WASI command reading
+// {"icon": ..., "config": ...} from stdin and writing an object of the
+// fields it sets to stdout, in their output JSON form. It runs sandboxed,
+// without filesystem, network or environment
+type WasmRule struct {
+	Name string `yaml:"name"`
+	// Module is the .wasm file, relative to the rules file
+	Module string `yaml:"module"`
+	// Fields are the fields the rule may set, output field names or x_
+	// prefixed extensions
+	Fields []string `yaml:"fields"`
+	// Providers restricts the rule to providers given by key or display
+	// name, all when empty
+	Providers []string `yaml:"providers,omitempty"`
+	// Timeout bounds the rule on one icon, 1s by default
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// MemoryMB bounds the memory of the module, 16 by default
+	MemoryMB int `yaml:"memory_mb,omitempty"`
+	// Config is passed to the module with every icon
+	Config map[string]any `yaml:"config,omitempty"`
+}
+
+// WasmRules is a WASM rules file, its rules run in order
+type WasmRules struct {
+	Rules []WasmRule `yaml:"rules"`
+}
+
+// WasmEngine compiles WASM modules, see RegisterWasmEngine
+type WasmEngine interface {
+	Compile(ctx context.Context, wasm []byte, memoryMB int) (WasmModule, error)
+}
+
+// WasmModule is a compiled WASI command. Run instantiates it with stdin and
+// returns its stdout, it is called concurrently
+type WasmModule interface {
+	Run(ctx context.Context, stdin []byte) ([]byte, error)
+	Close(ctx context.Context) error
+}
+
+// wasmEngine runs WASM rules, registered by building with -tags wazero
+var wasmEngine WasmEngine
+
+// RegisterWasmEngine sets the engine running WASM rules
+func RegisterWasmEngine(e WasmEngine) {
+	wasmEngine = e
+}
+
+// LoadWasmRules reads a WASM rules file, a missing file yields nil
+func LoadWasmRules(path string) (*WasmRules, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading wasm rules %s: %w", path, err)
+	}
+
+	var rules WasmRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("error parsing wasm rules %s: %w", path, err)
+	}
+	known := iconFields()
+	for i, r := range rules.Rules {
+		if r.Name == "" || r.Module == "" {
+			return nil, fmt.Errorf("wasm rule %d of %s needs a name and a module", i+1, path)
+		}
+		if len(r.Fields) == 0 {
+			return nil, fmt.Errorf("wasm rule %s sets no fields", r.Name)
+		}
+		for _, f := range r.Fields {
+			if protectedWasmFields[f] || (!known[f] && !strings.HasPrefix(f, extensionPrefix)) {
+				return nil, fmt.Errorf("wasm rule %s cannot set field %q", r.Name, f)
+			}
+		}
+		if !filepath.IsAbs(r.Module) {
+			rules.Rules[i].Module = filepath.Join(filepath.Dir(path), r.Module)
+		}
+	}
+	return &rules, nil
+}
+
+// wasmRule is a compiled WasmRule
+type wasmRule struct {
+	WasmRule
+	module    WasmModule
+	fields    map[string]bool
+	providers map[string]bool
+}
+
+// wasmRuleSet are the compiled rules of a run
+type wasmRuleSet []*wasmRule
+
+// compileWasmRules compiles the rules of cfg, nil when there are none
+func compileWasmRules(ctx context.Context, cfg *Config) (wasmRuleSet, error) {
+	if cfg.WasmRulesFile == "" {
+		return nil, nil
+	}
+	rules, err := LoadWasmRules(cfg.WasmRulesFile)
+	if err != nil || rules == nil || len(rules.Rules) == 0 {
+		return nil, err
+	}
+	if wasmEngine == nil {
+		return nil, fmt.Errorf("wasm rules of %s require building with -tags wazero", cfg.WasmRulesFile)
+	}
+
+	set := make(wasmRuleSet, 0, len(rules.Rules))
+	for _, r := range rules.Rules {
+		wasm, err := os.ReadFile(r.Module)
+		if err != nil {
+			set.close(ctx)
+			return nil, fmt.Errorf("error reading wasm rule %s: %w", r.Name, err)
+		}
+		if r.MemoryMB <= 0 {
+			r.MemoryMB = defaultWasmMemoryMB
+		}
+		if r.Timeout <= 0 {
+			r.Timeout = defaultWasmTimeout
+		}
+		module, err := wasmEngine.Compile(ctx, wasm, r.MemoryMB)
+		if err != nil {
+			set.close(ctx)
+			return nil, fmt.Errorf("error compiling wasm rule %s: %w", r.Name, err)
+		}
+		rule := &wasmRule{WasmRule: r, module: module, fields: make(map[string]bool), providers: make(map[string]bool)}
+		for _, f := range r.Fields {
+			rule.fields[f] = true
+		}
+		for _, p := range r.Providers {
+			rule.providers[strings.ToLower(p)] = true
+		}
+		set = append(set, rule)
+	}
+	return set, nil
+}
+
+// run applies the rules to icons in order, a failing rule is logged and
+// skipped for that icon. It returns the number of icons changed
+func (set wasmRuleSet) run(ctx context.Context, icons []*IconPayload, timestamp string) int {
+	changed := 0
+	for _, icon := range icons {
+		updated := false
+		for _, r := range set {
+			if !r.matches(icon) {
+				continue
+			}
+			ok, err := r.apply(ctx, icon, timestamp)
+			if err != nil {
+				log.Printf("⚠️  WASM rule %s on %s: %v", r.Name, icon.Slug, err)
+				continue
+			}
+			updated = updated || ok
+		}
+		if updated {
+			changed++
+		}
+	}
+	return changed
+}
+
+func (set wasmRuleSet) close(ctx context.Context) {
+	for _, r := range set {
+		r.module.Close(ctx)
+	}
+}
+
+// matches reports whether the rule runs on icon
+func (r *wasmRule) matches(icon *IconPayload) bool {
+	if len(r.providers) == 0 || r.providers[strings.ToLower(icon.Provider)] {
+		return true
+	}
+	p, ok := Providers.ByDisplayName(icon.Provider)
+	return ok && r.providers[p.Key]
+}
+
+// wasmInput is the stdin of a rule
+type wasmInput struct {
+	Icon   *IconPayload   `json:"icon"`
+	Config map[string]any `json:"config,omitempty"`
+}
+
+// apply runs the rule on icon and sets the fields it returns
+func (r *wasmRule) apply(ctx context.Context, icon *IconPayload, timestamp string) (bool, error) {
+	in, err := json.Marshal(wasmInput{Icon: icon, Config: r.Config})
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	out, err := r.module.Run(ctx, in)
+	if err != nil {
+		return false, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return false, nil
+	}
+
+	var updates map[string]json.RawMessage
+	if err := json.Unmarshal(out, &updates); err != nil {
+		return false, fmt.Errorf("invalid output: %w", err)
+	}
+	if len(updates) == 0 {
+		return false, nil
+	}
+	provenance := make([]string, 0, len(updates))
+	for field := range updates {
+		if !r.fields[field] {
+			return false, fmt.Errorf("sets %s outside its fields", field)
+		}
+		if strings.HasPrefix(field, extensionPrefix) {
+			field = "extensions"
+		}
+		provenance = append(provenance, field)
+	}
+
+	current, err := json.Marshal(icon)
+	if err != nil {
+		return false, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(current, &fields); err != nil {
+		return false, err
+	}
+	for field, value := range updates {
+		fields[field] = value
+	}
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return false, err
+	}
+	var next IconPayload
+	if err := json.Unmarshal(merged, &next); err != nil {
+		return false, fmt.Errorf("invalid value: %w", err)
+	}
+	*icon = next
+	icon.setProvenance(r.Name, timestamp, provenance...)
+	return true, nil
+}
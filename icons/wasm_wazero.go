@@ -0,0 +1,72 @@
+//go:build wazero
+
+package icons
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// wasmPageSize is the size of a WebAssembly memory page
+const wasmPageSize = 64 << 10
+
+func init() { RegisterWasmEngine(wazeroEngine{}) }
+
+// wazeroEngine runs WASM rules with wazero, a runtime per rule so that its
+// memory limit applies to it alone
+type wazeroEngine struct{}
+
+func (wazeroEngine) Compile(ctx context.Context, wasm []byte, memoryMB int) (WasmModule, error) {
+	config := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(memoryMB << 20 / wasmPageSize)).
+		WithCloseOnContextDone(true)
+	runtime := wazero.NewRuntimeWithConfig(ctx, config)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	compiled, err := runtime.CompileModule(ctx, wasm)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	return &wazeroModule{runtime: runtime, compiled: compiled}, nil
+}
+
+// wazeroModule instantiates a compiled rule per icon, anonymous so instances
+// can run concurrently. Instances get no filesystem, environment or args
+type wazeroModule struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+}
+
+func (m *wazeroModule) Run(ctx context.Context, stdin []byte) ([]byte, error) {
+	var stdout bytes.Buffer
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithStdin(bytes.NewReader(stdin)).
+		WithStdout(&stdout).
+		WithStderr(os.Stderr)
+	mod, err := m.runtime.InstantiateModule(ctx, m.compiled, config)
+	if mod != nil {
+		mod.Close(ctx)
+	}
+	var exit *sys.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 0 {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+func (m *wazeroModule) Close(ctx context.Context) error {
+	return m.runtime.Close(ctx)
+}
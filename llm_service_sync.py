@@ -6,6 +6,7 @@ Implements senior developer recommendations for production-grade icon classifica
 
 from flask import Flask, request, jsonify
 import json
+import os
 import re
 import sys
 import asyncio
@@ -15,6 +16,13 @@ import time
 
 app = Flask(__name__)
 
+# LLM endpoint settings, read from the environment so no key lives in the source
+LLM_SETTINGS = {
+    "base_url": os.environ.get("LLM_BASE_URL", "https://backend.v3.codemateai.dev/v2"),
+    "api_key": os.environ["LLM_API_KEY"],
+    "model": os.environ.get("LLM_MODEL", "openai/web_chat"),
+}
+
 # Enhanced tool definition with is_container and expanded categories
 TOOLS = [{
     "type": "function",
@@ -66,6 +74,11 @@ TOOLS = [{
                 "brand_color": {
                     "type": "string",
                     "description": "Official HEX color code (e.g., #FF9900 for AWS)"
+                },
+                "confidence": {
+                    "type": "object",
+                    "additionalProperties": {"type": "number", "minimum": 0, "maximum": 1},
+                    "description": "How sure you are of each field above, keyed by field name, from 0 (guess) to 1 (certain)"
                 }
             },
             "required": [
@@ -202,9 +215,7 @@ Call classify_icon with complete metadata."""
         
         # Use synchronous completion with strict tool calling
         response = completion(
-            base_url="https://backend.v3.codemateai.dev/v2",
-            api_key="988fe7dd-5a67-4c93-a435-abdb03bd383c",
-            model="openai/web_chat",
+            **LLM_SETTINGS,
             messages=messages,
             tools=TOOLS,
             tool_choice={"type": "function", "function": {"name": "classify_icon"}},
@@ -236,6 +247,7 @@ Call classify_icon with complete metadata."""
             result.setdefault("is_container", False)
             result.setdefault("aliases", [])
             result.setdefault("tags", [provider.lower()])
+            result.setdefault("confidence", {})
             
             return result
         
@@ -290,9 +302,7 @@ Call classify_icon with complete metadata."""
         
         # Use asynchronous completion
         response = await acompletion(
-            base_url="https://backend.v3.codemateai.dev/v2",
-            api_key="988fe7dd-5a67-4c93-a435-abdb03bd383c",
-            model="openai/web_chat",
+            **LLM_SETTINGS,
             messages=messages,
             tools=TOOLS,
             tool_choice={"type": "function", "function": {"name": "classify_icon"}},
@@ -320,6 +330,7 @@ Call classify_icon with complete metadata."""
             result.setdefault("is_container", False)
             result.setdefault("aliases", [])
             result.setdefault("tags", [provider.lower()])
+            result.setdefault("confidence", {})
             
             return result
         
@@ -415,6 +426,94 @@ def batch_classify_endpoint():
         return jsonify({"error": str(e)}), 500
 
 
+QUERIES_TOOL = [{
+    "type": "function",
+    "function": {
+        "name": "icon_queries",
+        "description": "Realistic search queries users type when looking for an architecture icon",
+        "parameters": {
+            "type": "object",
+            "properties": {
+                "queries": {
+                    "type": "array",
+                    "items": {"type": "string"},
+                    "description": "Short natural language queries, mixing exact names, abbreviations and descriptions of the need"
+                }
+            },
+            "required": ["queries"]
+        }
+    }
+}]
+
+
+def generate_queries(icon: dict, n: int) -> list:
+    """
+    Generate n realistic user queries that should retrieve an icon
+
+    Args:
+        icon: slug, provider, display_name, technical_intent and aliases
+        n: Number of queries
+
+    Returns:
+        list: Queries, empty when the LLM failed
+    """
+    user_prompt = f"""Write {n} different search queries a developer would type into an architecture diagram tool to find this icon:
+
+**Provider:** {icon.get('provider', '')}
+**Icon:** {icon.get('display_name', '')}
+**Intent:** {icon.get('technical_intent', '')}
+**Aliases:** {', '.join(icon.get('aliases') or [])}
+
+Mix exact product names, abbreviations and descriptions of what the user needs without naming the product, e.g. "queue for async jobs". Keep each under 8 words.
+
+Call icon_queries with the queries."""
+
+    try:
+        response = completion(
+            **LLM_SETTINGS,
+            messages=[{"role": "user", "content": user_prompt}],
+            tools=QUERIES_TOOL,
+            tool_choice={"type": "function", "function": {"name": "icon_queries"}},
+            temperature=0.7,  # Higher temperature for varied phrasings
+        )
+        if response.choices and response.choices[0].message.tool_calls:
+            args_str = response.choices[0].message.tool_calls[0].function.arguments
+            json_match = re.search(r'\{[\s\S]*\}', args_str)
+            result = json.loads(json_match.group(0) if json_match else args_str)
+            return [q for q in result.get("queries", []) if isinstance(q, str)][:n]
+        raise ValueError("No tool calls in LLM response")
+    except Exception as e:
+        print(f"[ERROR] Query generation failed for {icon.get('slug', '')}: {e}", file=sys.stderr)
+        return []
+
+
+@app.route('/queries', methods=['POST'])
+def queries_endpoint():
+    """
+    Synthetic query generation endpoint for retrieval training data
+
+    Expected payload:
+    {
+        "n": 5,
+        "icons": [{"slug": "aws-amazon-rds", "provider": "AWS", "display_name": "Amazon RDS"}]
+    }
+    """
+    try:
+        data = request.json
+        icons = data.get('icons', [])
+        n = int(data.get('n', 5))
+
+        if not icons or not isinstance(icons, list):
+            return jsonify({"error": "Expected 'icons' array in request"}), 400
+
+        results = list(executor.map(lambda icon: generate_queries(icon, n), icons))
+        return jsonify({"results": results})
+
+    except Exception as e:
+        print(f"[ERROR] queries endpoint: {e}", file=sys.stderr)
+        return jsonify({"error": str(e)}), 500
+
+
 @app.route('/health', methods=['GET'])
 def health():
     """Health check endpoint with service info"""
@@ -427,7 +526,8 @@ def health():
             "Container identification",
             "RAG semantic profiles",
             "Batch processing support",
-            "Brand color management"
+            "Brand color management",
+            "Synthetic query generation"
         ]
     })
 
@@ -440,6 +540,7 @@ if __name__ == '__main__':
     print("   GET  /health        - Health check with service info")
     print("   POST /classify      - Single icon classification")
     print("   POST /batch         - Batch icon classification (parallel)")
+    print("   POST /queries       - Synthetic search queries per icon")
     print("")
     print("🔧 Features:")
     print("   ✓ Expanded categories (17 types)")
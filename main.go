@@ -1,13 +1,135 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/tf2d2/terrastruct-icons/icons"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "audit":
+			os.Exit(audit(os.Args[2:]))
+		case "search":
+			os.Exit(search(os.Args[2:]))
+		case "browse":
+			os.Exit(browse(os.Args[2:]))
+		case "validate":
+			os.Exit(validate(os.Args[2:]))
+		case "eval":
+			os.Exit(eval(os.Args[2:]))
+		case "review":
+			os.Exit(review(os.Args[2:]))
+		case "serve":
+			os.Exit(serve(os.Args[2:]))
+		case "import-usage":
+			os.Exit(importUsage(os.Args[2:]))
+		}
+	}
 	if err := icons.Generate(); err != nil {
+		log.Printf("❌ %v", err)
 		os.Exit(1)
 	}
 }
+
+// audit prints the repair plan of an output directory, exiting with 1 when
+// it found issues and 2 when it could not run
+func audit(args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	remote := fs.Bool("remote", false, "also fetch the URL of every icon")
+	fs.Parse(args)
+	dir := "output"
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+
+	report, err := icons.Audit(context.Background(), dir, icons.AuditOptions{Remote: *remote})
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+	e.Encode(report)
+	if len(report.Repairs) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// search prints the icons matching a query, from a dataset directory or a
+// search endpoint
+func search(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	provider := fs.String("provider", "", "only match icons of this provider")
+	limit := fs.Int("limit", 10, "maximum number of matches, 0 for all")
+	dir := fs.String("dir", "output", "dataset directory to search")
+	endpoint := fs.String("endpoint", "", "search endpoint to query instead of -dir, e.g. http://localhost:8080/search")
+	apiKey := fs.String("api-key", os.Getenv("ICONS_API_KEY"), "API key sent to -endpoint, defaults to $ICONS_API_KEY")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	query := parseInterspersed(fs, args)
+	if query == "" {
+		fmt.Fprintln(os.Stderr, "usage: search [flags] <query>")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	opts := icons.SearchOptions{Provider: *provider, Limit: *limit}
+	var (
+		results []icons.SearchResult
+		err     error
+	)
+	if *endpoint != "" {
+		results, err = icons.RemoteSearch(context.Background(), *endpoint, *apiKey, query, opts)
+	} else {
+		var d *icons.Dataset
+		if d, err = icons.LoadDataset(*dir); err == nil {
+			results = d.Search(query, opts)
+		}
+	}
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+
+	if *asJSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		e.Encode(results)
+		return 0
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "no icons match %q\n", query)
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCORE\tSLUG\tICONIFY ID\tURL")
+	for _, r := range results {
+		fmt.Fprintf(w, "%.2f\t%s\t%s\t%s\n", r.Score, r.Icon.Slug, r.Icon.IconifyID, r.Icon.URL)
+	}
+	w.Flush()
+	return 0
+}
+
+// parseInterspersed parses the flags of args wherever they appear, so the
+// query may come first, and returns the remaining words as the query
+func parseInterspersed(fs *flag.FlagSet, args []string) string {
+	var words []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
+		}
+		words = append(words, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	return strings.Join(words, " ")
+}
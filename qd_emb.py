@@ -215,7 +215,7 @@ class IconRAGPipeline:
                     "icon_position": icon.get("icon_position", ""),
                     "color_theme": icon.get("color_theme", ""),
                     "popularity": icon.get("popularity", ""),
-                    "last_scraped": icon.get("last_scraped", ""),
+                    "last_modified": icon.get("last_modified", icon.get("last_scraped", "")),
                      # Helper field
                     "search_document": search_doc
                 }
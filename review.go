@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/tf2d2/terrastruct-icons/icons"
+)
+
+// review merges the approved items of a review queue into the overrides
+// file, exiting with 2 when it could not
+func review(args []string) int {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	overrides := fs.String("overrides", "overrides.yaml", "overrides file to merge approved items into")
+	queue := parseInterspersed(fs, args)
+	if queue == "" {
+		queue = "output/review_queue.json"
+	}
+
+	n, err := icons.ApplyReview(queue, *overrides)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	log.Printf("✅ Merged %d reviewed icons into %s", n, *overrides)
+	return 0
+}
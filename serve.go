@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tf2d2/terrastruct-icons/icons"
+)
+
+// serve serves a dataset directory over HTTP: search, paged icons, facet
+// counts, suggestions, lookup_icon, batch resolution, a streaming export,
+// downloads of the corpus files and the curation UI, exiting with 2 when it
+// could not. The dataset is reloaded when a run writes a new corpus. With an
+// API keys file every request but /health needs a key, and saving overrides
+// a curator key. Usage hits of search, lookup_icon and resolve are saved to
+// the usage file on every reload and on shutdown
+func serve(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	overrides := fs.String("overrides", "overrides.yaml", "overrides file the curation UI writes to")
+	keysFile := fs.String("keys", "api_keys.yaml", "API keys file, serving without authentication when missing")
+	cors := fs.String("cors", "", "comma separated origins browsers may call the API from, * for any")
+	cacheControl := fs.String("cache-control", "", "Cache-Control of successful responses without their own, e.g. \"private, max-age=300\"")
+	compress := fs.Bool("compress", true, "gzip responses for clients accepting it")
+	reload := fs.Duration("reload", 10*time.Second, "how often to check the directory for a new corpus, 0 disables reloading")
+	usage := fs.String("usage", "usage.json", "usage stats file hits are added to, empty to not record them")
+	fs.Parse(args)
+	dir := "output"
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	keys, err := icons.LoadAPIKeys(*keysFile)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	protect := func(role string, h http.Handler) http.Handler { return h }
+	if len(keys) > 0 {
+		protect = icons.NewAuthenticator(keys).Require
+		log.Printf("🔑 Loaded %d API keys from %s", len(keys), *keysFile)
+	} else {
+		log.Printf("⚠️  No API keys in %s, serving without authentication", *keysFile)
+	}
+
+	build := func(d *icons.Dataset) http.Handler {
+		mux := http.NewServeMux()
+		mux.Handle("/search", protect(icons.RoleReader, icons.SearchHandler(d)))
+		mux.Handle("/icons", protect(icons.RoleReader, icons.IconsHandler(d)))
+		mux.Handle("/suggest", protect(icons.RoleReader, icons.SuggestHandler(d)))
+		mux.Handle("/facets", protect(icons.RoleReader, icons.FacetsHandler(d)))
+		mux.Handle("/lookup_icon", protect(icons.RoleReader, icons.LookupIconHandler(d)))
+		mux.Handle("/resolve", protect(icons.RoleReader, icons.ResolveHandler(d)))
+		mux.Handle("/export.jsonl", protect(icons.RoleReader, icons.ExportHandler(d)))
+		curate := icons.CurationHandler(d, *overrides)
+		read, write := protect(icons.RoleReader, curate), protect(icons.RoleCurator, curate)
+		mux.Handle("/curate/", http.StripPrefix("/curate", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				read.ServeHTTP(w, r)
+				return
+			}
+			write.ServeHTTP(w, r)
+		})))
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			http.Redirect(w, r, "/curate/", http.StatusFound)
+		})
+		return mux
+	}
+	live, err := icons.NewLiveDataset(dir, build)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	if *usage != "" {
+		if err := live.PersistUsage(*usage); err != nil {
+			log.Printf("❌ %v", err)
+			return 2
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *reload > 0 {
+		go live.Watch(ctx, *reload)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/health", live.HealthHandler())
+	downloads := protect(icons.RoleReader, icons.DownloadHandler(dir))
+	mux.Handle("/dataset.json", downloads)
+	mux.Handle("/providers/", downloads)
+	mux.Handle("/", live)
+	opts := icons.HTTPOptions{CacheControl: *cacheControl, Compress: *compress}
+	for _, origin := range strings.Split(*cors, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			opts.CORSOrigins = append(opts.CORSOrigins, origin)
+		}
+	}
+	srv := &http.Server{Addr: *addr, Handler: opts.Handler(mux), ReadHeaderTimeout: 10 * time.Second}
+	log.Printf("🌐 Serving %d icons from %s, curate them at http://%s/curate/", len(live.Dataset().Icons), dir, *addr)
+	// requests in flight finish before the usage is saved
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	<-drained
+	log.Println("👋 Shut down")
+	if err := live.SaveUsage(); err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	return 0
+}
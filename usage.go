@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/tf2d2/terrastruct-icons/icons"
+)
+
+// importUsage adds the click and query events of a usage log to the usage
+// stats file the next run learns popularity from, exiting with 2 when it
+// could not
+func importUsage(args []string) int {
+	fs := flag.NewFlagSet("import-usage", flag.ExitOnError)
+	dir := fs.String("dir", "output", "dataset directory queries are resolved against")
+	usage := fs.String("usage", "usage.json", "usage stats file to add the hits to")
+	path := parseInterspersed(fs, args)
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "usage: import-usage [flags] <log>")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	d, err := icons.LoadDataset(*dir)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	stats, err := icons.LoadUsage(*usage)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	d.MergeUsage(stats)
+
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	defer f.Close()
+	credited, err := d.ImportUsageLog(f)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	if err := d.Usage().Save(*usage); err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+	log.Printf("📈 Credited %d events of %s to %s", credited, path, *usage)
+	return 0
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/tf2d2/terrastruct-icons/icons"
+)
+
+// validate lints an output directory against validation rules, exiting with
+// 1 when a rule reported as an error is broken and 2 when it could not run
+func validate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	rulesPath := fs.String("rules", "", "YAML validation rules, the default rules when empty")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	strict := fs.Bool("strict", false, "also fail on warnings")
+	dir := parseInterspersed(fs, args)
+	if dir == "" {
+		dir = "output"
+	}
+
+	var rules *icons.ValidationRules
+	if *rulesPath != "" {
+		var err error
+		if rules, err = icons.LoadValidationRules(*rulesPath); err != nil {
+			log.Printf("❌ %v", err)
+			return 2
+		}
+	}
+	report, err := icons.Validate(context.Background(), dir, rules)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 2
+	}
+
+	if *asJSON {
+		e := json.NewEncoder(os.Stdout)
+		e.SetIndent("", "  ")
+		e.Encode(report)
+	} else {
+		for _, issue := range report.Issues {
+			fmt.Printf("%s: %s: %s [%s]\n", issue.Severity, issue.Slug, issue.Message, issue.Rule)
+		}
+		fmt.Printf("%d icons, %d errors, %d warnings\n", report.Icons, report.Errors, report.Warnings)
+	}
+	if report.Failed() || (*strict && report.Warnings > 0) {
+		return 1
+	}
+	return 0
+}